@@ -0,0 +1,7 @@
+module github.com/BernardSimon/etl-go/components/datasource/http
+
+replace github.com/BernardSimon/etl-go/etl/core => ../../../etl/core
+
+go 1.24.4
+
+require github.com/BernardSimon/etl-go/etl/core v0.0.0-00010101000000-000000000000
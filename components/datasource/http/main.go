@@ -0,0 +1,256 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+)
+
+var name = "http"
+
+func SetCustomName(customName string) {
+	name = customName
+}
+
+// DataSource 保存 HTTP 接口的基础地址、认证方式与 TLS 设置。
+//
+// Open 返回一个 *http.Client，它会将相对地址解析到 base_url 下，并为每个请求加上认证头与公共请求头，
+// 因此使用它的组件不需要依赖本包。
+type DataSource struct {
+	client *nethttp.Client
+}
+
+func DatasourceCreator() (string, datasource.Datasource, []params.Params) {
+	return name, &DataSource{}, []params.Params{
+		{
+			Key:          "base_url",
+			Required:     true,
+			DefaultValue: "",
+			Description:  "base url of the api, relative request paths are resolved against it",
+		},
+		{
+			Key:          "auth_type",
+			Required:     false,
+			DefaultValue: "none",
+			Description:  "none, basic, bearer or api_key",
+		},
+		{
+			Key:          "username",
+			Required:     false,
+			DefaultValue: "",
+			Description:  "user of basic auth",
+		},
+		{
+			Key:          "password",
+			Required:     false,
+			DefaultValue: "",
+			Description:  "password of basic auth",
+		},
+		{
+			Key:          "token",
+			Required:     false,
+			DefaultValue: "",
+			Description:  "token of bearer auth, or the key of api_key auth",
+		},
+		{
+			Key:          "api_key_header",
+			Required:     false,
+			DefaultValue: "X-API-Key",
+			Description:  "header carrying the key of api_key auth",
+		},
+		{
+			Key:          "headers",
+			Required:     false,
+			DefaultValue: "",
+			Description:  `headers added to every request as a json object, e.g. {"Accept": "application/json"}`,
+		},
+		{
+			Key:          "timeout",
+			Required:     false,
+			DefaultValue: "30s",
+			Description:  "timeout of a single request",
+		},
+		{
+			Key:          "ca_cert",
+			Required:     false,
+			DefaultValue: "",
+			Description:  "PEM content or path of the CA certificate used to verify the server",
+		},
+		{
+			Key:          "client_cert",
+			Required:     false,
+			DefaultValue: "",
+			Description:  "PEM content or path of the client certificate for mutual TLS",
+		},
+		{
+			Key:          "client_key",
+			Required:     false,
+			DefaultValue: "",
+			Description:  "PEM content or path of the client private key for mutual TLS",
+		},
+		{
+			Key:          "insecure_skip_verify",
+			Required:     false,
+			DefaultValue: "false",
+			Description:  "skip verification of the server certificate",
+		},
+	}
+}
+
+func (d *DataSource) Init(config map[string]string) error {
+	base, err := url.Parse(strings.TrimSpace(config["base_url"]))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return fmt.Errorf("http datasource: invalid 'base_url' %q", config["base_url"])
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		// 保证相对路径解析到 base_url 之下而不是替换它的最后一段
+		base.Path += "/"
+	}
+
+	headers := make(nethttp.Header)
+	if v := strings.TrimSpace(config["headers"]); v != "" {
+		var extra map[string]string
+		if err := json.Unmarshal([]byte(v), &extra); err != nil {
+			return fmt.Errorf("http datasource: 'headers' is not a json object of strings: %w", err)
+		}
+		for key, value := range extra {
+			headers.Set(key, value)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(config["auth_type"])) {
+	case "", "none":
+	case "basic":
+		credentials := config["username"] + ":" + config["password"]
+		headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	case "bearer":
+		if config["token"] == "" {
+			return fmt.Errorf("http datasource: 'token' is required for bearer auth")
+		}
+		headers.Set("Authorization", "Bearer "+config["token"])
+	case "api_key":
+		header := strings.TrimSpace(config["api_key_header"])
+		if header == "" {
+			header = "X-API-Key"
+		}
+		if config["token"] == "" {
+			return fmt.Errorf("http datasource: 'token' is required for api_key auth")
+		}
+		headers.Set(header, config["token"])
+	default:
+		return fmt.Errorf("http datasource: unsupported 'auth_type' %q, expected none, basic, bearer or api_key", config["auth_type"])
+	}
+
+	timeout := 30 * time.Second
+	if v := strings.TrimSpace(config["timeout"]); v != "" {
+		timeout, err = time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("http datasource: invalid 'timeout' %q", v)
+		}
+	}
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return fmt.Errorf("http datasource: %w", err)
+	}
+	transport := nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	d.client = &nethttp.Client{
+		Transport: &baseTransport{base: base, headers: headers, next: transport},
+		Timeout:   timeout,
+	}
+	return nil
+}
+
+func (d *DataSource) Open() any {
+	return d.client
+}
+
+func (d *DataSource) Close() error {
+	if d.client != nil {
+		d.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// baseTransport 将请求的相对地址解析到基础地址下，并加上认证头与公共请求头。
+type baseTransport struct {
+	base    *url.URL
+	headers nethttp.Header
+	next    nethttp.RoundTripper
+}
+
+func (t *baseTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	// RoundTrip 不能修改调用方的请求
+	req = req.Clone(req.Context())
+	if !req.URL.IsAbs() {
+		ref := *req.URL
+		ref.Path = strings.TrimPrefix(ref.Path, "/")
+		req.URL = t.base.ResolveReference(&ref)
+		req.Host = ""
+	}
+	if req.URL.Host == t.base.Host {
+		// 认证信息只发送给 base_url 所在的主机，分页链接指向其他主机时不会泄露
+		for key, values := range t.headers {
+			if req.Header.Get(key) == "" {
+				req.Header[key] = values
+			}
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// newTLSConfig 根据证书配置创建 TLS 配置，证书可以是 PEM 内容或文件路径。
+func newTLSConfig(config map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if strings.EqualFold(strings.TrimSpace(config["insecure_skip_verify"]), "true") {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if v := strings.TrimSpace(config["ca_cert"]); v != "" {
+		pem, err := readPEM(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read 'ca_cert': %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("'ca_cert' contains no valid certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	certValue, keyValue := strings.TrimSpace(config["client_cert"]), strings.TrimSpace(config["client_key"])
+	if certValue != "" || keyValue != "" {
+		certPEM, err := readPEM(certValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read 'client_cert': %w", err)
+		}
+		keyPEM, err := readPEM(keyValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read 'client_key': %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("value is empty")
+	}
+	return os.ReadFile(value)
+}
@@ -10,7 +10,8 @@ import (
 )
 
 type DataSource struct {
-	db *sql.DB
+	db     *sql.DB
+	config map[string]string
 }
 
 var name = "mysql"
@@ -56,6 +57,10 @@ func DatasourceCreator() (string, datasource.Datasource, []params.Params) {
 
 func (d *DataSource) Init(config map[string]string) error {
 	var err error
+	d.config = make(map[string]string, len(config))
+	for k, v := range config {
+		d.config[k] = v
+	}
 	d.db, err = sql.Open("mysql", config["user"]+":"+config["password"]+"@tcp("+config["host"]+":"+config["port"]+")/"+config["database"])
 	if err != nil {
 		return err
@@ -70,6 +75,15 @@ func (d *DataSource) Open() any {
 	return d.db
 }
 
+// Config 返回连接配置，供需要建立 database/sql 之外连接的组件使用，例如读取 binlog 的复制连接。
+func (d *DataSource) Config() map[string]string {
+	config := make(map[string]string, len(d.config))
+	for k, v := range d.config {
+		config[k] = v
+	}
+	return config
+}
+
 func (d *DataSource) Close() error {
 	return d.db.Close()
 }
@@ -34,13 +34,15 @@ func SetCustomNameSqlite(name string, datasourceName string) {
 }
 
 type Executor struct {
-	db         *sql.DB
-	results    sql.Result
-	datasource *datasource.Datasource
+	db          *sql.DB
+	results     sql.Result
+	datasource  *datasource.Datasource
+	placeholder func(n int) string // 绑定任务变量时使用的位置占位符
+	backslash   bool               // 字符串字面量中的反斜杠是否为转义符，只有 MySQL 如此
 }
 
 func ExecutorCreatorMysql() (string, executor.Executor, *string, []params.Params) {
-	return mysqlName, &Executor{}, &mysqlDatasourceName, []params.Params{
+	return mysqlName, &Executor{placeholder: params.QuestionPlaceholder, backslash: true}, &mysqlDatasourceName, []params.Params{
 		{
 			Key:          "sql",
 			Required:     true,
@@ -50,7 +52,7 @@ func ExecutorCreatorMysql() (string, executor.Executor, *string, []params.Params
 	}
 }
 func ExecutorCreatorPostgre() (string, executor.Executor, *string, []params.Params) {
-	return postgreName, &Executor{}, &postgreDatasourceName, []params.Params{
+	return postgreName, &Executor{placeholder: params.DollarPlaceholder}, &postgreDatasourceName, []params.Params{
 		{
 			Key:          "sql",
 			Required:     true,
@@ -60,7 +62,7 @@ func ExecutorCreatorPostgre() (string, executor.Executor, *string, []params.Para
 	}
 }
 func ExecutorCreatorSqlite() (string, executor.Executor, *string, []params.Params) {
-	return sqliteName, &Executor{}, &sqliteDatasourceName, []params.Params{
+	return sqliteName, &Executor{placeholder: params.QuestionPlaceholder}, &sqliteDatasourceName, []params.Params{
 		{
 			Key:          "sql",
 			Required:     true,
@@ -75,10 +77,14 @@ func (s *Executor) Open(config map[string]string, datasource *datasource.Datasou
 	if !ok || query == "" {
 		return fmt.Errorf("sql executor: config is missing or has invalid 'sql'")
 	}
-	var err error
+	// 将 :name 占位符替换为驱动参数，值来自任务变量
+	query, args, err := params.BindNamed(query, config, 0, s.placeholder, s.backslash)
+	if err != nil {
+		return fmt.Errorf("sql executor: %w", err)
+	}
 	s.datasource = datasource
 	s.db = (*s.datasource).Open().(*sql.DB)
-	s.results, err = s.db.Exec(query)
+	s.results, err = s.db.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("sql executor: failed to executor sql: %w", err)
 	}
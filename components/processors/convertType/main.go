@@ -60,23 +60,23 @@ func (p *Processor) Open(config map[string]string) error {
 //   - 为了统一处理来自不同源（如数据库的 int、json 的 float64、csv 的 string）的原始类型，
 //     它首先将输入值格式化为字符串，然后再从字符串解析为目标类型。
 //   - 如果类型转换失败（例如，试图将 "abc" 转换为 integer），将返回一个错误，导致整个管道中止。
-func (p *Processor) Process(record record.Record) (record.Record, error) {
-	originalValue, ok := record[p.column]
+func (p *Processor) Process(r record.Record) (record.Record, error) {
+	originalValue, ok := r[p.column]
 	if !ok {
 		// 如果列不存在，静默忽略。
-		return record, nil
+		return r, nil
 	}
 
 	if originalValue == nil {
 		// 不对 nil 值进行转换。
-		return record, nil
+		return r, nil
 	}
 
 	var convertedValue interface{}
 	var err error
 
 	// 统一转换为字符串，再进行解析，以获得最大的兼容性。
-	valStr := fmt.Sprintf("%v", originalValue)
+	valStr := record.Format(originalValue)
 
 	switch p.toType {
 	case "integer", "int":
@@ -98,9 +98,9 @@ func (p *Processor) Process(record record.Record) (record.Record, error) {
 	}
 
 	// 使用转换后的新值更新记录。
-	record[p.column] = convertedValue
+	r[p.column] = convertedValue
 
-	return record, nil
+	return r, nil
 }
 
 func (p *Processor) Close() error {
@@ -129,8 +129,8 @@ func (p *Processor) compare(recordValue, configValue interface{}) (bool, error)
 	}
 
 	// 如果无法进行数字比较，则进行字符串比较。
-	val1Str := fmt.Sprintf("%v", recordValue)
-	val2Str := fmt.Sprintf("%v", configValue)
+	val1Str := record.Format(recordValue)
+	val2Str := record.Format(configValue)
 
 	switch p.operator {
 	case "=", "==":
@@ -155,10 +155,14 @@ func (p *Processor) toFloat(v interface{}) (float64, error) {
 		return float64(i), nil
 	case int64:
 		return float64(i), nil
+	case uint64:
+		return float64(i), nil
 	case json.Number: // 来自 JSON source 的数字可能是这个类型
 		return i.Float64()
 	case string:
 		return strconv.ParseFloat(i, 64)
+	case []byte:
+		return strconv.ParseFloat(string(i), 64)
 	default:
 		return 0, fmt.Errorf("cannot convert type %T to float64", v)
 	}
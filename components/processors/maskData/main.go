@@ -71,20 +71,20 @@ func (p *Processor) Open(config map[string]string) error {
 //   - **安全警告**: 这是一种单向哈希，不是加密。相同地输入值将始终产生相同地输出哈希值。
 //     这对于保持数据关联性很有用，但也意味着如果攻击者能够猜到原始值（例如，对于低复杂度的输入），
 //     他们可以通过彩虹表攻击来反查。请勿将其用于需要可逆加密的场景。
-func (p *Processor) Process(record record.Record) (record.Record, error) {
-	originalValue, ok := record[p.column]
+func (p *Processor) Process(r record.Record) (record.Record, error) {
+	originalValue, ok := r[p.column]
 	if !ok {
 		// 如果列不存在，静默忽略。
-		return record, nil
+		return r, nil
 	}
 
 	if originalValue == nil {
 		// 不处理 nil 值。
-		return record, nil
+		return r, nil
 	}
 
 	// 将原始值转换为字符串以进行哈希。
-	valStr := fmt.Sprintf("%v", originalValue)
+	valStr := record.Format(originalValue)
 	var maskedValue string
 
 	switch p.method {
@@ -101,9 +101,9 @@ func (p *Processor) Process(record record.Record) (record.Record, error) {
 	}
 
 	// 使用脱敏后的新值更新记录。
-	record[p.column] = maskedValue
+	r[p.column] = maskedValue
 
-	return record, nil
+	return r, nil
 }
 
 // Close 是一个无操作（no-op）方法，因为此处理器是无状态的。
@@ -4,4 +4,9 @@ replace github.com/BernardSimon/etl-go/etl/core => ../../../etl/core
 
 go 1.24.4
 
-require github.com/BernardSimon/etl-go/etl/core v0.0.0-00010101000000-000000000000
+require (
+	github.com/BernardSimon/etl-go/etl/core v0.0.0-00010101000000-000000000000
+	golang.org/x/text v0.32.0
+)
+
+require github.com/klauspost/compress v1.17.9 // indirect
@@ -1,29 +1,47 @@
 package csvSink
 
 import (
-	"encoding/csv"
 	"fmt"
-	"os"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/files"
 	"github.com/BernardSimon/etl-go/etl/core/params"
 	"github.com/BernardSimon/etl-go/etl/core/record"
 	"github.com/BernardSimon/etl-go/etl/core/sink"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
 )
 
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
 // Sink 实现了 core.Sink 接口，用于将数据以 CSV 格式写入文件。
 type Sink struct {
-	ID       string
-	filePath string // 输出文件路径。
-	file     *os.File
-	writer   *csv.Writer // CSV 写入器。
-	header   []string    // 可选的表头字段。
-	written  bool        // 是否已经写入过数据（防止重复写header）
+	ID          string
+	output      *files.Output                                 // 输出文件，负责滚动、分区与打包
+	create      func(name string, ext string) (string, error) // 由引擎传入，创建新的输出文件
+	columnTypes map[string]string                             // 记录键 -> 通用类型，由引擎在 Open 之前传入
+	keys        []string                                      // 按输出顺序排列的记录键
+	header      []string                                      // 与 keys 对应的表头字段，关闭表头时为空
+	delimiter   rune                                          // 字段分隔符
+	quoteAll    bool                                          // 是否为所有字段加引号
+	lineEnding  string                                        // 行结束符
+	encoding    encoding.Encoding                             // 输出编码，UTF-8 时为 nil
+	bom         bool                                          // 是否在文件开头写入 UTF-8 BOM
+	null        string                                        // NULL 的写法
+	dateFormat  string                                        // date 类型列的时间布局
+	timeFormat  string                                        // 其余时间值的时间布局
+	floatPrec   int                                           // 浮点数的小数位数，-1 表示最短表示
 }
 
 // NewSink 是 csv.Sink 的构造函数，由工厂调用。
 func SinkCreator() (string, sink.Sink, *string, []params.Params) {
-	return "csv", &Sink{}, nil, []params.Params{
+	return "csv", &Sink{}, nil, append([]params.Params{
 		{
 			Key:         "file_name",
 			Description: "The name of the output file",
@@ -35,26 +53,174 @@ func SinkCreator() (string, sink.Sink, *string, []params.Params) {
 			DefaultValue: "csv",
 			Required:     true,
 		},
-	}
+		{
+			Key:          "columns",
+			Description:  "comma separated pipeline columns in output order, defaults to all pipeline columns sorted by output name",
+			DefaultValue: "",
+			Required:     false,
+		},
+		{
+			Key:          "header",
+			Description:  "write the column names as the first row of every file",
+			DefaultValue: "true",
+			Required:     false,
+		},
+		{
+			Key:          "delimiter",
+			Description:  "field delimiter, a single character or \\t (tab)",
+			DefaultValue: ",",
+			Required:     false,
+		},
+		{
+			Key:          "quote_all",
+			Description:  "quote every field instead of only fields that need quoting",
+			DefaultValue: "false",
+			Required:     false,
+		},
+		{
+			Key:          "line_ending",
+			Description:  "lf or crlf",
+			DefaultValue: "lf",
+			Required:     false,
+		},
+		{
+			Key:          "encoding",
+			Description:  "output encoding: utf-8, utf-8-bom (for Excel), gbk, gb18030, utf-16 and other WHATWG labels; characters the encoding cannot represent are replaced",
+			DefaultValue: "utf-8",
+			Required:     false,
+		},
+		{
+			Key:          "null_value",
+			Description:  "text written for NULL values",
+			DefaultValue: "",
+			Required:     false,
+		},
+		{
+			Key:          "date_format",
+			Description:  "Go time layout of date columns",
+			DefaultValue: time.DateOnly,
+			Required:     false,
+		},
+		{
+			Key:          "datetime_format",
+			Description:  "Go time layout of the other time values",
+			DefaultValue: "2006-01-02 15:04:05.999999999",
+			Required:     false,
+		},
+		{
+			Key:          "float_precision",
+			Description:  "number of decimal places of floating point values, -1 writes the shortest exact representation",
+			DefaultValue: "-1",
+			Required:     false,
+		},
+	}, files.OutputParams...)
+}
+
+// SetFileCreator 保存引擎传入的文件创建函数，滚动与分区产生的文件通过它创建。
+func (s *Sink) SetFileCreator(create func(name string, ext string) (string, error)) {
+	s.create = create
+}
+
+// SetColumnTypes 记录输出列的类型，用于区分日期与日期时间列。
+func (s *Sink) SetColumnTypes(types map[string]string) {
+	s.columnTypes = types
 }
 
 func (s *Sink) Open(config map[string]string, columnMapping map[string]string, _ *datasource.Datasource) error {
-	filePath, ok := config["file_path"]
-	if !ok {
-		return fmt.Errorf("csv sink: config is missing or has invalid 'file_name'")
+	if len(columnMapping) == 0 {
+		return fmt.Errorf("csv sink: 'column_mapping' cannot be empty")
+	}
+	s.keys = s.keys[:0]
+	for _, key := range strings.Split(config["columns"], ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := columnMapping[key]; !ok {
+			return fmt.Errorf("csv sink: column '%s' in 'columns' is not a pipeline column", key)
+		}
+		s.keys = append(s.keys, key)
+	}
+	if len(s.keys) == 0 {
+		for key := range columnMapping {
+			s.keys = append(s.keys, key)
+		}
+		sort.Slice(s.keys, func(i, j int) bool {
+			return columnMapping[s.keys[i]] < columnMapping[s.keys[j]]
+		})
 	}
-	s.filePath = filePath
+	s.header = s.header[:0]
+	if !strings.EqualFold(strings.TrimSpace(config["header"]), "false") {
+		for _, key := range s.keys {
+			s.header = append(s.header, columnMapping[key])
+		}
+	}
+	if err := s.parseFormat(config); err != nil {
+		return err
+	}
+
 	var err error
-	s.file, err = os.Create(s.filePath)
+	s.output, err = files.NewOutput(config, columnMapping, s.create, s.newEncoder)
 	if err != nil {
-		return fmt.Errorf("csv sink: failed to create/open file: %w", err)
+		return fmt.Errorf("csv sink: %w", err)
+	}
+	return nil
+}
+
+// parseFormat 解析分隔符、引号、行结束符、编码与值的格式。
+func (s *Sink) parseFormat(config map[string]string) error {
+	delimiter := config["delimiter"]
+	switch strings.ToLower(delimiter) {
+	case "":
+		delimiter = ","
+	case `\t`, "tab":
+		delimiter = "\t"
+	}
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return fmt.Errorf("csv sink: invalid 'delimiter' %q, expected a single character other than quotes and line breaks", config["delimiter"])
+	}
+	s.delimiter = r
+	s.quoteAll = strings.EqualFold(strings.TrimSpace(config["quote_all"]), "true")
+
+	switch strings.ToLower(strings.TrimSpace(config["line_ending"])) {
+	case "", "lf", `\n`:
+		s.lineEnding = "\n"
+	case "crlf", `\r\n`:
+		s.lineEnding = "\r\n"
+	default:
+		return fmt.Errorf("csv sink: unsupported 'line_ending' %q, expected lf or crlf", config["line_ending"])
 	}
-	s.writer = csv.NewWriter(s.file)
 
-	for _, key := range columnMapping {
-		s.header = append(s.header, key)
+	name := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(config["encoding"]), "_", "-"))
+	s.bom = name == "utf-8-bom" || name == "utf8-bom" || name == "utf-8-sig"
+	s.encoding = nil
+	if !s.bom {
+		// 输出时 UTF-16 写入 BOM，便于 Excel 识别字节序
+		enc, err := files.LookupEncoding(name, true)
+		if err != nil {
+			return fmt.Errorf("csv sink: %w", err)
+		}
+		s.encoding = enc
 	}
 
+	s.null = config["null_value"]
+	s.dateFormat = config["date_format"]
+	if s.dateFormat == "" {
+		s.dateFormat = time.DateOnly
+	}
+	s.timeFormat = config["datetime_format"]
+	if s.timeFormat == "" {
+		s.timeFormat = "2006-01-02 15:04:05.999999999"
+	}
+	s.floatPrec = -1
+	if v := strings.TrimSpace(config["float_precision"]); v != "" {
+		prec, err := strconv.Atoi(v)
+		if err != nil || prec < -1 {
+			return fmt.Errorf("csv sink: invalid 'float_precision' %q, expected -1 or a non-negative integer", v)
+		}
+		s.floatPrec = prec
+	}
 	return nil
 }
 
@@ -65,35 +231,18 @@ func (s *Sink) Write(ID string, records []record.Record) error {
 		return nil
 	}
 
-	if s.writer == nil {
+	if s.output == nil {
 		return fmt.Errorf("csv sink: writer is not initialized")
 	}
 
-	// 第一次写入时先写 header（如果有）
-	if !s.written && len(s.header) > 0 {
-		if err := s.writer.Write(s.header); err != nil {
-			return fmt.Errorf("csv sink: failed to write header: %w", err)
-		}
-		s.written = true
-	}
-
 	for _, r := range records {
-		// 假设每个 record 都是 map[string]interface{} 或可转换为字符串数组
-		row := make([]string, 0, len(r))
-		for _, key := range s.header {
-			value := ""
-			if v, ok := r[key]; ok {
-				value = fmt.Sprintf("%v", v)
-			}
-			row = append(row, value)
-		}
-		if err := s.writer.Write(row); err != nil {
+		if err := s.output.Write(r); err != nil {
 			return fmt.Errorf("csv sink: failed to write row: %w", err)
 		}
 	}
 
-	s.writer.Flush() // 每批数据后刷新缓冲区
-	if err := s.writer.Error(); err != nil {
+	// 每批数据后刷新缓冲区
+	if err := s.output.Flush(); err != nil {
 		return fmt.Errorf("csv sink: flush error: %w", err)
 	}
 
@@ -102,11 +251,116 @@ func (s *Sink) Write(ID string, records []record.Record) error {
 
 // Close 负责关闭文件句柄并保存元信息。
 func (s *Sink) Close() error {
-	if s.writer != nil {
-		s.writer.Flush()
+	if s.output == nil {
+		return nil
 	}
-	if s.file != nil {
-		return s.file.Close()
+	err := s.output.Close()
+	s.output = nil
+	if err != nil {
+		return fmt.Errorf("csv sink: %w", err)
 	}
 	return nil
 }
+
+// newEncoder 为每个输出文件创建写入器，按需写入 BOM 与表头。
+func (s *Sink) newEncoder(w io.Writer) (files.Encoder, error) {
+	enc := &encoder{sink: s, w: w}
+	if s.encoding != nil {
+		enc.transformer = transform.NewWriter(w, encoding.ReplaceUnsupported(s.encoding.NewEncoder()))
+		enc.w = enc.transformer
+	}
+	if s.bom {
+		if _, err := enc.w.Write(utf8BOM); err != nil {
+			return nil, fmt.Errorf("failed to write BOM: %w", err)
+		}
+	}
+	if len(s.header) > 0 {
+		if err := enc.writeRow(s.header, nil); err != nil {
+			return nil, fmt.Errorf("failed to write header: %w", err)
+		}
+	}
+	return enc, nil
+}
+
+type encoder struct {
+	sink        *Sink
+	w           io.Writer
+	transformer *transform.Writer
+	fields      []string
+	nulls       []bool
+	line        []byte
+}
+
+func (e *encoder) Encode(r record.Record) error {
+	e.fields, e.nulls = e.fields[:0], e.nulls[:0]
+	for _, key := range e.sink.keys {
+		e.fields = append(e.fields, e.sink.format(key, r[key]))
+		e.nulls = append(e.nulls, r[key] == nil)
+	}
+	return e.writeRow(e.fields, e.nulls)
+}
+
+func (e *encoder) Close() error {
+	if e.transformer != nil {
+		return e.transformer.Close()
+	}
+	return nil
+}
+
+// writeRow 写入一行，字段按 encoding/csv 的规则在需要时加引号，quote_all 时除 NULL 以外全部加引号，
+// 以便读取方区分 NULL 与同名的字符串。
+func (e *encoder) writeRow(fields []string, nulls []bool) error {
+	s := e.sink
+	e.line = e.line[:0]
+	for i, field := range fields {
+		if i > 0 {
+			e.line = utf8.AppendRune(e.line, s.delimiter)
+		}
+		quote := s.needsQuotes(field) || s.quoteAll && (nulls == nil || !nulls[i])
+		if !quote {
+			e.line = append(e.line, field...)
+			continue
+		}
+		e.line = append(e.line, '"')
+		e.line = append(e.line, strings.ReplaceAll(field, `"`, `""`)...)
+		e.line = append(e.line, '"')
+	}
+	e.line = append(e.line, s.lineEnding...)
+	_, err := e.w.Write(e.line)
+	return err
+}
+
+// needsQuotes 含有分隔符、引号、换行或以空白开头的字段需要加引号。
+func (s *Sink) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsRune(field, s.delimiter) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return r == ' ' || r == '\t'
+}
+
+// format 将值格式化为字段文本：NULL 写为 null_value，时间与浮点数按配置的格式输出。
+func (s *Sink) format(key string, v any) string {
+	switch v := v.(type) {
+	case nil:
+		return s.null
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		if s.columnTypes[key] == record.TypeDate {
+			return v.Format(s.dateFormat)
+		}
+		return v.Format(s.timeFormat)
+	case float64:
+		return strconv.FormatFloat(v, 'f', s.floatPrec, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', s.floatPrec, 32)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -1,26 +1,41 @@
 package jsonSink
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"sort"
+	"strings"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/files"
 	"github.com/BernardSimon/etl-go/etl/core/params"
 	"github.com/BernardSimon/etl-go/etl/core/record"
 	"github.com/BernardSimon/etl-go/etl/core/sink"
 )
 
-// Sink 实现了 core.Sink 接口，用于将数据以 JSON 格式写入文件。
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// Sink 实现了 core.Sink 接口，用于将数据以 JSON 数组或 NDJSON 格式流式写入文件。
+// JSON 数组在文件创建时写入开始符号，在文件关闭时补上结束符号，所有批次的记录都在同一个数组中。
 type Sink struct {
 	ID       string
-	filePath string        // 输出文件路径。
-	file     *os.File      // 文件句柄。
-	encoder  *json.Encoder // JSON 编码器。
+	output   *files.Output                                 // 输出文件，负责滚动、分区与打包
+	create   func(name string, ext string) (string, error) // 由引擎传入，创建新的输出文件
+	format   string                                        // json 或 ndjson
+	pretty   bool                                          // 是否缩进输出
+	keys     []string                                      // 按输出顺序排列的记录键
+	names    [][]byte                                      // 与 keys 对应、已编码的字段名
+	buf      bytes.Buffer                                  // 编码单条记录的缓冲区
+	indented bytes.Buffer                                  // 缩进后的记录
 }
 
 func SinkCreator() (string, sink.Sink, *string, []params.Params) {
-	return "json", &Sink{}, nil, []params.Params{
+	return "json", &Sink{}, nil, append([]params.Params{
 		{
 			Key:         "file_name",
 			Description: "The name of the output file",
@@ -32,69 +47,203 @@ func SinkCreator() (string, sink.Sink, *string, []params.Params) {
 			DefaultValue: "json",
 			Required:     true,
 		},
-	}
+		{
+			Key:          "format",
+			Description:  "json writes one array of objects, ndjson writes one object per line",
+			DefaultValue: formatJSON,
+			Required:     false,
+		},
+		{
+			Key:          "pretty",
+			Description:  "indent the objects of a json array, ignored by ndjson",
+			DefaultValue: "false",
+			Required:     false,
+		},
+		{
+			Key:          "columns",
+			Description:  "comma separated pipeline columns in output order, defaults to all pipeline columns sorted by output name",
+			DefaultValue: "",
+			Required:     false,
+		},
+	}, files.OutputParams...)
+}
+
+// SetFileCreator 保存引擎传入的文件创建函数，滚动与分区产生的文件通过它创建。
+func (s *Sink) SetFileCreator(create func(name string, ext string) (string, error)) {
+	s.create = create
 }
 
-// Open 打开输出文件并初始化编码器
+// Open 打开输出文件，按列映射确定输出的字段与顺序。
 func (s *Sink) Open(config map[string]string, columnMapping map[string]string, _ *datasource.Datasource) error {
-	filePath, ok := config["file_path"]
-	if !ok {
-		return fmt.Errorf("json sink: config is missing or has invalid 'file_name'")
+	s.format = strings.ToLower(strings.TrimSpace(config["format"]))
+	switch s.format {
+	case "":
+		s.format = formatJSON
+	case formatJSON, formatNDJSON:
+	case "jsonl", "json_lines":
+		s.format = formatNDJSON
+	default:
+		return fmt.Errorf("json sink: unsupported 'format' %q, expected json or ndjson", config["format"])
+	}
+	s.pretty = strings.EqualFold(strings.TrimSpace(config["pretty"]), "true") && s.format == formatJSON
+
+	if len(columnMapping) == 0 {
+		return fmt.Errorf("json sink: 'column_mapping' cannot be empty")
+	}
+	s.keys = s.keys[:0]
+	for _, key := range strings.Split(config["columns"], ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := columnMapping[key]; !ok {
+			return fmt.Errorf("json sink: column '%s' in 'columns' is not a pipeline column", key)
+		}
+		s.keys = append(s.keys, key)
+	}
+	if len(s.keys) == 0 {
+		for key := range columnMapping {
+			s.keys = append(s.keys, key)
+		}
+		sort.Slice(s.keys, func(i, j int) bool {
+			return columnMapping[s.keys[i]] < columnMapping[s.keys[j]]
+		})
+	}
+	s.names = s.names[:0]
+	for _, key := range s.keys {
+		name, err := json.Marshal(columnMapping[key])
+		if err != nil {
+			return fmt.Errorf("json sink: invalid column name %q: %w", columnMapping[key], err)
+		}
+		s.names = append(s.names, name)
 	}
-	s.filePath = filePath
 
 	var err error
-	s.file, err = os.Create(s.filePath)
+	s.output, err = files.NewOutput(config, columnMapping, s.create, s.newEncoder)
 	if err != nil {
-		return fmt.Errorf("json sink: failed to create/open file: %w", err)
+		return fmt.Errorf("json sink: %w", err)
 	}
-
-	s.encoder = json.NewEncoder(s.file)
-
 	return nil
 }
 
-// Write 将一批记录以 JSON 对象的形式写入文件。
+// Write 将一批记录编码后追加到文件中。
 func (s *Sink) Write(ID string, records []record.Record) error {
 	s.ID = ID
 	if len(records) == 0 {
 		return nil
 	}
 
-	if s.encoder == nil {
-		return fmt.Errorf("json sink: encoder is not initialized")
+	if s.output == nil {
+		return fmt.Errorf("json sink: writer is not initialized")
 	}
 
-	// 写入JSON数组开始符号
-	if _, err := s.file.WriteString("[\n"); err != nil {
-		return fmt.Errorf("json sink: failed to write array start: %w", err)
+	for _, r := range records {
+		if err := s.output.Write(r); err != nil {
+			return fmt.Errorf("json sink: %w", err)
+		}
 	}
+	if err := s.output.Flush(); err != nil {
+		return fmt.Errorf("json sink: %w", err)
+	}
+	return nil
+}
 
-	// 写入每条记录
-	for i, r := range records {
-		if err := s.encoder.Encode(r); err != nil {
-			return fmt.Errorf("json sink: failed to encode/write record: %w", err)
+// encode 按输出顺序将一条记录编码为 JSON 对象，记录中缺少的列写为 null。
+func (s *Sink) encode(r record.Record) error {
+	s.buf.Reset()
+	s.buf.WriteByte('{')
+	for i, key := range s.keys {
+		if i > 0 {
+			s.buf.WriteByte(',')
 		}
-		// 如果不是最后一条记录，添加逗号
-		if i < len(records)-1 {
-			if _, err := s.file.WriteString(",\n"); err != nil {
-				return fmt.Errorf("json sink: failed to write separator: %w", err)
-			}
+		s.buf.Write(s.names[i])
+		s.buf.WriteByte(':')
+		value, err := json.Marshal(r[key])
+		if err != nil {
+			return fmt.Errorf("failed to encode column '%s': %w", key, err)
 		}
+		s.buf.Write(value)
 	}
+	s.buf.WriteByte('}')
+	return nil
+}
 
-	// 写入JSON数组结束符号
-	if _, err := s.file.WriteString("\n]"); err != nil {
-		return fmt.Errorf("json sink: failed to write array end: %w", err)
+// Close 关闭全部输出文件，JSON 数组在此补上结束符号。
+func (s *Sink) Close() error {
+	if s.output == nil {
+		return nil
 	}
+	err := s.output.Close()
+	s.output = nil
+	if err != nil {
+		return fmt.Errorf("json sink: %w", err)
+	}
+	return nil
+}
+
+// newEncoder 为每个输出文件创建写入器，JSON 数组以开始符号开头。
+func (s *Sink) newEncoder(w io.Writer) (files.Encoder, error) {
+	if s.format == formatJSON {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, fmt.Errorf("failed to write array start: %w", err)
+		}
+	}
+	return &encoder{sink: s, w: w}, nil
+}
 
+// encoder 向一个输出文件写入记录，记录数用于决定数组中的分隔符。
+type encoder struct {
+	sink  *Sink
+	w     io.Writer
+	count int
+}
+
+func (e *encoder) Encode(r record.Record) error {
+	s := e.sink
+	if err := s.encode(r); err != nil {
+		return err
+	}
+	object := s.buf.Bytes()
+	var separator string
+	switch {
+	case s.format == formatNDJSON:
+	case e.count == 0:
+		separator = "\n"
+	default:
+		separator = ",\n"
+	}
+	if s.pretty {
+		s.indented.Reset()
+		if err := json.Indent(&s.indented, object, "  ", "  "); err != nil {
+			return fmt.Errorf("failed to indent record: %w", err)
+		}
+		object = s.indented.Bytes()
+		separator += "  "
+	}
+	if s.format == formatNDJSON {
+		object = append(object, '\n')
+	}
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return fmt.Errorf("failed to write separator: %w", err)
+	}
+	if _, err := e.w.Write(object); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	e.count++
 	return nil
 }
 
-// Close 负责关闭文件句柄并保存元信息。
-func (s *Sink) Close() error {
-	if s.file != nil {
-		return s.file.Close()
+// Close 写入 JSON 数组的结束符号。
+func (e *encoder) Close() error {
+	if e.sink.format != formatJSON {
+		return nil
+	}
+	end := "]\n"
+	if e.count > 0 {
+		end = "\n]\n"
+	}
+	if _, err := io.WriteString(e.w, end); err != nil {
+		return fmt.Errorf("failed to write array end: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,300 @@
+package parquetSink
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	pq "github.com/parquet-go/parquet-go"
+)
+
+// column 是 schema 中的一列以及将记录中的值转换为该列 Parquet 值的方式。
+type column struct {
+	name    string
+	kind    string // 通用类型名，定点数为 decimal
+	scale   int    // 定点数的小数位
+	index   int    // 在 schema 中的列序号
+	decimal bool   // 是否以 INT64 存储的定点数，精度超过 18 位的定点数按字符串存储
+}
+
+func newColumn(name string, kind string, index int) column {
+	c := column{name: name, kind: kind, index: index}
+	if precision, scale, ok := decimalSize(kind); ok {
+		c.kind = record.TypeDecimal
+		c.scale = scale
+		c.decimal = precision <= 18
+	}
+	return c
+}
+
+// nodeOf 返回通用类型对应的 Parquet 叶子节点。
+func nodeOf(kind string) pq.Node {
+	if precision, scale, ok := decimalSize(kind); ok {
+		if precision <= 18 {
+			return pq.Decimal(scale, precision, pq.Int64Type)
+		}
+		return pq.String()
+	}
+	switch kind {
+	case record.TypeInteger:
+		return pq.Int(64)
+	case record.TypeFloat:
+		return pq.Leaf(pq.DoubleType)
+	case record.TypeBoolean:
+		return pq.Leaf(pq.BooleanType)
+	case record.TypeDate:
+		return pq.Date()
+	case record.TypeDatetime:
+		return pq.Timestamp(pq.Microsecond)
+	case record.TypeBytes:
+		return pq.Leaf(pq.ByteArrayType)
+	default:
+		return pq.String()
+	}
+}
+
+// decimalSize 从 "decimal(精度,小数位)" 中取出精度与小数位。
+func decimalSize(kind string) (precision int, scale int, ok bool) {
+	if !strings.HasPrefix(kind, record.TypeDecimal) {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(kind, record.TypeDecimal+"(%d,%d)", &precision, &scale); err != nil || precision <= 0 {
+		return 0, 0, false
+	}
+	return precision, scale, true
+}
+
+// inferKind 根据样本中该列第一个非空值的 Go 类型推断列类型。
+func inferKind(name string, sample []record.Record) string {
+	for _, r := range sample {
+		switch r[name].(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+			return record.TypeInteger
+		case float32, float64:
+			return record.TypeFloat
+		case bool:
+			return record.TypeBoolean
+		case time.Time:
+			return record.TypeDatetime
+		case []byte:
+			return record.TypeBytes
+		default:
+			return record.TypeString
+		}
+	}
+	return record.TypeString
+}
+
+// value 将记录中的值转换为该列的 Parquet 值。
+func (c column) value(v any) (pq.Value, error) {
+	if v == nil {
+		return pq.NullValue().Level(0, 0, c.index), nil
+	}
+	var value pq.Value
+	switch c.kind {
+	case record.TypeInteger:
+		n, err := toInt64(v)
+		if err != nil {
+			return value, err
+		}
+		value = pq.Int64Value(n)
+	case record.TypeFloat:
+		f, err := toFloat64(v)
+		if err != nil {
+			return value, err
+		}
+		value = pq.DoubleValue(f)
+	case record.TypeBoolean:
+		b, err := toBool(v)
+		if err != nil {
+			return value, err
+		}
+		value = pq.BooleanValue(b)
+	case record.TypeDate:
+		t, err := toTime(v)
+		if err != nil {
+			return value, err
+		}
+		days := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+		value = pq.Int32Value(int32(days))
+	case record.TypeDatetime:
+		t, err := toTime(v)
+		if err != nil {
+			return value, err
+		}
+		value = pq.Int64Value(t.UnixMicro())
+	case record.TypeBytes:
+		value = pq.ByteArrayValue(toBytes(v))
+	case record.TypeDecimal:
+		if !c.decimal {
+			value = pq.ByteArrayValue([]byte(toString(v)))
+			break
+		}
+		n, err := toUnscaled(v, c.scale)
+		if err != nil {
+			return value, err
+		}
+		value = pq.Int64Value(n)
+	default:
+		value = pq.ByteArrayValue([]byte(toString(v)))
+	}
+	return value.Level(0, 1, c.index), nil
+}
+
+func toInt64(v any) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return uintToInt64(uint64(n))
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return uintToInt64(n)
+	case float32:
+		return toInt64(float64(n))
+	case float64:
+		if n != math.Trunc(n) || math.Abs(n) > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", n)
+		}
+		return int64(n), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		text := strings.TrimSpace(toString(v))
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not an integer", text)
+		}
+		return parsed, nil
+	}
+}
+
+func uintToInt64(n uint64) (int64, error) {
+	if n > math.MaxInt64 {
+		return 0, fmt.Errorf("value %d overflows int64", n)
+	}
+	return int64(n), nil
+}
+
+func toFloat64(v any) (float64, error) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		i, err := toInt64(n)
+		return float64(i), err
+	default:
+		text := strings.TrimSpace(toString(v))
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not a number", text)
+		}
+		return parsed, nil
+	}
+}
+
+func toBool(v any) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		i, err := toInt64(b)
+		return i != 0, err
+	default:
+		text := strings.TrimSpace(toString(v))
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return false, fmt.Errorf("value %q is not a boolean", text)
+		}
+		return parsed, nil
+	}
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func toTime(v any) (time.Time, error) {
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+	text := strings.TrimSpace(toString(v))
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("value %q is not a date or time", text)
+}
+
+// toUnscaled 将定点数转换为按小数位放大后的整数，小数位超过列定义时报错而不是静默截断。
+func toUnscaled(v any, scale int) (int64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(toString(v)))
+	if !ok {
+		return 0, fmt.Errorf("value %q is not a decimal", toString(v))
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("value %q has more than %d decimal places", toString(v), scale)
+	}
+	n := r.Num()
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("value %q overflows the decimal column", toString(v))
+	}
+	return n.Int64(), nil
+}
+
+func toBytes(v any) []byte {
+	if b, ok := v.([]byte); ok {
+		return b
+	}
+	return []byte(toString(v))
+}
+
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case time.Time:
+		return s.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case map[string]any, []any:
+		data, err := json.Marshal(s)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,190 @@
+package parquetSink
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/sink"
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// Sink 实现了 core.Sink 与 core.SchemaAware 接口，用于将数据写入 Parquet 文件。
+//
+// schema 由管道的输出列生成：引擎提供了列类型时按类型建列，否则按第一批数据中的值推断，
+// 仍无法确定的列按字符串处理，所有列都是可空的。
+type Sink struct {
+	ID           string
+	filePath     string // 输出文件路径。
+	file         *os.File
+	writer       *pq.Writer
+	header       []string          // 输出列
+	types        map[string]string // 引擎提供的列类型
+	columns      []column          // 按 schema 中的列序号排列
+	codec        compress.Codec
+	rowGroupSize int64
+}
+
+func SinkCreator() (string, sink.Sink, *string, []params.Params) {
+	return "parquet", &Sink{}, nil, []params.Params{
+		{
+			Key:         "file_name",
+			Description: "The name of the output file",
+			Required:    true,
+		},
+		{
+			Key:          "file_ext",
+			Description:  "The extension of the output file",
+			DefaultValue: "parquet",
+			Required:     true,
+		},
+		{
+			Key:          "compression",
+			Description:  "compression codec: snappy, gzip, zstd or none",
+			DefaultValue: "snappy",
+			Required:     false,
+		},
+		{
+			Key:          "row_group_size",
+			Description:  "maximum number of rows in a row group",
+			DefaultValue: "100000",
+			Required:     false,
+		},
+	}
+}
+
+// SetColumnTypes 记录输出列的类型，用于生成 schema。
+func (s *Sink) SetColumnTypes(types map[string]string) {
+	s.types = types
+}
+
+// Open 解析配置并创建输出文件，写入器在确定 schema 后创建。
+func (s *Sink) Open(config map[string]string, columnMapping map[string]string, _ *datasource.Datasource) error {
+	filePath, ok := config["file_path"]
+	if !ok {
+		return fmt.Errorf("parquet sink: config is missing or has invalid 'file_name'")
+	}
+	s.filePath = filePath
+
+	switch strings.ToLower(strings.TrimSpace(config["compression"])) {
+	case "", "snappy":
+		s.codec = &pq.Snappy
+	case "gzip":
+		s.codec = &pq.Gzip
+	case "zstd":
+		s.codec = &pq.Zstd
+	case "none", "uncompressed":
+		s.codec = &pq.Uncompressed
+	default:
+		return fmt.Errorf("parquet sink: unsupported 'compression' %q, expected snappy, gzip, zstd or none", config["compression"])
+	}
+	s.rowGroupSize = 100000
+	if v := strings.TrimSpace(config["row_group_size"]); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("parquet sink: invalid 'row_group_size' %q", v)
+		}
+		s.rowGroupSize = parsed
+	}
+
+	s.header = s.header[:0]
+	resolved := make(map[string]string, len(columnMapping))
+	for key, value := range columnMapping {
+		s.header = append(s.header, value)
+		if t, ok := s.types[key]; ok {
+			resolved[value] = t
+		} else if t, ok := s.types[value]; ok {
+			resolved[value] = t
+		}
+	}
+	sort.Strings(s.header)
+	s.types = resolved
+	s.columns = nil
+	s.writer = nil
+
+	var err error
+	s.file, err = os.Create(s.filePath)
+	if err != nil {
+		return fmt.Errorf("parquet sink: failed to create/open file: %w", err)
+	}
+	return nil
+}
+
+// Write 将一批记录转换为 Parquet 行并写入，写入器按 row_group_size 切分行组。
+func (s *Sink) Write(ID string, records []record.Record) error {
+	s.ID = ID
+	if len(records) == 0 {
+		return nil
+	}
+	if s.file == nil {
+		return fmt.Errorf("parquet sink: file is not initialized")
+	}
+	if s.writer == nil {
+		s.createWriter(records)
+	}
+
+	rows := make([]pq.Row, len(records))
+	for i, r := range records {
+		row := make(pq.Row, len(s.columns))
+		for _, c := range s.columns {
+			value, err := c.value(r[c.name])
+			if err != nil {
+				return fmt.Errorf("parquet sink: column '%s': %w", c.name, err)
+			}
+			row[c.index] = value
+		}
+		rows[i] = row
+	}
+	if _, err := s.writer.WriteRows(rows); err != nil {
+		return fmt.Errorf("parquet sink: failed to write rows: %w", err)
+	}
+	return nil
+}
+
+// createWriter 生成 schema 并创建写入器，没有类型的列根据 sample 中第一个非空值推断类型。
+func (s *Sink) createWriter(sample []record.Record) {
+	group := make(pq.Group, len(s.header))
+	kinds := make(map[string]string, len(s.header))
+	for _, name := range s.header {
+		kind, ok := s.types[name]
+		if !ok {
+			kind = inferKind(name, sample)
+		}
+		kinds[name] = kind
+		group[name] = pq.Optional(nodeOf(kind))
+	}
+	schema := pq.NewSchema("etl", group)
+	s.columns = make([]column, 0, len(s.header))
+	for _, name := range s.header {
+		leaf, _ := schema.Lookup(name)
+		s.columns = append(s.columns, newColumn(name, kinds[name], leaf.ColumnIndex))
+	}
+	s.writer = pq.NewWriter(s.file, schema, pq.Compression(s.codec), pq.MaxRowsPerRowGroup(s.rowGroupSize))
+}
+
+// Close 写入文件尾部的元数据并关闭文件。
+func (s *Sink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	var err error
+	if s.writer == nil {
+		// 没有任何记录时仍然输出只包含 schema 的文件
+		s.createWriter(nil)
+	}
+	if closeErr := s.writer.Close(); closeErr != nil {
+		err = fmt.Errorf("parquet sink: failed to write file footer: %w", closeErr)
+	}
+	if closeErr := s.file.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("parquet sink: failed to close file: %w", closeErr)
+	}
+	s.writer = nil
+	s.file = nil
+	return err
+}
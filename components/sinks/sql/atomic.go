@@ -0,0 +1,308 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+)
+
+const (
+	atomicNone          = "none"
+	atomicTransaction   = "transaction"
+	atomicStagingSwap   = "staging_swap"
+	atomicStagingInsert = "staging_insert"
+
+	stagingSuffix = "_etl_staging"
+	oldSuffix     = "_etl_old"
+	savepointName = "etl_go_batch"
+)
+
+var atomicParams = []params.Params{
+	{
+		Key:          "atomic_mode",
+		Required:     false,
+		DefaultValue: atomicNone,
+		Description: "none commits every batch, transaction keeps one transaction for the whole run, " +
+			"staging_swap loads a staging table and swaps it with the table, staging_insert loads a staging table and " +
+			"moves its rows into the table, replacing rows with the same key_columns or all rows without key_columns; " +
+			"the table only changes when the whole run succeeds",
+	},
+	{
+		Key:          "staging_table",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "staging table of the staging modes, defaults to the table name with suffix " + stagingSuffix,
+	},
+}
+
+// parseAtomicMode 解析原子加载模式，需要在处理表结构之前调用，以便拒绝与之冲突的 prepare_mode。
+func (s *Sink) parseAtomicMode(config map[string]string) error {
+	s.atomic = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(config["atomic_mode"])), "-", "_")
+	if s.atomic == "" {
+		s.atomic = atomicNone
+	}
+	s.staging = ""
+	switch s.atomic {
+	case atomicNone, atomicTransaction:
+		return nil
+	case atomicStagingSwap, atomicStagingInsert:
+	default:
+		return fmt.Errorf("sql sink: unsupported 'atomic_mode' %q, expected none, transaction, staging_swap or staging_insert", config["atomic_mode"])
+	}
+	if s.seen != nil {
+		return fmt.Errorf("sql sink: write_mode sync cannot be used with atomic_mode %s, the staging modes already replace the table", s.atomic)
+	}
+	if s.mode == modeUpdateOnly || s.mode == modeScd2 {
+		return fmt.Errorf("sql sink: write_mode %s cannot be used with atomic_mode %s, the staging table starts empty", s.mode, s.atomic)
+	}
+	if prepare := strings.ToLower(strings.TrimSpace(config["prepare_mode"])); prepare != "" && prepare != prepareNone {
+		return fmt.Errorf("sql sink: prepare_mode %s cannot be used with atomic_mode %s", prepare, s.atomic)
+	}
+	return nil
+}
+
+// beginAtomic 在表准备完成后开始原子加载：开启整个运行共用的事务，或重新创建暂存表并将写入转向暂存表。
+func (s *Sink) beginAtomic(config map[string]string) error {
+	switch s.atomic {
+	case atomicTransaction:
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("sql sink: failed to begin transaction: %w", err)
+		}
+		s.tx = tx
+	case atomicStagingSwap, atomicStagingInsert:
+		staging := strings.TrimSpace(config["staging_table"])
+		if staging == "" {
+			staging = s.table + stagingSuffix
+		}
+		if _, err := s.db.Exec("DROP TABLE IF EXISTS " + table(s.dialect, staging)); err != nil {
+			return fmt.Errorf("sql sink: failed to drop staging table %s: %w", staging, err)
+		}
+		s.staging = staging
+		if err := s.dialect.cloneTable(s.db, s.table, staging); err != nil {
+			return fmt.Errorf("sql sink: failed to create staging table %s: %w", staging, err)
+		}
+		s.target, s.table = s.table, staging
+	}
+	return nil
+}
+
+// Commit 在整个运行成功后生效本次加载：sync 模式先删除没有出现过的主键，再提交共用的事务，或以暂存表替换、合并目标表。
+// 没有写入任何批次时，留待第一个批次执行的清空或重建表语句在这里执行。
+// 引擎只在 Source 完整读取、没有被取消时调用 Commit；有批次写入失败时同样拒绝提交，sync 模式不会删除任何行。
+func (s *Sink) Commit() error {
+	if s.failed {
+		return fmt.Errorf("sql sink: a batch failed to write, refusing to commit")
+	}
+	if s.seen != nil {
+		err := s.inTransaction(s.deleteUnseen)
+		if closeErr := s.seen.close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("sql sink: failed to remove seen key files: %w", closeErr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	switch s.atomic {
+	case atomicTransaction:
+		if s.tx == nil {
+			return fmt.Errorf("sql sink: transaction is not open")
+		}
+		if err := s.execPending(s.tx); err != nil {
+			return err
+		}
+		tx := s.tx
+		s.tx = nil
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("sql sink: failed to commit transaction: %w", err)
+		}
+		s.pending = nil
+		return nil
+	case atomicStagingSwap:
+		old := s.target + oldSuffix
+		if _, err := s.db.Exec("DROP TABLE IF EXISTS " + table(s.dialect, old)); err != nil {
+			return fmt.Errorf("sql sink: failed to drop table %s: %w", old, err)
+		}
+		if err := s.inTransaction(s.execAll(s.dialect.swapStatements(s.target, s.staging, old))); err != nil {
+			return fmt.Errorf("sql sink: failed to swap staging table %s with %s: %w", s.staging, s.target, err)
+		}
+		s.staging = ""
+		return nil
+	case atomicStagingInsert:
+		return s.mergeStaging()
+	}
+	if len(s.pending) > 0 {
+		return s.inTransaction(func(*sql.Tx) error { return nil })
+	}
+	return nil
+}
+
+// mergeStaging 在一个事务中删除目标表中将被替换的行，再把暂存表的行插入目标表，最后删除暂存表。
+func (s *Sink) mergeStaging() error {
+	target := table(s.dialect, s.target)
+	staging := table(s.dialect, s.staging)
+	statements := []string{"DELETE FROM " + target}
+	if len(s.keyColumns) > 0 {
+		keys := strings.Join(s.quoteAll(s.keyColumns), ", ")
+		statements[0] = fmt.Sprintf("DELETE FROM %s WHERE (%s) IN (SELECT %s FROM %s)", target, keys, keys, staging)
+	}
+	columns := strings.Join(s.quoteAll(s.allColumns()), ", ")
+	statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", target, columns, columns, staging))
+	// MySQL 的 DROP TABLE 会隐式提交事务，放到提交之后执行
+	if s.dialect.transactionalDDL() {
+		statements = append(statements, "DROP TABLE "+staging)
+	}
+	if err := s.inTransaction(s.execAll(statements)); err != nil {
+		return fmt.Errorf("sql sink: failed to move rows from staging table %s into %s: %w", s.staging, s.target, err)
+	}
+	if !s.dialect.transactionalDDL() {
+		if _, err := s.db.Exec("DROP TABLE " + staging); err != nil {
+			return fmt.Errorf("sql sink: rows were moved into %s but staging table %s could not be dropped: %w", s.target, s.staging, err)
+		}
+	}
+	s.staging = ""
+	return nil
+}
+
+// Rollback 在运行失败后放弃本次加载：回滚共用的事务并删除暂存表，目标表保持不变。
+func (s *Sink) Rollback() error {
+	s.pending = nil
+	var err error
+	if s.seen != nil {
+		err = s.seen.close()
+	}
+	if s.tx != nil {
+		if rollbackErr := s.tx.Rollback(); rollbackErr != nil {
+			err = fmt.Errorf("sql sink: failed to roll back transaction: %w", rollbackErr)
+		}
+		s.tx = nil
+	}
+	if s.staging != "" && s.db != nil {
+		if _, dropErr := s.db.Exec("DROP TABLE IF EXISTS " + table(s.dialect, s.staging)); dropErr != nil && err == nil {
+			err = fmt.Errorf("sql sink: failed to drop staging table %s: %w", s.staging, dropErr)
+		}
+		s.staging = ""
+	}
+	return err
+}
+
+// execAll 返回依次执行 statements 的事务函数。
+func (s *Sink) execAll(statements []string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// execPending 执行留待第一个批次的清空或重建表语句。
+func (s *Sink) execPending(tx *sql.Tx) error {
+	for _, statement := range s.pending {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("sql sink: failed to prepare table %s: %w", s.table, err)
+		}
+	}
+	return nil
+}
+
+func (d mysqlDialect) cloneTable(db *sql.DB, tableName string, staging string) error {
+	_, err := db.Exec(fmt.Sprintf("CREATE TABLE %s LIKE %s", table(d, staging), table(d, tableName)))
+	return err
+}
+
+// swapStatements RENAME TABLE 在一条语句中原子地交换两个表名，之后删除旧表。
+func (d mysqlDialect) swapStatements(tableName string, staging string, old string) []string {
+	return []string{
+		fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s", table(d, tableName), table(d, old), table(d, staging), table(d, tableName)),
+		"DROP TABLE " + table(d, old),
+	}
+}
+
+func (d postgreDialect) cloneTable(db *sql.DB, tableName string, staging string) error {
+	_, err := db.Exec(fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING ALL)", table(d, staging), table(d, tableName)))
+	return err
+}
+
+// swapStatements RENAME TO 只接受不带 schema 的新表名，旧表上的视图等依赖对象会阻止删除旧表。
+func (d postgreDialect) swapStatements(tableName string, staging string, old string) []string {
+	_, name := splitTable(tableName)
+	_, oldName := splitTable(old)
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table(d, tableName), d.quote(oldName)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table(d, staging), d.quote(name)),
+		"DROP TABLE " + table(d, old),
+	}
+}
+
+var (
+	sqliteIdentifier  = `(?:"(?:[^"]|"")*"|` + "`[^`]*`" + `|\[[^\]]*\]|[^\s(]+)`
+	sqliteCreateTable = regexp.MustCompile(`(?is)^\s*CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + sqliteIdentifier + `(?:\s*\.\s*` + sqliteIdentifier + `)?`)
+	sqliteCreateIndex = regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?` + sqliteIdentifier + `(?:\s*\.\s*` + sqliteIdentifier + `)?\s+ON\s+` + sqliteIdentifier)
+)
+
+// cloneTable SQLite 没有 CREATE TABLE LIKE，按 sqlite_master 中保存的建表与建索引语句替换表名后重新执行，
+// 索引名加上暂存表名作为前缀以免与原表的索引重名。
+func (d sqliteDialect) cloneTable(db *sql.DB, tableName string, staging string) error {
+	schema, name := splitTable(tableName)
+	if schema == "" {
+		schema = "main"
+	}
+	_, stagingName := splitTable(staging)
+	rows, err := db.Query("SELECT type, name, sql FROM "+d.quote(schema)+".sqlite_master WHERE tbl_name = ? AND type IN ('table', 'index') AND sql IS NOT NULL ORDER BY type = 'index'", name)
+	if err != nil {
+		return err
+	}
+	var statements []string
+	for rows.Next() {
+		var kind, objectName, statement string
+		if err := rows.Scan(&kind, &objectName, &statement); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if kind == "table" {
+			statement = sqliteCreateTable.ReplaceAllLiteralString(statement, "CREATE TABLE "+d.quote(schema)+"."+d.quote(stagingName))
+		} else {
+			unique := ""
+			if match := sqliteCreateIndex.FindStringSubmatch(statement); match != nil {
+				unique = strings.ToUpper(strings.TrimSpace(match[1]))
+				if unique != "" {
+					unique += " "
+				}
+			}
+			statement = sqliteCreateIndex.ReplaceAllLiteralString(statement,
+				"CREATE "+unique+"INDEX "+d.quote(schema)+"."+d.quote(stagingName+"_"+objectName)+" ON "+d.quote(stagingName))
+		}
+		statements = append(statements, statement)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// swapStatements SQLite 的单个写事务已能保证原子性，直接在事务中以暂存表的行替换目标表的行，
+// 目标表的索引与触发器保持不变。暂存表由目标表复制而来，列的顺序一致。
+func (d sqliteDialect) swapStatements(tableName string, staging string, _ string) []string {
+	return []string{
+		"DELETE FROM " + table(d, tableName),
+		fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", table(d, tableName), table(d, staging)),
+		"DROP TABLE " + table(d, staging),
+	}
+}
@@ -0,0 +1,144 @@
+package sql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+func TestSwapStatements(t *testing.T) {
+	tests := []struct {
+		dialect dialect
+		want    []string
+	}{
+		{mysqlDialect{}, []string{
+			"RENAME TABLE `app`.`orders` TO `app`.`orders_etl_old`, `app`.`orders_etl_staging` TO `app`.`orders`",
+			"DROP TABLE `app`.`orders_etl_old`",
+		}},
+		// RENAME TO 的新表名不带 schema
+		{postgreDialect{}, []string{
+			`ALTER TABLE "app"."orders" RENAME TO "orders_etl_old"`,
+			`ALTER TABLE "app"."orders_etl_staging" RENAME TO "orders"`,
+			`DROP TABLE "app"."orders_etl_old"`,
+		}},
+		{sqliteDialect{}, []string{
+			`DELETE FROM "app"."orders"`,
+			`INSERT INTO "app"."orders" SELECT * FROM "app"."orders_etl_staging"`,
+			`DROP TABLE "app"."orders_etl_staging"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.name(), func(t *testing.T) {
+			got := tt.dialect.swapStatements("app.orders", "app.orders"+stagingSuffix, "app.orders"+oldSuffix)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("swapStatements =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestStagingLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		config  map[string]string
+		open    []string // Open 创建暂存表的语句
+		want    []string // 写入与 Commit 的语句
+	}{
+		{
+			name:    "mysql staging_swap",
+			dialect: mysqlDialect{},
+			config:  map[string]string{"atomic_mode": atomicStagingSwap},
+			open:    []string{"DROP TABLE IF EXISTS `t_etl_staging`", "CREATE TABLE `t_etl_staging` LIKE `t`"},
+			want: []string{
+				"BEGIN",
+				"INSERT INTO `t_etl_staging` (`age`, `id`, `name`) VALUES (?, ?, ?)",
+				"COMMIT",
+				"DROP TABLE IF EXISTS `t_etl_old`",
+				"BEGIN",
+				"RENAME TABLE `t` TO `t_etl_old`, `t_etl_staging` TO `t`",
+				"DROP TABLE `t_etl_old`",
+				"COMMIT",
+			},
+		},
+		{
+			name:    "postgre staging_swap",
+			dialect: postgreDialect{},
+			config:  map[string]string{"atomic_mode": atomicStagingSwap, "staging_table": "public.t_load"},
+			open:    []string{`DROP TABLE IF EXISTS "public"."t_load"`, `CREATE TABLE "public"."t_load" (LIKE "t" INCLUDING ALL)`},
+			want: []string{
+				"BEGIN",
+				`INSERT INTO "public"."t_load" ("age", "id", "name") VALUES ($1, $2, $3)`,
+				"COMMIT",
+				`DROP TABLE IF EXISTS "t_etl_old"`,
+				"BEGIN",
+				`ALTER TABLE "t" RENAME TO "t_etl_old"`,
+				`ALTER TABLE "public"."t_load" RENAME TO "t"`,
+				`DROP TABLE "t_etl_old"`,
+				"COMMIT",
+			},
+		},
+		{
+			// MySQL 的 DROP TABLE 会隐式提交，在合并的事务提交之后执行
+			name:    "mysql staging_insert with key",
+			dialect: mysqlDialect{},
+			config:  map[string]string{"atomic_mode": atomicStagingInsert, "key_columns": "id"},
+			open:    []string{"DROP TABLE IF EXISTS `t_etl_staging`", "CREATE TABLE `t_etl_staging` LIKE `t`"},
+			want: []string{
+				"BEGIN",
+				"INSERT INTO `t_etl_staging` (`age`, `id`, `name`) VALUES (?, ?, ?)",
+				"COMMIT",
+				"BEGIN",
+				"DELETE FROM `t` WHERE (`id`) IN (SELECT `id` FROM `t_etl_staging`)",
+				"INSERT INTO `t` (`age`, `id`, `name`) SELECT `age`, `id`, `name` FROM `t_etl_staging`",
+				"COMMIT",
+				"DROP TABLE `t_etl_staging`",
+			},
+		},
+		{
+			name:    "postgre staging_insert without key",
+			dialect: postgreDialect{},
+			config:  map[string]string{"atomic_mode": atomicStagingInsert},
+			open:    []string{`DROP TABLE IF EXISTS "t_etl_staging"`, `CREATE TABLE "t_etl_staging" (LIKE "t" INCLUDING ALL)`},
+			want: []string{
+				"BEGIN",
+				`INSERT INTO "t_etl_staging" ("age", "id", "name") VALUES ($1, $2, $3)`,
+				"COMMIT",
+				"BEGIN",
+				`DELETE FROM "t"`,
+				`INSERT INTO "t" ("age", "id", "name") SELECT "age", "id", "name" FROM "t_etl_staging"`,
+				`DROP TABLE "t_etl_staging"`,
+				"COMMIT",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			s := openRecorded(t, r, tt.dialect, tt.config)
+			// 第一条语句读取目标表的列
+			if statements, _ := r.take(); len(statements) == 0 || !reflect.DeepEqual(statements[1:], tt.open) {
+				t.Fatalf("Open statements = %q, want the column query followed by %q", statements, tt.open)
+			}
+			if err := s.Write("test", []record.Record{{"id": int64(1), "name": "a", "age": int64(10)}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			checkStatements(t, r, tt.want, nil)
+		})
+	}
+}
+
+func TestStagingRollback(t *testing.T) {
+	r := &recorder{}
+	s := openRecorded(t, r, postgreDialect{}, map[string]string{"atomic_mode": atomicStagingInsert})
+	r.take()
+	if err := s.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, r, []string{`DROP TABLE IF EXISTS "t_etl_staging"`}, nil)
+}
@@ -0,0 +1,157 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// bulkReaderSeq 为每次 LOAD DATA 生成唯一的 reader 名称，驱动的 reader 注册表是全局的。
+var bulkReaderSeq atomic.Uint64
+
+// supportsBulk LOAD DATA 支持 IGNORE 与 REPLACE，其他写入模式回退为 INSERT。
+func (mysqlDialect) supportsBulk(mode string) bool {
+	return mode == modeInsert || mode == modeInsertIgnore || mode == modeReplace
+}
+
+// bulkLoad 通过 LOAD DATA LOCAL INFILE 与注册的 reader 流式导入，数据边编码边发送，不落临时文件。
+// 要求服务端开启 local_infile。LOCAL 导入时主键冲突只产生警告，insert 模式下按影响行数检查，
+// 有行被跳过时返回错误，由调用方回退为 INSERT 以得到与逐批插入一致的报错。
+func (d mysqlDialect) bulkLoad(tx *sql.Tx, tableName string, columns []string, mode string, rows [][]any) error {
+	name := "etl-go-" + strconv.FormatUint(bulkReaderSeq.Add(1), 10)
+	reader, writer := io.Pipe()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return reader })
+	defer mysql.DeregisterReaderHandler(name)
+	go func() {
+		_ = writer.CloseWithError(writeLoadData(writer, rows))
+	}()
+	defer reader.Close()
+
+	var modifier string
+	switch mode {
+	case modeInsertIgnore:
+		modifier = " IGNORE"
+	case modeReplace:
+		modifier = " REPLACE"
+	}
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = d.quote(c)
+	}
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s'%s INTO TABLE %s CHARACTER SET utf8mb4 "+
+		`FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (%s)`,
+		name, modifier, table(d, tableName), strings.Join(quoted, ", "))
+	result, err := tx.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to execute LOAD DATA: %w", err)
+	}
+	if mode == modeInsert {
+		if affected, err := result.RowsAffected(); err == nil && affected != int64(len(rows)) {
+			return fmt.Errorf("LOAD DATA loaded %d of %d rows", affected, len(rows))
+		}
+	}
+	return nil
+}
+
+// writeLoadData 按 LOAD DATA 默认的转义规则将行编码为制表符分隔的文本，NULL 写为 \N。
+func writeLoadData(w io.Writer, rows [][]any) error {
+	var line []byte
+	for _, row := range rows {
+		line = line[:0]
+		for i, v := range row {
+			if i > 0 {
+				line = append(line, '\t')
+			}
+			line = appendLoadDataValue(line, v)
+		}
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendLoadDataValue(buf []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, '\\', 'N')
+	case bool:
+		if v {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	case time.Time:
+		return v.AppendFormat(buf, "2006-01-02 15:04:05.999999")
+	case float64:
+		return strconv.AppendFloat(buf, v, 'f', -1, 64)
+	case float32:
+		return strconv.AppendFloat(buf, float64(v), 'f', -1, 32)
+	case []byte:
+		return appendEscaped(buf, v)
+	case string:
+		return appendEscaped(buf, []byte(v))
+	default:
+		return appendEscaped(buf, []byte(fmt.Sprint(v)))
+	}
+}
+
+func appendEscaped(buf []byte, value []byte) []byte {
+	for _, b := range value {
+		switch b {
+		case '\\':
+			buf = append(buf, '\\', '\\')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case 0:
+			buf = append(buf, '\\', '0')
+		default:
+			buf = append(buf, b)
+		}
+	}
+	return buf
+}
+
+// supportsBulk COPY 只能插入，其他写入模式回退为 INSERT。
+func (postgreDialect) supportsBulk(mode string) bool {
+	return mode == modeInsert
+}
+
+// bulkLoad 通过 COPY FROM STDIN 逐行发送数据，由驱动缓冲后分块写入连接。
+func (postgreDialect) bulkLoad(tx *sql.Tx, tableName string, columns []string, _ string, rows [][]any) error {
+	query := pq.CopyIn(tableName, columns...)
+	if schema, name, ok := strings.Cut(tableName, "."); ok {
+		query = pq.CopyInSchema(schema, name, columns...)
+	}
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("failed to start COPY: %w", err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			return fmt.Errorf("failed to COPY row: %w", err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		return fmt.Errorf("failed to finish COPY: %w", err)
+	}
+	return nil
+}
+
+func (sqliteDialect) supportsBulk(string) bool { return false }
+
+func (sqliteDialect) bulkLoad(*sql.Tx, string, []string, string, [][]any) error {
+	return fmt.Errorf("sqlite does not support bulk load")
+}
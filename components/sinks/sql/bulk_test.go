@@ -0,0 +1,173 @@
+package sql
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+func TestAppendEscaped(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "hello", "hello"},
+		{"tab", "a\tb", `a\tb`},
+		{"newline", "a\nb", `a\nb`},
+		{"carriage return", "a\rb", `a\rb`},
+		{"backslash", `a\b`, `a\\b`},
+		{"nul", "a\x00b", `a\0b`},
+		{"null token stays text", `\N`, `\\N`},
+		{"utf-8", "张三", "张三"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(appendEscaped(nil, []byte(tt.value))); got != tt.want {
+				t.Errorf("appendEscaped(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendLoadDataValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil", nil, `\N`},
+		{"true", true, "1"},
+		{"false", false, "0"},
+		{"int", int64(-42), "-42"},
+		{"float", 1e21, "1000000000000000000000"},
+		{"float32", float32(1.5), "1.5"},
+		{"time", time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC), "2024-01-02 03:04:05.123456"},
+		{"bytes", []byte("a\tb"), `a\tb`},
+		{"string", "a\\b\n", `a\\b\n`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(appendLoadDataValue(nil, tt.value)); got != tt.want {
+				t.Errorf("appendLoadDataValue(%#v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLoadData(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]any{
+		{int64(1), "a\tb", nil},
+		{int64(2), "", true},
+	}
+	if err := writeLoadData(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\ta\\tb\t\\N\n2\t\t1\n"
+	if buf.String() != want {
+		t.Errorf("writeLoadData = %q, want %q", buf.String(), want)
+	}
+}
+
+func benchRows(n int) [][]any {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := make([][]any, n)
+	for i := range rows {
+		rows[i] = []any{float64(i) * 1.25, created, int64(i), "name\t" + strconv.Itoa(i)}
+	}
+	return rows
+}
+
+func BenchmarkWriteLoadData(b *testing.B) {
+	rows := benchRows(1000)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := writeLoadData(io.Discard, rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchDatasource 将测试中打开的连接作为数据源传给 Sink。
+type benchDatasource struct{ db *sql.DB }
+
+func (d benchDatasource) Init(map[string]string) error { return nil }
+func (d benchDatasource) Open() any                    { return d.db }
+func (d benchDatasource) Close() error                 { return nil }
+
+// benchmarkWrite 以 bulk_mode 开启或关闭的 Sink 每次写入一批 1000 行。
+// 需要通过 ETL_GO_BENCH_MYSQL_DSN 或 ETL_GO_BENCH_POSTGRES_DSN 指定测试库，未设置时跳过；
+// MySQL 的 LOAD DATA 还要求 DSN 带 allowAllFiles=true 或服务端开启 local_infile。
+func benchmarkWrite(b *testing.B, sink *Sink, driver string, env string, bulk bool) {
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		b.Skipf("%s is not set", env)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	sink.SetColumnTypes(map[string]string{
+		"id":      record.TypeInteger,
+		"name":    record.TypeString,
+		"amount":  record.TypeFloat,
+		"created": record.TypeDatetime,
+	})
+	var ds datasource.Datasource = benchDatasource{db: db}
+	config := map[string]string{
+		"table":        "etl_go_bench",
+		"write_mode":   modeInsert,
+		"bulk_mode":    strconv.FormatBool(bulk),
+		"create_table": "true",
+		"prepare_mode": prepareRecreate,
+	}
+	columns := map[string]string{"id": "id", "name": "name", "amount": "amount", "created": "created"}
+	if err := sink.Open(config, columns, &ds); err != nil {
+		b.Fatal(err)
+	}
+	defer sink.Close()
+
+	rows := benchRows(1000)
+	records := make([]record.Record, len(rows))
+	next := 0
+	b.ReportAllocs()
+	for b.Loop() {
+		// 每次写入新的 id，避免依赖表上是否有主键
+		for i, row := range rows {
+			records[i] = record.Record{"amount": row[0], "created": row[1], "id": int64(next), "name": row[3]}
+			next++
+		}
+		if err := sink.Write("bench", records); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if bulk && !sink.bulk {
+		b.Fatal("bulk load failed and fell back to INSERT")
+	}
+}
+
+func BenchmarkBulkLoadMysql(b *testing.B) {
+	benchmarkWrite(b, &Sink{dialect: mysqlDialect{}}, "mysql", "ETL_GO_BENCH_MYSQL_DSN", true)
+}
+
+func BenchmarkInsertMysql(b *testing.B) {
+	benchmarkWrite(b, &Sink{dialect: mysqlDialect{}}, "mysql", "ETL_GO_BENCH_MYSQL_DSN", false)
+}
+
+func BenchmarkBulkLoadPostgre(b *testing.B) {
+	benchmarkWrite(b, &Sink{dialect: postgreDialect{}}, "postgres", "ETL_GO_BENCH_POSTGRES_DSN", true)
+}
+
+func BenchmarkInsertPostgre(b *testing.B) {
+	benchmarkWrite(b, &Sink{dialect: postgreDialect{}}, "postgres", "ETL_GO_BENCH_POSTGRES_DSN", false)
+}
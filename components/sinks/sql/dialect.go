@@ -0,0 +1,279 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+// dialect 封装各数据库在 SQL 语法上的差异，每个注册的 Sink 使用各自的方言。
+type dialect interface {
+	// name 返回方言名称，用于错误信息
+	name() string
+	// quote 为标识符加上引号以处理保留字与大小写
+	quote(identifier string) string
+	// placeholder 返回语句中第 n 个（从 1 开始）参数的占位符
+	placeholder(n int) string
+	// maxParams 返回一条语句最多可绑定的参数个数
+	maxParams() int
+	// columnType 返回 record 包中通用类型对应的列类型，key 表示该列用于主键或索引
+	columnType(kind string, key bool) string
+	// tableColumns 返回表的列名，表不存在时 exists 为 false
+	tableColumns(db *sql.DB, table string) (columns []string, exists bool, err error)
+	// truncate 返回清空表的语句
+	truncate(table string) string
+	// transactionalDDL 返回 DDL 能否在事务中执行并随事务回滚
+	transactionalDDL() bool
+	// insertVerb 返回写入模式对应的插入关键字
+	insertVerb(mode string) string
+	// conflictClause 返回写入模式在冲突时的处理子句，keys 与 updates 为已加引号的列名
+	conflictClause(mode string, keys []string, updates []string) string
+	// supportsBulk 返回写入模式能否使用批量导入
+	supportsBulk(mode string) bool
+	// cloneTable 以 table 的结构（列、主键与索引）创建空的 staging 表
+	cloneTable(db *sql.DB, table string, staging string) error
+	// swapStatements 返回在一个事务中用 staging 替换 table 的语句，old 为替换过程中旧表的临时名称
+	swapStatements(table string, staging string, old string) []string
+	// bulkLoad 以数据库的批量导入方式（COPY、LOAD DATA）写入 rows，列与 rows 中的值一一对应
+	bulkLoad(tx *sql.Tx, table string, columns []string, mode string, rows [][]any) error
+}
+
+// table 为可能带有 schema 前缀的表名加上引号，如 public.orders 生成 "public"."orders"。
+func table(d dialect, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = d.quote(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, ".")
+}
+
+// decimalSize 从 "decimal(精度,小数位)" 中取出精度与小数位，不带精度的 "decimal" 返回 false。
+func decimalSize(kind string) (precision int, scale int, ok bool) {
+	if !strings.HasPrefix(kind, record.TypeDecimal+"(") {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(kind, record.TypeDecimal+"(%d,%d)", &precision, &scale); err != nil || precision <= 0 || scale < 0 || scale > precision {
+		return 0, 0, false
+	}
+	return precision, scale, true
+}
+
+// baseKind 去掉类型中括号内的参数，精度无法解析的定点数按不带精度的 decimal 建列。
+func baseKind(kind string) string {
+	if i := strings.IndexByte(kind, '('); i >= 0 {
+		return kind[:i]
+	}
+	return kind
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) name() string { return dialectMysql }
+
+func (mysqlDialect) quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (mysqlDialect) placeholder(int) string { return "?" }
+
+func (mysqlDialect) maxParams() int { return 65535 }
+
+// columnType 主键与索引列不能使用不定长的 TEXT 与 BLOB，字符串与二进制按 255 长度建列。
+// 定点数超出 MySQL 的上限（精度 65、小数位 30）时按上限建列。
+func (mysqlDialect) columnType(kind string, key bool) string {
+	if precision, scale, ok := decimalSize(kind); ok {
+		return fmt.Sprintf("DECIMAL(%d,%d)", min(precision, 65), min(scale, 30))
+	}
+	switch baseKind(kind) {
+	case record.TypeInteger:
+		return "BIGINT"
+	case record.TypeFloat:
+		return "DOUBLE"
+	case record.TypeDecimal:
+		return "DECIMAL(38,10)"
+	case record.TypeBoolean:
+		return "TINYINT(1)"
+	case record.TypeDate:
+		return "DATE"
+	case record.TypeDatetime:
+		return "DATETIME(6)"
+	case record.TypeBytes:
+		if key {
+			return "VARBINARY(255)"
+		}
+		return "LONGBLOB"
+	default:
+		if key {
+			return "VARCHAR(255)"
+		}
+		return "TEXT"
+	}
+}
+
+func (mysqlDialect) tableColumns(db *sql.DB, table string) ([]string, bool, error) {
+	schema, name := splitTable(table)
+	return queryColumns(db, "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION", schema, name)
+}
+
+func (mysqlDialect) truncate(table string) string { return "TRUNCATE TABLE " + table }
+
+func (mysqlDialect) transactionalDDL() bool { return false }
+
+func (mysqlDialect) insertVerb(mode string) string {
+	switch mode {
+	case modeInsertIgnore:
+		return "INSERT IGNORE INTO"
+	case modeReplace:
+		return "REPLACE INTO"
+	}
+	return "INSERT INTO"
+}
+
+func (mysqlDialect) conflictClause(mode string, keys []string, updates []string) string {
+	if mode != modeUpsert {
+		return ""
+	}
+	if len(updates) == 0 {
+		// 将主键列赋值为自身，效果等同于忽略冲突的行
+		return " ON DUPLICATE KEY UPDATE " + keys[0] + " = " + keys[0]
+	}
+	sets := make([]string, len(updates))
+	for i, c := range updates {
+		sets[i] = c + " = VALUES(" + c + ")"
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+type postgreDialect struct{}
+
+func (postgreDialect) name() string { return dialectPostgre }
+
+func (postgreDialect) quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (postgreDialect) placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (postgreDialect) maxParams() int { return 65535 }
+
+func (postgreDialect) columnType(kind string, _ bool) string {
+	if precision, scale, ok := decimalSize(kind); ok {
+		return fmt.Sprintf("NUMERIC(%d,%d)", precision, scale)
+	}
+	switch baseKind(kind) {
+	case record.TypeInteger:
+		return "BIGINT"
+	case record.TypeFloat:
+		return "DOUBLE PRECISION"
+	case record.TypeDecimal:
+		return "NUMERIC"
+	case record.TypeBoolean:
+		return "BOOLEAN"
+	case record.TypeDate:
+		return "DATE"
+	case record.TypeDatetime:
+		return "TIMESTAMP"
+	case record.TypeBytes:
+		return "BYTEA"
+	default:
+		return "TEXT"
+	}
+}
+
+func (postgreDialect) tableColumns(db *sql.DB, table string) ([]string, bool, error) {
+	schema, name := splitTable(table)
+	return queryColumns(db, "SELECT column_name FROM information_schema.columns WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2 ORDER BY ordinal_position", schema, name)
+}
+
+func (postgreDialect) truncate(table string) string { return "TRUNCATE TABLE " + table }
+
+func (postgreDialect) transactionalDDL() bool { return true }
+
+// insertVerb postgre 没有 REPLACE，replace 由 Sink 先删除同键的行再插入。
+func (postgreDialect) insertVerb(string) string { return "INSERT INTO" }
+
+func (postgreDialect) conflictClause(mode string, keys []string, updates []string) string {
+	return onConflict(mode, keys, updates, "EXCLUDED")
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string { return dialectSqlite }
+
+func (sqliteDialect) quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (sqliteDialect) placeholder(int) string { return "?" }
+
+// maxParams 为 SQLite 3.32 起 SQLITE_MAX_VARIABLE_NUMBER 的默认值。
+func (sqliteDialect) maxParams() int { return 32766 }
+
+func (sqliteDialect) columnType(kind string, _ bool) string {
+	if precision, scale, ok := decimalSize(kind); ok {
+		return fmt.Sprintf("NUMERIC(%d,%d)", precision, scale)
+	}
+	switch baseKind(kind) {
+	case record.TypeInteger, record.TypeBoolean:
+		return "INTEGER"
+	case record.TypeFloat:
+		return "REAL"
+	case record.TypeDecimal:
+		return "NUMERIC"
+	case record.TypeBytes:
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+func (sqliteDialect) tableColumns(db *sql.DB, table string) ([]string, bool, error) {
+	schema, name := splitTable(table)
+	if schema == "" {
+		schema = "main"
+	}
+	return queryColumns(db, "SELECT name FROM pragma_table_info(?, ?) ORDER BY cid", name, schema)
+}
+
+// truncate SQLite 没有 TRUNCATE，不带条件的 DELETE 会被优化为清空表。
+func (sqliteDialect) truncate(table string) string { return "DELETE FROM " + table }
+
+func (sqliteDialect) transactionalDDL() bool { return true }
+
+func (sqliteDialect) insertVerb(mode string) string {
+	switch mode {
+	case modeInsertIgnore:
+		return "INSERT OR IGNORE INTO"
+	case modeReplace:
+		return "INSERT OR REPLACE INTO"
+	}
+	return "INSERT INTO"
+}
+
+func (sqliteDialect) conflictClause(mode string, keys []string, updates []string) string {
+	if mode == modeInsertIgnore {
+		return ""
+	}
+	return onConflict(mode, keys, updates, "excluded")
+}
+
+// onConflict 生成 postgre 与 SQLite 共用的 ON CONFLICT 子句，excluded 为冲突时待插入行的别名。
+func onConflict(mode string, keys []string, updates []string, excluded string) string {
+	switch mode {
+	case modeInsertIgnore:
+		return " ON CONFLICT DO NOTHING"
+	case modeUpsert:
+		if len(updates) == 0 {
+			return " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO NOTHING"
+		}
+		sets := make([]string, len(updates))
+		for i, c := range updates {
+			sets[i] = c + " = " + excluded + "." + c
+		}
+		return " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(sets, ", ")
+	}
+	return ""
+}
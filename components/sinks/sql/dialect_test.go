@@ -0,0 +1,43 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+func TestColumnType(t *testing.T) {
+	tests := []struct {
+		kind    string
+		key     bool
+		mysql   string
+		postgre string
+		sqlite  string
+	}{
+		{record.TypeInteger, false, "BIGINT", "BIGINT", "INTEGER"},
+		{record.TypeFloat, false, "DOUBLE", "DOUBLE PRECISION", "REAL"},
+		{"decimal(10,2)", false, "DECIMAL(10,2)", "NUMERIC(10,2)", "NUMERIC(10,2)"},
+		{"decimal(18,0)", true, "DECIMAL(18,0)", "NUMERIC(18,0)", "NUMERIC(18,0)"},
+		{"decimal(80,40)", false, "DECIMAL(65,30)", "NUMERIC(80,40)", "NUMERIC(80,40)"},
+		{record.TypeDecimal, false, "DECIMAL(38,10)", "NUMERIC", "NUMERIC"},
+		{"decimal(x)", false, "DECIMAL(38,10)", "NUMERIC", "NUMERIC"},
+		{record.TypeBoolean, false, "TINYINT(1)", "BOOLEAN", "INTEGER"},
+		{record.TypeDate, false, "DATE", "DATE", "TEXT"},
+		{record.TypeDatetime, false, "DATETIME(6)", "TIMESTAMP", "TEXT"},
+		{record.TypeBytes, false, "LONGBLOB", "BYTEA", "BLOB"},
+		{record.TypeBytes, true, "VARBINARY(255)", "BYTEA", "BLOB"},
+		{record.TypeString, false, "TEXT", "TEXT", "TEXT"},
+		{record.TypeString, true, "VARCHAR(255)", "TEXT", "TEXT"},
+		{"", false, "TEXT", "TEXT", "TEXT"},
+	}
+	for _, tt := range tests {
+		for _, d := range []struct {
+			dialect dialect
+			want    string
+		}{{mysqlDialect{}, tt.mysql}, {postgreDialect{}, tt.postgre}, {sqliteDialect{}, tt.sqlite}} {
+			if got := d.dialect.columnType(tt.kind, tt.key); got != d.want {
+				t.Errorf("%s columnType(%q, key=%v) = %q, want %q", d.dialect.name(), tt.kind, tt.key, got, d.want)
+			}
+		}
+	}
+}
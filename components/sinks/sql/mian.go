@@ -3,6 +3,7 @@ package sql
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
@@ -24,7 +25,7 @@ var postgreDatasourceName = "postgre"
 
 func SetCustomNamePostgre(customName string, customDatasourceName string) {
 	postgreName = customName
-	mysqlDatasourceName = customDatasourceName
+	postgreDatasourceName = customDatasourceName
 }
 
 type Sink struct {
@@ -32,27 +33,47 @@ type Sink struct {
 	table         string            // 目标表名
 	columnMapping map[string]string // 列映射关系 (Record 中的键 -> 数据库中的列名)
 	datasource    *datasource.Datasource
+	dialect       dialect
+	dbColumns     []string          // 按名称排序的表列
+	recordKeys    []string          // 与 dbColumns 对应的记录键
+	recordKey     map[string]string // 表列 -> 记录键
+	mode          string            // 写入模式
+	keyColumns    []string          // 标识一行的表列
+	updateColumns []string          // upsert 与 update_only 更新的表列
+	bulk          bool              // 是否使用批量导入，失败一次后回退为 INSERT
+	columnTypes   map[string]string // 记录键 -> 通用类型，由引擎在 Open 之前传入
+	pending       []string          // 在第一个批次的事务中执行的清空或重建表语句
+	atomic        string            // 原子加载模式
+	tx            *sql.Tx           // transaction 模式下整个运行共用的事务
+	target        string            // 暂存模式下最终的目标表，此时 table 为暂存表
+	staging       string            // 已创建、尚未替换或删除的暂存表
+	scd           scd               // scd2 模式的配置
+	managed       []string          // 由 Sink 维护、不在列映射中的表列，如 scd2 的版本列
+	managedKind   map[string]string // Sink 维护的列 -> 通用类型
+	seen          *keySet           // sync 模式下本次运行出现过的主键
+	syncMaxDelete float64           // sync 模式允许删除的行数占比上限（百分比）
+	failed        bool              // 是否有批次写入失败，失败后不再提交
 }
 
 func SinkCreatorMysql() (string, sink.Sink, *string, []params.Params) {
-	return mysqlName, &Sink{}, &mysqlDatasourceName, []params.Params{
+	return mysqlName, &Sink{dialect: mysqlDialect{}}, &mysqlDatasourceName, append(append(append(append(append([]params.Params{
 		{
 			Key:          "table",
 			Required:     true,
 			DefaultValue: "",
 			Description:  "sql table name",
 		},
-	}
+	}, writeModeParams...), scdParams...), syncParams...), tableParams...), atomicParams...)
 }
 func SinkCreatorPostgre() (string, sink.Sink, *string, []params.Params) {
-	return postgreName, &Sink{}, &postgreDatasourceName, []params.Params{
+	return postgreName, &Sink{dialect: postgreDialect{}}, &postgreDatasourceName, append(append(append(append(append([]params.Params{
 		{
 			Key:          "table",
 			Required:     true,
 			DefaultValue: "",
 			Description:  "sql table name",
 		},
-	}
+	}, writeModeParams...), scdParams...), syncParams...), tableParams...), atomicParams...)
 }
 
 var sqliteName = "sqlite"
@@ -63,14 +84,14 @@ func SetCustomNameSqlite(customName string, customDatasourceName string) {
 	sqliteDatasourceName = customDatasourceName
 }
 func SinkCreatorSqlite() (string, sink.Sink, *string, []params.Params) {
-	return sqliteName, &Sink{}, &sqliteDatasourceName, []params.Params{
+	return sqliteName, &Sink{dialect: sqliteDialect{}}, &sqliteDatasourceName, append(append(append(append(append([]params.Params{
 		{
 			Key:          "table",
 			Required:     true,
 			DefaultValue: "",
 			Description:  "sql table name",
 		},
-	}
+	}, writeModeParams...), scdParams...), syncParams...), tableParams...), atomicParams...)
 }
 
 // Open 负责解析配置并初始化数据库连接设置
@@ -79,7 +100,16 @@ func (s *Sink) Open(config map[string]string, columnMapping map[string]string, d
 	if len(columnMapping) == 0 {
 		return fmt.Errorf("sql sink: 'column_mapping' cannot be empty")
 	}
+	s.failed = false
 	s.columnMapping = columnMapping
+	s.dbColumns = s.dbColumns[:0]
+	s.recordKey = make(map[string]string, len(columnMapping))
+	for recordKey, dbCol := range columnMapping {
+		s.dbColumns = append(s.dbColumns, dbCol)
+		s.recordKey[dbCol] = recordKey
+	}
+	sort.Strings(s.dbColumns)
+	s.recordKeys = s.recordKeysOf(s.dbColumns)
 
 	// 从 datasource 获取数据库连接
 	if dataSource != nil {
@@ -98,26 +128,96 @@ func (s *Sink) Open(config map[string]string, columnMapping map[string]string, d
 	} else {
 		return fmt.Errorf("sql sink: config is missing required key 'table'")
 	}
+	if err := s.parseWriteMode(config); err != nil {
+		return err
+	}
+	if err := s.parseAtomicMode(config); err != nil {
+		return err
+	}
+	s.bulk = strings.EqualFold(strings.TrimSpace(config["bulk_mode"]), "true") && s.dialect.supportsBulk(s.mode)
 
 	// 验证数据库连接是否存在
 	if s.db == nil {
 		return fmt.Errorf("sql sink: database connection is not available")
 	}
-
-	return nil
+	if err := s.prepareTable(config); err != nil {
+		return err
+	}
+	return s.beginAtomic(config)
 }
 
-// Write 将一批记录通过构建一个大的 INSERT 语句在事务中批量写入数据库
-func (s *Sink) Write(_ string, records []record.Record) error {
+// Write 在一个事务中按写入模式写入一批记录：插入类模式构建批量语句，update_only 逐行更新。
+// 开启 bulk_mode 时先尝试批量导入，失败后以 INSERT 重写该批次，并在之后的批次中不再使用批量导入。
+func (s *Sink) Write(_ string, records []record.Record) (err error) {
 	if len(records) == 0 {
 		return nil
 	}
+	defer func() {
+		if err != nil {
+			s.failed = true
+		}
+	}()
 
 	if s.db == nil {
 		return fmt.Errorf("sql sink: database connection is not open")
 	}
+	if s.seen != nil {
+		if err := s.trackKeys(records); err != nil {
+			return err
+		}
+	}
 
-	// 启动事务
+	if s.bulk {
+		bulkErr := s.inTransaction(func(tx *sql.Tx) error {
+			rows := make([][]any, len(records))
+			for i, r := range records {
+				rows[i] = s.values(r, s.recordKeys)
+			}
+			return s.dialect.bulkLoad(tx, s.table, s.dbColumns, s.mode, rows)
+		})
+		if bulkErr == nil {
+			return nil
+		}
+		s.bulk = false
+		if err := s.inTransaction(s.writeBatch(records)); err != nil {
+			return fmt.Errorf("%w (bulk load failed before falling back to INSERT: %v)", err, bulkErr)
+		}
+		return nil
+	}
+	return s.inTransaction(s.writeBatch(records))
+}
+
+// writeBatch 返回以 INSERT 或 UPDATE 写入 records 的事务函数。
+func (s *Sink) writeBatch(records []record.Record) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		switch s.mode {
+		case modeUpdateOnly:
+			return s.update(tx, records)
+		case modeScd2:
+			return s.writeScd2(tx, records)
+		}
+		// 按方言的参数个数上限拆分为多条语句，在同一个事务中执行
+		size := max(s.dialect.maxParams()/len(s.dbColumns), 1)
+		for start := 0; start < len(records); start += size {
+			chunk := records[start:min(start+size, len(records))]
+			if s.mode == modeReplace && s.dialect.name() == dialectPostgre {
+				if err := s.deleteByKey(tx, chunk); err != nil {
+					return err
+				}
+			}
+			if err := s.insert(tx, chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// inTransaction 在一个事务中执行 fn，fn 返回错误时回滚。transaction 模式下在共用的事务中执行。
+func (s *Sink) inTransaction(fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return s.inSavepoint(fn)
+	}
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("sql sink: failed to begin transaction: %w", err)
@@ -126,48 +226,52 @@ func (s *Sink) Write(_ string, records []record.Record) error {
 	defer func(tx *sql.Tx) {
 		_ = tx.Rollback()
 	}(tx)
-
-	// 根据 column_mapping 准备数据库列名和占位符顺序
-	dbColumns := make([]string, 0, len(s.columnMapping))
-	placeholders := make([]string, 0, len(s.columnMapping))
-	recordKeysInOrder := make([]string, 0, len(s.columnMapping))
-
-	for recordKey, dbCol := range s.columnMapping {
-		dbColumns = append(dbColumns, "`"+dbCol+"`") // 为列名加上反引号以处理保留字
-		placeholders = append(placeholders, "?")
-		recordKeysInOrder = append(recordKeysInOrder, recordKey)
+	if err := s.execPending(tx); err != nil {
+		return err
 	}
-
-	// 构建 SQL 语句
-	// 最终形式: "INSERT INTO [table](file:///Users/szy/Desktop/code/etl-go/components/sinks/sql/main.go#L13-L13) (`col1`, `col2`) VALUES (?, ?), (?, ?), ..."
-	valuePlaceholderGroup := "(" + strings.Join(placeholders, ", ") + ")"
-	allValuePlaceholders := strings.Repeat(valuePlaceholderGroup+",", len(records)-1) + valuePlaceholderGroup
-
-	query := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES %s", s.table, strings.Join(dbColumns, ", "), allValuePlaceholders)
-
-	// 准备所有参数
-	args := make([]interface{}, 0, len(records)*len(s.columnMapping))
-	for _, r := range records {
-		for _, recordKey := range recordKeysInOrder {
-			val, exists := r[recordKey]
-			if !exists {
-				val = nil // 如果记录中缺少该键，则插入 NULL
-			}
-			args = append(args, val)
-		}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	// 如果一切顺利，提交事务
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	s.pending = nil
+	return nil
+}
 
-	// 执行批量插入
-	_, err = tx.Exec(query, args...)
+// inSavepoint 在共用的事务中以保存点执行 fn，fn 返回错误时只回滚到保存点，事务仍可继续使用，
+// 批量导入失败后可以在同一事务中回退为 INSERT。
+func (s *Sink) inSavepoint(fn func(tx *sql.Tx) error) error {
+	if _, err := s.tx.Exec("SAVEPOINT " + savepointName); err != nil {
+		return fmt.Errorf("sql sink: failed to create savepoint: %w", err)
+	}
+	err := s.execPending(s.tx)
+	if err == nil {
+		err = fn(s.tx)
+	}
 	if err != nil {
-		return fmt.Errorf("sql sink: failed to execute batch insert: %w", err)
+		if _, rollbackErr := s.tx.Exec("ROLLBACK TO SAVEPOINT " + savepointName); rollbackErr != nil {
+			return fmt.Errorf("%w (failed to roll back to savepoint: %v)", err, rollbackErr)
+		}
+		return err
 	}
-
-	// 如果一切顺利，提交事务
-	return tx.Commit()
+	if _, err := s.tx.Exec("RELEASE SAVEPOINT " + savepointName); err != nil {
+		return fmt.Errorf("sql sink: failed to release savepoint: %w", err)
+	}
+	s.pending = nil
+	return nil
 }
 
 // Close 负责关闭数据库连接池，释放所有底层连接
 func (s *Sink) Close() error {
+	if s.tx != nil {
+		// 未经 Commit 的共用事务一律回滚
+		_ = s.tx.Rollback()
+		s.tx = nil
+	}
+	if s.seen != nil {
+		_ = s.seen.close()
+	}
 	return (*s.datasource).Close()
 }
@@ -0,0 +1,165 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+)
+
+// recorder 是只记录语句的 database/sql 驱动，用于在没有数据库的情况下检查 Sink 生成的 SQL。
+// 事务的开始与结束记为 BEGIN、COMMIT 与 ROLLBACK。
+type recorder struct {
+	mu         sync.Mutex
+	statements []string
+	args       [][]driver.Value
+	rows       func(query string) [][]driver.Value // 查询返回的行，为空时返回空结果
+	fail       string                              // 以此开头的语句返回错误
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return recorderDriver{r} }
+
+func (r *recorder) record(query string, args []driver.Value) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, query)
+	r.args = append(r.args, args)
+	if r.fail != "" && strings.HasPrefix(query, r.fail) {
+		return fmt.Errorf("recorder: %s failed", r.fail)
+	}
+	return nil
+}
+
+// take 返回目前记录的语句与参数并清空记录。
+func (r *recorder) take() ([]string, [][]driver.Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statements, args := r.statements, r.args
+	r.statements, r.args = nil, nil
+	return statements, args
+}
+
+type recorderDriver struct{ r *recorder }
+
+func (d recorderDriver) Open(string) (driver.Conn, error) { return recorderConn{d.r}, nil }
+
+type recorderConn struct{ r *recorder }
+
+func (c recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return recorderStmt{c.r, query}, nil
+}
+func (c recorderConn) Close() error { return nil }
+func (c recorderConn) Begin() (driver.Tx, error) {
+	return recorderTx{c.r}, c.r.record("BEGIN", nil)
+}
+
+type recorderTx struct{ r *recorder }
+
+func (t recorderTx) Commit() error   { return t.r.record("COMMIT", nil) }
+func (t recorderTx) Rollback() error { return t.r.record("ROLLBACK", nil) }
+
+type recorderStmt struct {
+	r     *recorder
+	query string
+}
+
+func (s recorderStmt) Close() error  { return nil }
+func (s recorderStmt) NumInput() int { return -1 }
+
+func (s recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), s.r.record(s.query, args)
+}
+
+func (s recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.r.record(s.query, args); err != nil {
+		return nil, err
+	}
+	var rows [][]driver.Value
+	if s.r.rows != nil {
+		rows = s.r.rows(s.query)
+	}
+	return &recorderRows{rows: rows}, nil
+}
+
+type recorderRows struct{ rows [][]driver.Value }
+
+func (r *recorderRows) Columns() []string {
+	width := 1
+	if len(r.rows) > 0 {
+		width = len(r.rows[0])
+	}
+	return make([]string, width)
+}
+
+func (r *recorderRows) Close() error { return nil }
+
+func (r *recorderRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// limitedDialect 降低方言的参数个数上限，使少量记录即可覆盖按上限拆分语句的逻辑。
+type limitedDialect struct {
+	dialect
+	params int
+}
+
+func (d limitedDialect) maxParams() int { return d.params }
+
+// recorderDatasource 将 recorder 的连接作为数据源传给 Sink。
+type recorderDatasource struct{ db *sql.DB }
+
+func (d recorderDatasource) Init(map[string]string) error { return nil }
+func (d recorderDatasource) Open() any                    { return d.db }
+func (d recorderDatasource) Close() error                 { return nil }
+
+// openSink 以 recorder 打开 Sink，返回前清空 Open 产生的记录。
+func openSink(t *testing.T, d dialect, config map[string]string) (*Sink, *recorder) {
+	t.Helper()
+	r := &recorder{}
+	s := openRecorded(t, r, d, config)
+	r.take()
+	return s, r
+}
+
+// openRecorded 以 r 的连接打开 Sink，列映射为 id、name、age 到同名的表列，表名默认为 t。
+func openRecorded(t *testing.T, r *recorder, d dialect, config map[string]string) *Sink {
+	t.Helper()
+	db := sql.OpenDB(r)
+	t.Cleanup(func() { _ = db.Close() })
+	var ds datasource.Datasource = recorderDatasource{db: db}
+	s := &Sink{dialect: d}
+	if _, ok := config["table"]; !ok {
+		config["table"] = "t"
+	}
+	if err := s.Open(config, map[string]string{"id": "id", "name": "name", "age": "age"}, &ds); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// checkStatements 比较记录的语句，wantArgs 不为 nil 时同时比较每条语句的参数，返回记录的参数。
+func checkStatements(t *testing.T, r *recorder, want []string, wantArgs [][]driver.Value) [][]driver.Value {
+	t.Helper()
+	statements, args := r.take()
+	if !reflect.DeepEqual(statements, want) {
+		t.Fatalf("statements =\n%s\nwant\n%s", strings.Join(statements, "\n"), strings.Join(want, "\n"))
+	}
+	if wantArgs != nil && !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+	return args
+}
@@ -0,0 +1,345 @@
+package sql
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+var scdParams = []params.Params{
+	{
+		Key:          "scd_tracked_columns",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "comma separated columns whose changes create a new version in write_mode scd2, defaults to all mapped columns except key_columns",
+	},
+	{
+		Key:          "scd_valid_from_column",
+		Required:     false,
+		DefaultValue: "valid_from",
+		Description:  "column holding the time a version became current in write_mode scd2, set to the start time of the run",
+	},
+	{
+		Key:          "scd_valid_to_column",
+		Required:     false,
+		DefaultValue: "valid_to",
+		Description:  "column holding the time a version was replaced in write_mode scd2, NULL for the current version",
+	},
+	{
+		Key:          "scd_current_column",
+		Required:     false,
+		DefaultValue: "is_current",
+		Description:  "boolean column marking the current version in write_mode scd2",
+	},
+	{
+		Key:          "scd_hash_column",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "column storing a SHA-256 hash of the tracked columns in write_mode scd2; when set, changes are detected by comparing hashes instead of column values",
+	},
+}
+
+// scd 保存 scd2 写入模式的配置，版本列由 Sink 维护，不出现在列映射中。
+type scd struct {
+	tracked   []string  // 变化时产生新版本的表列
+	validFrom string    // 版本生效时间列
+	validTo   string    // 版本失效时间列
+	current   string    // 当前版本标记列
+	hash      string    // 跟踪列哈希列，为空时逐列比较
+	now       time.Time // 本次运行的生效时间
+}
+
+// parseScd 解析 scd2 模式的配置，并将版本列登记为 Sink 维护的列。
+func (s *Sink) parseScd(config map[string]string, mapped map[string]bool) error {
+	s.managed = s.managed[:0]
+	s.managedKind = nil
+	if s.mode != modeScd2 {
+		return nil
+	}
+	if len(s.keyColumns) == 0 {
+		return fmt.Errorf("sql sink: 'key_columns' is required by write_mode scd2")
+	}
+	tracked, err := columnList(config["scd_tracked_columns"], mapped, "scd_tracked_columns")
+	if err != nil {
+		return err
+	}
+	if len(tracked) == 0 {
+		keys := make(map[string]bool, len(s.keyColumns))
+		for _, c := range s.keyColumns {
+			keys[c] = true
+		}
+		for _, c := range s.dbColumns {
+			if !keys[c] {
+				tracked = append(tracked, c)
+			}
+		}
+	}
+	if len(tracked) == 0 {
+		return fmt.Errorf("sql sink: write_mode scd2 has no columns to track")
+	}
+	s.scd = scd{
+		tracked:   tracked,
+		validFrom: scdColumn(config, "scd_valid_from_column", "valid_from"),
+		validTo:   scdColumn(config, "scd_valid_to_column", "valid_to"),
+		current:   scdColumn(config, "scd_current_column", "is_current"),
+		hash:      strings.TrimSpace(config["scd_hash_column"]),
+		now:       time.Now().Truncate(time.Microsecond),
+	}
+	s.managedKind = map[string]string{
+		s.scd.validFrom: record.TypeDatetime,
+		s.scd.validTo:   record.TypeDatetime,
+		s.scd.current:   record.TypeBoolean,
+	}
+	s.managed = append(s.managed, s.scd.validFrom, s.scd.validTo, s.scd.current)
+	if s.scd.hash != "" {
+		s.managedKind[s.scd.hash] = record.TypeString
+		s.managed = append(s.managed, s.scd.hash)
+	}
+	if len(s.managedKind) != len(s.managed) {
+		return fmt.Errorf("sql sink: the scd2 columns %s must be distinct", strings.Join(s.managed, ", "))
+	}
+	for _, c := range s.managed {
+		if mapped[c] {
+			return fmt.Errorf("sql sink: scd2 column '%s' is maintained by the sink and cannot be a mapped column", c)
+		}
+	}
+	return nil
+}
+
+func scdColumn(config map[string]string, key string, defaultValue string) string {
+	if c := strings.TrimSpace(config[key]); c != "" {
+		return c
+	}
+	return defaultValue
+}
+
+// allColumns 返回映射的表列与 Sink 维护的列。
+func (s *Sink) allColumns() []string {
+	if len(s.managed) == 0 {
+		return s.dbColumns
+	}
+	return append(append(make([]string, 0, len(s.dbColumns)+len(s.managed)), s.dbColumns...), s.managed...)
+}
+
+// writeScd2 按业务主键比较当前版本：新出现的键插入新版本，跟踪列有变化的键先关闭当前版本再插入新版本，
+// 没有变化的行被跳过。当前版本按批次一次查询，关闭与插入均按方言的参数个数上限拆分为批量语句。
+func (s *Sink) writeScd2(tx *sql.Tx, records []record.Record) error {
+	records = s.lastByKey(records)
+	keyRecordKeys := s.recordKeysOf(s.keyColumns)
+	trackedRecordKeys := s.recordKeysOf(s.scd.tracked)
+
+	currentRows, err := s.currentVersions(tx, records, keyRecordKeys)
+	if err != nil {
+		return err
+	}
+	var changed, inserted []record.Record
+	for _, r := range records {
+		values := s.values(r, trackedRecordKeys)
+		existing, ok := currentRows[scdKey(s.values(r, keyRecordKeys))]
+		switch {
+		case !ok:
+			inserted = append(inserted, r)
+		case s.scd.hash != "":
+			if existing[0] != scdHash(values) {
+				changed = append(changed, r)
+				inserted = append(inserted, r)
+			}
+		case !equalValues(existing, values):
+			changed = append(changed, r)
+			inserted = append(inserted, r)
+		}
+	}
+	if err := s.closeVersions(tx, changed, keyRecordKeys); err != nil {
+		return err
+	}
+	return s.insertVersions(tx, inserted, trackedRecordKeys)
+}
+
+// currentVersions 查询 records 中业务主键的当前版本，返回主键到哈希或跟踪列值的映射。
+func (s *Sink) currentVersions(tx *sql.Tx, records []record.Record, keyRecordKeys []string) (map[string][]any, error) {
+	compared := s.scd.tracked
+	if s.scd.hash != "" {
+		compared = []string{s.scd.hash}
+	}
+	selected := strings.Join(s.quoteAll(append(append([]string{}, s.keyColumns...), compared...)), ", ")
+	keys := strings.Join(s.quoteAll(s.keyColumns), ", ")
+	currentRows := make(map[string][]any, len(records))
+	size := max((s.dialect.maxParams()-1)/len(s.keyColumns), 1)
+	for start := 0; start < len(records); start += size {
+		chunk := records[start:min(start+size, len(records))]
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s AND (%s) IN (%s)", selected, table(s.dialect, s.table),
+			s.dialect.quote(s.scd.current), s.dialect.placeholder(1), keys, s.placeholderGroups(len(chunk), len(s.keyColumns), 2))
+		args := []any{true}
+		for _, r := range chunk {
+			args = append(args, s.values(r, keyRecordKeys)...)
+		}
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("sql sink: failed to query current versions: %w", err)
+		}
+		for rows.Next() {
+			values := make([]any, len(s.keyColumns)+len(compared))
+			pointers := make([]any, len(values))
+			for i := range values {
+				pointers[i] = &values[i]
+			}
+			if err := rows.Scan(pointers...); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("sql sink: failed to read current versions: %w", err)
+			}
+			compare := values[len(s.keyColumns):]
+			if s.scd.hash != "" {
+				compare[0] = normalizeValue(compare[0])
+			}
+			currentRows[scdKey(values[:len(s.keyColumns)])] = compare
+		}
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("sql sink: failed to read current versions: %w", err)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("sql sink: failed to read current versions: %w", err)
+		}
+	}
+	return currentRows, nil
+}
+
+// closeVersions 将 records 中业务主键的当前版本标记为失效。
+func (s *Sink) closeVersions(tx *sql.Tx, records []record.Record, keyRecordKeys []string) error {
+	size := max((s.dialect.maxParams()-3)/len(s.keyColumns), 1)
+	for start := 0; start < len(records); start += size {
+		chunk := records[start:min(start+size, len(records))]
+		query := fmt.Sprintf("UPDATE %s SET %s = %s, %s = %s WHERE %s = %s AND (%s) IN (%s)", table(s.dialect, s.table),
+			s.dialect.quote(s.scd.validTo), s.dialect.placeholder(1), s.dialect.quote(s.scd.current), s.dialect.placeholder(2),
+			s.dialect.quote(s.scd.current), s.dialect.placeholder(3),
+			strings.Join(s.quoteAll(s.keyColumns), ", "), s.placeholderGroups(len(chunk), len(s.keyColumns), 4))
+		args := []any{s.scd.now, false, true}
+		for _, r := range chunk {
+			args = append(args, s.values(r, keyRecordKeys)...)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("sql sink: failed to close current versions: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertVersions 为 records 插入新的当前版本。
+func (s *Sink) insertVersions(tx *sql.Tx, records []record.Record, trackedRecordKeys []string) error {
+	columns := s.allColumns()
+	size := max(s.dialect.maxParams()/len(columns), 1)
+	for start := 0; start < len(records); start += size {
+		chunk := records[start:min(start+size, len(records))]
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table(s.dialect, s.table),
+			strings.Join(s.quoteAll(columns), ", "), s.placeholderGroups(len(chunk), len(columns), 1))
+		args := make([]any, 0, len(chunk)*len(columns))
+		for _, r := range chunk {
+			args = append(args, s.values(r, s.recordKeys)...)
+			args = append(args, s.scd.now, nil, true)
+			if s.scd.hash != "" {
+				args = append(args, scdHash(s.values(r, trackedRecordKeys)))
+			}
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("sql sink: failed to insert new versions: %w", err)
+		}
+	}
+	return nil
+}
+
+// placeholderGroups 生成 rows 组、每组 width 个占位符的 (…), (…) 列表，占位符从第 first 个开始编号。
+func (s *Sink) placeholderGroups(rows int, width int, first int) string {
+	groups := make([]string, rows)
+	placeholders := make([]string, width)
+	n := first
+	for i := range groups {
+		for j := range placeholders {
+			placeholders[j] = s.dialect.placeholder(n)
+			n++
+		}
+		groups[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	return strings.Join(groups, ", ")
+}
+
+// scdKey 将业务主键的值规整为字符串，使数据库返回的值与记录中的值可以相互匹配。
+func scdKey(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%#v", normalizeValue(v))
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+// scdHash 计算跟踪列的哈希，值先按 normalizeValue 规整，NULL 与空字符串区分。
+func scdHash(values []any) string {
+	h := sha256.New()
+	for _, v := range values {
+		if v == nil {
+			h.Write([]byte{0})
+		} else {
+			h.Write([]byte{1})
+			h.Write([]byte(normalizeValue(v).(string)))
+		}
+		h.Write([]byte{0x1f})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// equalValues 逐列比较数据库中当前版本的值与记录中的值。
+func equalValues(existing []any, values []any) bool {
+	for i := range values {
+		a, b := normalizeValue(existing[i]), normalizeValue(values[i])
+		if a == b {
+			continue
+		}
+		// 数值在数据库中的精度可能与记录不同，如 DECIMAL 的 1.5000 与 1.5
+		as, aok := a.(string)
+		bs, bok := b.(string)
+		if !aok || !bok {
+			return false
+		}
+		af, aerr := strconv.ParseFloat(as, 64)
+		bf, berr := strconv.ParseFloat(bs, 64)
+		if aerr != nil || berr != nil || af != bf {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeValue 将驱动返回的值与记录中的值统一为字符串，NULL 保持为 nil：
+// 布尔值写为 1 与 0，时间统一为 UTC，零点的时间只保留日期。
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		v = v.UTC()
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format("2006-01-02 15:04:05.999999")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
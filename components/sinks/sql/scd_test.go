@@ -0,0 +1,117 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+// scdRecords 中 id 1 没有变化，id 2 的 name 有变化，id 3 是新出现的键。
+var scdRecords = []record.Record{
+	{"id": int64(1), "name": "a", "age": int64(10)},
+	{"id": int64(2), "name": "new", "age": int64(20)},
+	{"id": int64(3), "name": "c", "age": int64(30)},
+}
+
+func TestScd2Statements(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		config  map[string]string
+		current [][]driver.Value // 当前版本查询返回的行
+		want    []string
+	}{
+		{
+			name:    "postgre",
+			dialect: postgreDialect{},
+			config:  map[string]string{"scd_tracked_columns": "name"},
+			current: [][]driver.Value{{int64(1), "a"}, {int64(2), "old"}},
+			want: []string{
+				"BEGIN",
+				`SELECT "id", "name" FROM "t" WHERE "is_current" = $1 AND ("id") IN (($2), ($3), ($4))`,
+				`UPDATE "t" SET "valid_to" = $1, "is_current" = $2 WHERE "is_current" = $3 AND ("id") IN (($4))`,
+				`INSERT INTO "t" ("age", "id", "name", "valid_from", "valid_to", "is_current") VALUES ($1, $2, $3, $4, $5, $6), ($7, $8, $9, $10, $11, $12)`,
+				"COMMIT",
+			},
+		},
+		{
+			// 每条语句最多 6 个参数：查询每条 5 个键，关闭每条 3 个键，插入每条 1 行
+			name:    "mysql split by max params",
+			dialect: limitedDialect{mysqlDialect{}, 6},
+			config:  map[string]string{"scd_tracked_columns": "name", "scd_current_column": "current"},
+			current: [][]driver.Value{{int64(1), "a"}, {int64(2), "old"}},
+			want: []string{
+				"BEGIN",
+				"SELECT `id`, `name` FROM `t` WHERE `current` = ? AND (`id`) IN ((?), (?), (?))",
+				"UPDATE `t` SET `valid_to` = ?, `current` = ? WHERE `current` = ? AND (`id`) IN ((?))",
+				"INSERT INTO `t` (`age`, `id`, `name`, `valid_from`, `valid_to`, `current`) VALUES (?, ?, ?, ?, ?, ?)",
+				"INSERT INTO `t` (`age`, `id`, `name`, `valid_from`, `valid_to`, `current`) VALUES (?, ?, ?, ?, ?, ?)",
+				"COMMIT",
+			},
+		},
+		{
+			name:    "sqlite with hash column",
+			dialect: sqliteDialect{},
+			config:  map[string]string{"scd_hash_column": "row_hash"},
+			current: [][]driver.Value{{int64(1), scdHash([]any{int64(10), "a"})}, {int64(2), scdHash([]any{int64(20), "old"})}},
+			want: []string{
+				"BEGIN",
+				`SELECT "id", "row_hash" FROM "t" WHERE "is_current" = ? AND ("id") IN ((?), (?), (?))`,
+				`UPDATE "t" SET "valid_to" = ?, "is_current" = ? WHERE "is_current" = ? AND ("id") IN ((?))`,
+				`INSERT INTO "t" ("age", "id", "name", "valid_from", "valid_to", "is_current", "row_hash") VALUES (?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?)`,
+				"COMMIT",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["write_mode"] = modeScd2
+			tt.config["key_columns"] = "id"
+			s, r := openSink(t, tt.dialect, tt.config)
+			r.rows = func(query string) [][]driver.Value {
+				if strings.HasPrefix(query, "SELECT") {
+					return tt.current
+				}
+				return nil
+			}
+			if err := s.Write("test", scdRecords); err != nil {
+				t.Fatal(err)
+			}
+			args := checkStatements(t, r, tt.want, nil)
+			// 关闭 id 2 的当前版本，为 id 2 与 3 插入新版本
+			if want := []driver.Value{s.scd.now, false, true, int64(2)}; !equalArgs(args[2], want) {
+				t.Errorf("close args = %v, want %v", args[2], want)
+			}
+			var inserted []driver.Value
+			for _, a := range args[3 : len(args)-1] {
+				inserted = append(inserted, a...)
+			}
+			want := []driver.Value{int64(20), int64(2), "new", s.scd.now, nil, true}
+			if s.scd.hash != "" {
+				want = append(want, scdHash([]any{int64(20), "new"}))
+			}
+			want = append(want, int64(30), int64(3), "c", s.scd.now, nil, true)
+			if s.scd.hash != "" {
+				want = append(want, scdHash([]any{int64(30), "c"}))
+			}
+			if !equalArgs(inserted, want) {
+				t.Errorf("insert args = %v, want %v", inserted, want)
+			}
+		})
+	}
+}
+
+// equalArgs 按 normalizeValue 逐个比较参数。
+func equalArgs(got []driver.Value, want []driver.Value) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if normalizeValue(got[i]) != normalizeValue(want[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,305 @@
+package sql
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+const (
+	modeSync = "sync"
+
+	syncBuckets = 64
+)
+
+var syncParams = []params.Params{
+	{
+		Key:          "sync_max_delete_percent",
+		Required:     false,
+		DefaultValue: "20",
+		Description:  "write_mode sync aborts instead of deleting when more than this percentage of the table rows would be deleted, 100 disables the check",
+	},
+	{
+		Key:          "sync_memory_keys",
+		Required:     false,
+		DefaultValue: "1000000",
+		Description:  "number of keys write_mode sync keeps in memory before spilling the seen keys to temporary files",
+	},
+}
+
+// parseSync 解析 sync 模式的配置。sync 按 upsert 写入，同时记录本次运行出现过的主键，
+// 运行成功后删除目标表中没有出现过的主键对应的行。
+func (s *Sink) parseSync(config map[string]string) error {
+	if s.seen != nil {
+		_ = s.seen.close()
+		s.seen = nil
+	}
+	if s.mode != modeSync {
+		return nil
+	}
+	if len(s.keyColumns) == 0 {
+		return fmt.Errorf("sql sink: 'key_columns' is required by write_mode sync")
+	}
+	s.mode = modeUpsert
+	s.syncMaxDelete = 20
+	if v := strings.TrimSpace(config["sync_max_delete_percent"]); v != "" {
+		percent, err := strconv.ParseFloat(v, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return fmt.Errorf("sql sink: invalid 'sync_max_delete_percent' %q, expected a number from 0 to 100", v)
+		}
+		s.syncMaxDelete = percent
+	}
+	limit := 1000000
+	if v := strings.TrimSpace(config["sync_memory_keys"]); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("sql sink: invalid 'sync_memory_keys' %q, expected a positive integer", v)
+		}
+		limit = n
+	}
+	s.seen = &keySet{limit: limit, keys: make(map[string]struct{})}
+	return nil
+}
+
+// trackKeys 记录一批记录的主键。
+func (s *Sink) trackKeys(records []record.Record) error {
+	keyRecordKeys := s.recordKeysOf(s.keyColumns)
+	for _, r := range records {
+		key, ok := syncKey(s.values(r, keyRecordKeys))
+		if !ok {
+			continue
+		}
+		if err := s.seen.add(key); err != nil {
+			return fmt.Errorf("sql sink: failed to record seen keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteUnseen 读取目标表的全部主键，删除本次运行没有出现过的行；待删除的行超过阈值时不删除任何行并返回错误。
+func (s *Sink) deleteUnseen(tx *sql.Tx) error {
+	keys := strings.Join(s.quoteAll(s.keyColumns), ", ")
+	rows, err := tx.Query(fmt.Sprintf("SELECT %s FROM %s", keys, table(s.dialect, s.table)))
+	if err != nil {
+		return fmt.Errorf("sql sink: failed to read keys of table %s: %w", s.table, err)
+	}
+	values := make([]any, len(s.keyColumns))
+	pointers := make([]any, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	total := 0
+	var scanErr error
+	for rows.Next() {
+		if scanErr = rows.Scan(pointers...); scanErr != nil {
+			break
+		}
+		total++
+		if key, ok := syncKey(values); ok {
+			if scanErr = s.seen.check(key); scanErr != nil {
+				break
+			}
+		}
+	}
+	if err := rows.Close(); err != nil && scanErr == nil {
+		scanErr = err
+	}
+	if err := rows.Err(); err != nil && scanErr == nil {
+		scanErr = err
+	}
+	if scanErr != nil {
+		return fmt.Errorf("sql sink: failed to read keys of table %s: %w", s.table, scanErr)
+	}
+
+	missing, err := s.seen.missing()
+	if err != nil {
+		return fmt.Errorf("sql sink: failed to compare seen keys: %w", err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if percent := float64(len(missing)) * 100 / float64(total); percent > s.syncMaxDelete {
+		return fmt.Errorf("sql sink: write_mode sync would delete %d of %d rows (%.1f%%) from table %s, more than sync_max_delete_percent %g",
+			len(missing), total, percent, s.table, s.syncMaxDelete)
+	}
+
+	size := max(s.dialect.maxParams()/len(s.keyColumns), 1)
+	for start := 0; start < len(missing); start += size {
+		chunk := missing[start:min(start+size, len(missing))]
+		query := fmt.Sprintf("DELETE FROM %s WHERE (%s) IN (%s)", table(s.dialect, s.table), keys, s.placeholderGroups(len(chunk), len(s.keyColumns), 1))
+		args := make([]any, 0, len(chunk)*len(s.keyColumns))
+		for _, key := range chunk {
+			var parts []string
+			if err := json.Unmarshal([]byte(key), &parts); err != nil {
+				return fmt.Errorf("sql sink: invalid seen key %s: %w", key, err)
+			}
+			for _, part := range parts {
+				args = append(args, part)
+			}
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("sql sink: failed to delete rows missing from the source: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncKey 将主键的值按 normalizeValue 规整后编码为 JSON 数组，含有 NULL 的主键无法按值匹配，返回 false。
+func syncKey(values []any) (string, bool) {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		normalized := normalizeValue(v)
+		if normalized == nil {
+			return "", false
+		}
+		parts[i] = normalized.(string)
+	}
+	key, _ := json.Marshal(parts)
+	return string(key), true
+}
+
+// keySet 是出现过的主键集合。主键数超过 limit 后按哈希分桶写入临时文件，
+// 比较时逐桶读入内存，内存占用约为一个桶的大小。
+type keySet struct {
+	limit  int
+	keys   map[string]struct{} // 未溢出时的全部主键
+	dir    string              // 溢出后的临时目录
+	seen   []*bucket           // 出现过的主键的分桶
+	target []*bucket           // 目标表主键的分桶
+	unseen []string            // 未溢出时目标表中没有出现过的主键
+}
+
+type bucket struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func (k *keySet) add(key string) error {
+	if k.seen != nil {
+		return k.write(k.seen, key)
+	}
+	k.keys[key] = struct{}{}
+	if len(k.keys) <= k.limit {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "etl-go-sync-")
+	if err != nil {
+		return err
+	}
+	k.dir = dir
+	if k.seen, err = k.buckets("seen"); err != nil {
+		return err
+	}
+	for key := range k.keys {
+		if err := k.write(k.seen, key); err != nil {
+			return err
+		}
+	}
+	k.keys = nil
+	return nil
+}
+
+// check 登记目标表中的一个主键，未溢出时直接判断是否出现过。
+func (k *keySet) check(key string) error {
+	if k.seen == nil {
+		if _, ok := k.keys[key]; !ok {
+			k.unseen = append(k.unseen, key)
+		}
+		return nil
+	}
+	if k.target == nil {
+		var err error
+		if k.target, err = k.buckets("target"); err != nil {
+			return err
+		}
+	}
+	return k.write(k.target, key)
+}
+
+// missing 返回目标表中没有出现过的主键。
+func (k *keySet) missing() ([]string, error) {
+	if k.seen == nil {
+		return k.unseen, nil
+	}
+	var missing []string
+	for i := range k.seen {
+		seen := make(map[string]struct{})
+		if err := k.read(k.seen[i], func(key string) { seen[key] = struct{}{} }); err != nil {
+			return nil, err
+		}
+		if k.target == nil {
+			continue
+		}
+		if err := k.read(k.target[i], func(key string) {
+			if _, ok := seen[key]; !ok {
+				missing = append(missing, key)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+func (k *keySet) buckets(prefix string) ([]*bucket, error) {
+	buckets := make([]*bucket, syncBuckets)
+	for i := range buckets {
+		file, err := os.Create(filepath.Join(k.dir, prefix+"-"+strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+		buckets[i] = &bucket{file: file, writer: bufio.NewWriter(file)}
+	}
+	return buckets, nil
+}
+
+func (k *keySet) write(buckets []*bucket, key string) error {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	b := buckets[h.Sum32()%syncBuckets]
+	if _, err := b.writer.WriteString(key); err != nil {
+		return err
+	}
+	return b.writer.WriteByte('\n')
+}
+
+func (k *keySet) read(b *bucket, fn func(key string)) error {
+	if err := b.writer.Flush(); err != nil {
+		return err
+	}
+	if _, err := b.file.Seek(0, 0); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(b.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// close 删除溢出的临时文件。
+func (k *keySet) close() error {
+	if k.dir == "" {
+		return nil
+	}
+	for _, buckets := range [][]*bucket{k.seen, k.target} {
+		for _, b := range buckets {
+			if b != nil {
+				_ = b.file.Close()
+			}
+		}
+	}
+	err := os.RemoveAll(k.dir)
+	k.dir, k.seen, k.target = "", nil, nil
+	return err
+}
@@ -0,0 +1,242 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+func TestSyncKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []any
+		want   string
+		ok     bool
+	}{
+		{"integer", []any{int64(1)}, `["1"]`, true},
+		{"float matches integer", []any{float64(1)}, `["1"]`, true},
+		{"composite", []any{"a", int64(2)}, `["a","2"]`, true},
+		{"bytes from driver", []any{[]byte("a")}, `["a"]`, true},
+		{"boolean", []any{true}, `["1"]`, true},
+		{"date", []any{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, `["2024-01-02"]`, true},
+		{"separator in value", []any{`a","b`}, `["a\",\"b"]`, true},
+		{"null", []any{"a", nil}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := syncKey(tt.values)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("syncKey(%#v) = %q, %v, want %q, %v", tt.values, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		{"in memory", 10},
+		// 超过 1 个主键后溢出到临时文件
+		{"spilled", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &keySet{limit: tt.limit, keys: make(map[string]struct{})}
+			for _, key := range []string{`["a"]`, `["b"]`, `["c"]`, `["a"]`} {
+				if err := k.add(key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, key := range []string{`["a"]`, `["d"]`, `["c"]`, `["e"]`} {
+				if err := k.check(key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			missing, err := k.missing()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(missing)
+			if want := []string{`["d"]`, `["e"]`}; !reflect.DeepEqual(missing, want) {
+				t.Errorf("missing() = %v, want %v", missing, want)
+			}
+			dir := k.dir
+			if (dir != "") != (tt.limit == 1) {
+				t.Errorf("spill directory = %q with limit %d", dir, tt.limit)
+			}
+			if err := k.close(); err != nil {
+				t.Fatal(err)
+			}
+			if dir != "" {
+				if _, err := os.Stat(dir); !os.IsNotExist(err) {
+					t.Errorf("spill directory %s was not removed: %v", dir, err)
+				}
+			}
+		})
+	}
+}
+
+// syncRecords 是来源中的 id 为 1 与 2 的记录。
+var syncRecords = []record.Record{
+	{"id": int64(1), "name": "a", "age": int64(10)},
+	{"id": int64(2), "name": "b", "age": int64(20)},
+}
+
+// tableKeys 让 recorder 对读取主键的查询返回 ids。
+func tableKeys(r *recorder, ids ...int64) {
+	r.rows = func(query string) [][]driver.Value {
+		if !strings.HasPrefix(query, "SELECT") {
+			return nil
+		}
+		rows := make([][]driver.Value, len(ids))
+		for i, id := range ids {
+			rows[i] = []driver.Value{id}
+		}
+		return rows
+	}
+}
+
+func TestSyncDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  dialect
+		memory   string
+		want     []string
+		wantArgs [][]driver.Value
+	}{
+		{
+			// 每条 DELETE 最多 2 个主键
+			name:    "postgre",
+			dialect: limitedDialect{postgreDialect{}, 2},
+			memory:  "100",
+			want: []string{
+				"BEGIN",
+				`SELECT "id" FROM "t"`,
+				`DELETE FROM "t" WHERE ("id") IN (($1), ($2))`,
+				`DELETE FROM "t" WHERE ("id") IN (($1))`,
+				"COMMIT",
+			},
+			wantArgs: [][]driver.Value{nil, {}, {"3", "4"}, {"5"}, nil},
+		},
+		{
+			name:    "mysql with spilled keys",
+			dialect: mysqlDialect{},
+			memory:  "1",
+			want: []string{
+				"BEGIN",
+				"SELECT `id` FROM `t`",
+				"DELETE FROM `t` WHERE (`id`) IN ((?), (?), (?))",
+				"COMMIT",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := openSink(t, tt.dialect, map[string]string{
+				"write_mode":              modeSync,
+				"key_columns":             "id",
+				"sync_max_delete_percent": "100",
+				"sync_memory_keys":        tt.memory,
+			})
+			if err := s.Write("test", syncRecords); err != nil {
+				t.Fatal(err)
+			}
+			r.take()
+			tableKeys(r, 1, 2, 3, 4, 5)
+			if err := s.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			statements, args := r.take()
+			if tt.memory == "1" {
+				// 溢出后主键按桶的顺序比较，删除的顺序不固定
+				deleted := make([]string, 0, 3)
+				for _, v := range args[2] {
+					deleted = append(deleted, v.(string))
+				}
+				sort.Strings(deleted)
+				if want := []string{"3", "4", "5"}; !reflect.DeepEqual(deleted, want) {
+					t.Errorf("deleted keys = %v, want %v", deleted, want)
+				}
+				args = nil
+			}
+			if !reflect.DeepEqual(statements, tt.want) {
+				t.Fatalf("statements =\n%s\nwant\n%s", strings.Join(statements, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if tt.wantArgs != nil && !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSyncWritesUpsert(t *testing.T) {
+	s, r := openSink(t, sqliteDialect{}, map[string]string{"write_mode": modeSync, "key_columns": "id"})
+	if err := s.Write("test", syncRecords); err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, r, []string{
+		"BEGIN",
+		`INSERT INTO "t" ("age", "id", "name") VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT ("id") DO UPDATE SET "age" = excluded."age", "name" = excluded."name"`,
+		"COMMIT",
+	}, nil)
+}
+
+func TestSyncMaxDelete(t *testing.T) {
+	s, r := openSink(t, postgreDialect{}, map[string]string{"write_mode": modeSync, "key_columns": "id"})
+	if err := s.Write("test", syncRecords); err != nil {
+		t.Fatal(err)
+	}
+	r.take()
+	// 3 / 5 = 60% 超过默认的 20%
+	tableKeys(r, 1, 2, 3, 4, 5)
+	err := s.Commit()
+	if err == nil || !strings.Contains(err.Error(), "would delete 3 of 5 rows") {
+		t.Fatalf("Commit() error = %v, want the max delete error", err)
+	}
+	checkStatements(t, r, []string{"BEGIN", `SELECT "id" FROM "t"`, "ROLLBACK"}, nil)
+}
+
+// TestSyncDeletesNothingUnlessCommitted 未完整读取或有批次失败的运行不会删除任何行：
+// 引擎在 Source 未读到末尾时调用 Rollback 而不是 Commit，有批次失败时 Commit 本身拒绝提交。
+func TestSyncDeletesNothingUnlessCommitted(t *testing.T) {
+	t.Run("rollback", func(t *testing.T) {
+		s, r := openSink(t, postgreDialect{}, map[string]string{"write_mode": modeSync, "key_columns": "id", "sync_memory_keys": "1"})
+		if err := s.Write("test", syncRecords); err != nil {
+			t.Fatal(err)
+		}
+		r.take()
+		dir := s.seen.dir
+		if err := s.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		checkStatements(t, r, nil, nil)
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("spill directory %s was not removed: %v", dir, err)
+		}
+	})
+	t.Run("failed batch", func(t *testing.T) {
+		s, r := openSink(t, postgreDialect{}, map[string]string{"write_mode": modeSync, "key_columns": "id"})
+		if err := s.Write("test", syncRecords[:1]); err != nil {
+			t.Fatal(err)
+		}
+		r.fail = "INSERT"
+		if err := s.Write("test", syncRecords[1:]); err == nil {
+			t.Fatal("Write() succeeded with a failing INSERT")
+		}
+		r.fail = ""
+		r.take()
+		tableKeys(r, 1, 2, 3)
+		if err := s.Commit(); err == nil || !strings.Contains(err.Error(), "refusing to commit") {
+			t.Fatalf("Commit() error = %v, want a refusal", err)
+		}
+		checkStatements(t, r, nil, nil)
+	})
+}
@@ -0,0 +1,246 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+const (
+	driftNone = "none"
+	driftAdd  = "add"
+	driftFail = "fail"
+
+	prepareNone     = "none"
+	prepareTruncate = "truncate"
+	prepareRecreate = "recreate"
+)
+
+var tableParams = []params.Params{
+	{
+		Key:          "create_table",
+		Required:     false,
+		DefaultValue: "false",
+		Description:  "create the table from the pipeline column types when it does not exist",
+	},
+	{
+		Key:          "primary_key",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "comma separated primary key columns of a created table, defaults to key_columns except in write_mode scd2",
+	},
+	{
+		Key:          "indexes",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "indexes of a created table separated by ';', each a comma separated column list such as customer_id;created_at,status; write_mode scd2 defaults to an index on key_columns and the current column",
+	},
+	{
+		Key:          "schema_drift",
+		Required:     false,
+		DefaultValue: driftNone,
+		Description:  "none leaves the table as is, add adds mapped columns missing from the table, fail stops when mapped columns are missing",
+	},
+	{
+		Key:          "prepare_mode",
+		Required:     false,
+		DefaultValue: prepareNone,
+		Description:  "none, truncate empties the table before loading, recreate drops and creates the table before loading; runs in the transaction of the first batch on postgre and sqlite",
+	},
+}
+
+// SetColumnTypes 记录输出列的类型，用于建表与新增列。
+func (s *Sink) SetColumnTypes(types map[string]string) {
+	s.columnTypes = types
+}
+
+// prepareTable 按配置建表、处理表结构差异并准备清空或重建表的语句。
+// 支持事务性 DDL 的方言把清空与重建放到第一个批次的事务中，与数据写入一起提交；
+// MySQL 的 DDL 会隐式提交事务，在打开时直接执行。
+func (s *Sink) prepareTable(config map[string]string) error {
+	createTable := strings.EqualFold(strings.TrimSpace(config["create_table"]), "true")
+	drift := strings.ToLower(strings.TrimSpace(config["schema_drift"]))
+	if drift == "" {
+		drift = driftNone
+	}
+	if drift != driftNone && drift != driftAdd && drift != driftFail {
+		return fmt.Errorf("sql sink: unsupported 'schema_drift' %q, expected none, add or fail", config["schema_drift"])
+	}
+	prepare := strings.ToLower(strings.TrimSpace(config["prepare_mode"]))
+	if prepare == "" {
+		prepare = prepareNone
+	}
+	if prepare != prepareNone && prepare != prepareTruncate && prepare != prepareRecreate {
+		return fmt.Errorf("sql sink: unsupported 'prepare_mode' %q, expected none, truncate or recreate", config["prepare_mode"])
+	}
+
+	mapped := make(map[string]bool, len(s.dbColumns)+len(s.managed))
+	for _, c := range s.allColumns() {
+		mapped[c] = true
+	}
+	primaryKey, err := columnList(config["primary_key"], mapped, "primary_key")
+	if err != nil {
+		return err
+	}
+	if len(primaryKey) == 0 && s.mode != modeScd2 {
+		primaryKey = s.keyColumns
+	}
+	var indexes [][]string
+	for _, spec := range strings.Split(config["indexes"], ";") {
+		index, err := columnList(spec, mapped, "indexes")
+		if err != nil {
+			return err
+		}
+		if len(index) > 0 {
+			indexes = append(indexes, index)
+		}
+	}
+	if s.mode == modeScd2 && len(indexes) == 0 {
+		// 业务主键对应多个版本，按业务主键与当前版本标记建索引以便查找当前版本
+		indexes = append(indexes, append(append([]string{}, s.keyColumns...), s.scd.current))
+	}
+
+	s.pending = nil
+	if prepare == prepareRecreate {
+		statements := append([]string{"DROP TABLE IF EXISTS " + table(s.dialect, s.table)}, s.createStatements(primaryKey, indexes)...)
+		return s.runPrepare(statements)
+	}
+
+	columns, exists, err := s.dialect.tableColumns(s.db, s.table)
+	if err != nil {
+		return fmt.Errorf("sql sink: failed to read columns of table %s: %w", s.table, err)
+	}
+	if !exists {
+		if !createTable {
+			if drift != driftNone {
+				return fmt.Errorf("sql sink: table %s does not exist", s.table)
+			}
+			return nil
+		}
+		for _, statement := range s.createStatements(primaryKey, indexes) {
+			if _, err := s.db.Exec(statement); err != nil {
+				return fmt.Errorf("sql sink: failed to create table %s: %w", s.table, err)
+			}
+		}
+	} else if drift != driftNone {
+		existing := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			existing[c] = true
+		}
+		var missing []string
+		for _, c := range s.allColumns() {
+			if !existing[c] {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) > 0 && drift == driftFail {
+			return fmt.Errorf("sql sink: table %s is missing columns %s", s.table, strings.Join(missing, ", "))
+		}
+		for _, c := range missing {
+			statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table(s.dialect, s.table), s.dialect.quote(c), s.dialect.columnType(s.kind(c), false))
+			if _, err := s.db.Exec(statement); err != nil {
+				return fmt.Errorf("sql sink: failed to add column %s to table %s: %w", c, s.table, err)
+			}
+		}
+	}
+
+	if prepare == prepareTruncate {
+		if s.atomic == atomicTransaction && !s.dialect.transactionalDDL() {
+			// TRUNCATE 会隐式提交，改为在共用的事务中 DELETE，与数据写入一起提交
+			s.pending = []string{"DELETE FROM " + table(s.dialect, s.table)}
+			return nil
+		}
+		return s.runPrepare([]string{s.dialect.truncate(table(s.dialect, s.table))})
+	}
+	return nil
+}
+
+// runPrepare 在支持事务性 DDL 的方言中把语句留到第一个批次的事务中执行，否则立即执行。
+func (s *Sink) runPrepare(statements []string) error {
+	if s.dialect.transactionalDDL() {
+		s.pending = statements
+		return nil
+	}
+	for _, statement := range statements {
+		if _, err := s.db.Exec(statement); err != nil {
+			return fmt.Errorf("sql sink: failed to prepare table %s: %w", s.table, err)
+		}
+	}
+	return nil
+}
+
+// createStatements 生成建表与建索引语句，列类型由管道中的列类型按方言映射，未知类型按字符串处理。
+func (s *Sink) createStatements(primaryKey []string, indexes [][]string) []string {
+	keys := make(map[string]bool)
+	for _, c := range primaryKey {
+		keys[c] = true
+	}
+	for _, index := range indexes {
+		for _, c := range index {
+			keys[c] = true
+		}
+	}
+	columns := s.allColumns()
+	definitions := make([]string, 0, len(columns)+1)
+	for _, c := range columns {
+		definition := s.dialect.quote(c) + " " + s.dialect.columnType(s.kind(c), keys[c])
+		definitions = append(definitions, definition)
+	}
+	if len(primaryKey) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(s.quoteAll(primaryKey), ", ")+")")
+	}
+	tableName := table(s.dialect, s.table)
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (%s)", tableName, strings.Join(definitions, ", "))}
+	base := s.table[strings.LastIndex(s.table, ".")+1:]
+	for _, index := range indexes {
+		name := indexName.ReplaceAllString("idx_"+base+"_"+strings.Join(index, "_"), "_")
+		statements = append(statements, fmt.Sprintf("CREATE INDEX %s ON %s (%s)", s.dialect.quote(name), tableName, strings.Join(s.quoteAll(index), ", ")))
+	}
+	return statements
+}
+
+var indexName = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// kind 返回表列在管道中的类型，无法确定时按字符串处理。
+func (s *Sink) kind(column string) string {
+	if kind, ok := s.managedKind[column]; ok {
+		return kind
+	}
+	if kind, ok := s.columnTypes[s.recordKey[column]]; ok {
+		return kind
+	}
+	return record.TypeString
+}
+
+// queryColumns 执行返回列名的查询，没有任何行时表示表不存在。
+func queryColumns(db *sql.DB, query string, args ...any) ([]string, bool, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, false, err
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	return columns, len(columns) > 0, nil
+}
+
+// splitTable 拆分 schema.table 形式的表名，没有 schema 时 schema 为空。
+func splitTable(name string) (string, string) {
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		return strings.TrimSpace(schema), strings.TrimSpace(table)
+	}
+	return "", strings.TrimSpace(name)
+}
@@ -0,0 +1,237 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+const (
+	dialectMysql   = "mysql"
+	dialectPostgre = "postgre"
+	dialectSqlite  = "sqlite"
+)
+
+const (
+	modeInsert       = "insert"
+	modeInsertIgnore = "insert_ignore"
+	modeUpsert       = "upsert"
+	modeReplace      = "replace"
+	modeUpdateOnly   = "update_only"
+	modeScd2         = "scd2"
+)
+
+var writeModeParams = []params.Params{
+	{
+		Key:          "write_mode",
+		Required:     false,
+		DefaultValue: modeInsert,
+		Description:  "insert, insert_ignore (skip rows conflicting with existing keys), upsert (insert or update on key conflict), replace (replace rows with conflicting keys) update_only (update existing rows by key, never insert) scd2 (keep history: close the current version of changed rows and insert a new one, skip unchanged rows) or sync (upsert, then delete rows whose key_columns did not appear in the run once the run succeeds)",
+	},
+	{
+		Key:          "key_columns",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "comma separated table columns identifying a row, required by update_only, scd2 and sync and by upsert and replace on postgre and sqlite; must match a primary key or unique index except in scd2, where they are the business key",
+	},
+	{
+		Key:          "bulk_mode",
+		Required:     false,
+		DefaultValue: "false",
+		Description:  "load batches with COPY FROM STDIN on postgre or LOAD DATA LOCAL INFILE on mysql (requires local_infile) when write_mode allows, falling back to INSERT",
+	},
+	{
+		Key:          "exclude_update_columns",
+		Required:     false,
+		DefaultValue: "",
+		Description:  "comma separated table columns that upsert and update_only never update, such as created_at",
+	},
+}
+
+// parseWriteMode 解析写入模式与主键列，校验主键列与排除列均为映射中的列。
+func (s *Sink) parseWriteMode(config map[string]string) error {
+	s.mode = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(config["write_mode"])), "-", "_")
+	if s.mode == "" {
+		s.mode = modeInsert
+	}
+	switch s.mode {
+	case modeInsert, modeInsertIgnore, modeUpsert, modeReplace, modeUpdateOnly, modeScd2, modeSync:
+	default:
+		return fmt.Errorf("sql sink: unsupported 'write_mode' %q, expected insert, insert_ignore, upsert, replace, update_only, scd2 or sync", config["write_mode"])
+	}
+
+	mapped := make(map[string]bool, len(s.dbColumns))
+	for _, c := range s.dbColumns {
+		mapped[c] = true
+	}
+	var err error
+	if s.keyColumns, err = columnList(config["key_columns"], mapped, "key_columns"); err != nil {
+		return err
+	}
+	excluded, err := columnList(config["exclude_update_columns"], mapped, "exclude_update_columns")
+	if err != nil {
+		return err
+	}
+	if err := s.parseScd(config, mapped); err != nil {
+		return err
+	}
+	if err := s.parseSync(config); err != nil {
+		return err
+	}
+	if len(s.keyColumns) == 0 {
+		switch {
+		case s.mode == modeUpdateOnly:
+			return fmt.Errorf("sql sink: 'key_columns' is required by write_mode update_only")
+		case s.dialect.name() != dialectMysql && (s.mode == modeUpsert || s.mode == modeReplace):
+			return fmt.Errorf("sql sink: 'key_columns' is required by write_mode %s on %s", s.mode, s.dialect.name())
+		}
+	}
+
+	skip := make(map[string]bool)
+	for _, c := range s.keyColumns {
+		skip[c] = true
+	}
+	for _, c := range excluded {
+		skip[c] = true
+	}
+	s.updateColumns = s.updateColumns[:0]
+	for _, c := range s.dbColumns {
+		if !skip[c] {
+			s.updateColumns = append(s.updateColumns, c)
+		}
+	}
+	if len(s.updateColumns) == 0 && (s.mode == modeUpdateOnly || (s.mode == modeUpsert && len(s.keyColumns) == 0)) {
+		return fmt.Errorf("sql sink: write_mode %s has no columns to update", s.mode)
+	}
+	return nil
+}
+
+// columnList 解析逗号分隔的列名，列名必须是映射后的表列。
+func columnList(spec string, mapped map[string]bool, key string) ([]string, error) {
+	var columns []string
+	for _, c := range strings.Split(spec, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !mapped[c] {
+			return nil, fmt.Errorf("sql sink: column '%s' in '%s' is not a mapped column", c, key)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// insertStatement 按写入模式与方言构建 rows 行的批量插入语句。
+func (s *Sink) insertStatement(rows int) string {
+	return fmt.Sprintf("%s %s (%s) VALUES %s%s", s.dialect.insertVerb(s.mode), table(s.dialect, s.table), strings.Join(s.quoteAll(s.dbColumns), ", "),
+		s.placeholderGroups(rows, len(s.dbColumns), 1), s.dialect.conflictClause(s.mode, s.quoteAll(s.keyColumns), s.quoteAll(s.updateColumns)))
+}
+
+// quoteAll 为一组列名加上引号。
+func (s *Sink) quoteAll(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = s.dialect.quote(c)
+	}
+	return quoted
+}
+
+// insert 以一条批量插入语句写入 records。
+func (s *Sink) insert(tx *sql.Tx, records []record.Record) error {
+	if (s.mode == modeUpsert || s.mode == modeReplace) && len(s.keyColumns) > 0 {
+		// 同一条语句中同键的行在 postgre 中会报错，在其他方言中也只有最后一行生效
+		records = s.lastByKey(records)
+	}
+	args := make([]any, 0, len(records)*len(s.recordKeys))
+	for _, r := range records {
+		args = append(args, s.values(r, s.recordKeys)...)
+	}
+	if _, err := tx.Exec(s.insertStatement(len(records)), args...); err != nil {
+		return fmt.Errorf("sql sink: failed to execute batch %s: %w", s.mode, err)
+	}
+	return nil
+}
+
+// deleteByKey 删除与 records 同键的行，用于在不支持 REPLACE 的方言中实现 replace。
+func (s *Sink) deleteByKey(tx *sql.Tx, records []record.Record) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE (%s) IN (%s)", table(s.dialect, s.table),
+		strings.Join(s.quoteAll(s.keyColumns), ", "), s.placeholderGroups(len(records), len(s.keyColumns), 1))
+	keyRecordKeys := s.recordKeysOf(s.keyColumns)
+	args := make([]any, 0, len(records)*len(s.keyColumns))
+	for _, r := range records {
+		args = append(args, s.values(r, keyRecordKeys)...)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("sql sink: failed to delete rows to replace: %w", err)
+	}
+	return nil
+}
+
+// update 按主键逐行更新已存在的行，目标表中不存在的行被忽略。
+func (s *Sink) update(tx *sql.Tx, records []record.Record) error {
+	n := 0
+	sets := make([]string, len(s.updateColumns))
+	for i, c := range s.updateColumns {
+		n++
+		sets[i] = s.dialect.quote(c) + " = " + s.dialect.placeholder(n)
+	}
+	conditions := make([]string, len(s.keyColumns))
+	for i, c := range s.keyColumns {
+		n++
+		conditions[i] = s.dialect.quote(c) + " = " + s.dialect.placeholder(n)
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table(s.dialect, s.table), strings.Join(sets, ", "), strings.Join(conditions, " AND "))
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("sql sink: failed to prepare update: %w", err)
+	}
+	defer stmt.Close()
+	recordKeys := append(s.recordKeysOf(s.updateColumns), s.recordKeysOf(s.keyColumns)...)
+	for _, r := range records {
+		if _, err := stmt.Exec(s.values(r, recordKeys)...); err != nil {
+			return fmt.Errorf("sql sink: failed to execute update: %w", err)
+		}
+	}
+	return nil
+}
+
+// lastByKey 对同键的行只保留最后一行，保持行的先后顺序。
+func (s *Sink) lastByKey(records []record.Record) []record.Record {
+	keyRecordKeys := s.recordKeysOf(s.keyColumns)
+	last := make(map[string]int, len(records))
+	for i, r := range records {
+		last[fmt.Sprintf("%#v", s.values(r, keyRecordKeys))] = i
+	}
+	if len(last) == len(records) {
+		return records
+	}
+	kept := make([]record.Record, 0, len(last))
+	for i, r := range records {
+		if last[fmt.Sprintf("%#v", s.values(r, keyRecordKeys))] == i {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// values 按 keys 的顺序取出记录中的值，缺少的键写入 NULL。
+func (s *Sink) values(r record.Record, keys []string) []any {
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		values[i] = r[k]
+	}
+	return values
+}
+
+// recordKeysOf 返回表列对应的记录键。
+func (s *Sink) recordKeysOf(columns []string) []string {
+	keys := make([]string, len(columns))
+	for i, c := range columns {
+		keys[i] = s.recordKey[c]
+	}
+	return keys
+}
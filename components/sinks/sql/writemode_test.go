@@ -0,0 +1,196 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+func TestInsertStatement(t *testing.T) {
+	tests := []struct {
+		dialect dialect
+		mode    string
+		keys    string
+		want    string
+	}{
+		{mysqlDialect{}, modeInsert, "", "INSERT INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?), (?, ?, ?)"},
+		{mysqlDialect{}, modeInsertIgnore, "", "INSERT IGNORE INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?), (?, ?, ?)"},
+		{mysqlDialect{}, modeUpsert, "id", "INSERT INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?), (?, ?, ?)" +
+			" ON DUPLICATE KEY UPDATE `age` = VALUES(`age`), `name` = VALUES(`name`)"},
+		{mysqlDialect{}, modeUpsert, "", "INSERT INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?), (?, ?, ?)" +
+			" ON DUPLICATE KEY UPDATE `age` = VALUES(`age`), `id` = VALUES(`id`), `name` = VALUES(`name`)"},
+		{mysqlDialect{}, modeReplace, "", "REPLACE INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?), (?, ?, ?)"},
+		{postgreDialect{}, modeInsert, "", `INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3), ($4, $5, $6)`},
+		{postgreDialect{}, modeInsertIgnore, "", `INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT DO NOTHING`},
+		{postgreDialect{}, modeUpsert, "id", `INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3), ($4, $5, $6)` +
+			` ON CONFLICT ("id") DO UPDATE SET "age" = EXCLUDED."age", "name" = EXCLUDED."name"`},
+		{postgreDialect{}, modeReplace, "id", `INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3), ($4, $5, $6)`},
+		{sqliteDialect{}, modeInsert, "", `INSERT INTO "t" ("age", "id", "name") VALUES (?, ?, ?), (?, ?, ?)`},
+		{sqliteDialect{}, modeInsertIgnore, "", `INSERT OR IGNORE INTO "t" ("age", "id", "name") VALUES (?, ?, ?), (?, ?, ?)`},
+		{sqliteDialect{}, modeUpsert, "id", `INSERT INTO "t" ("age", "id", "name") VALUES (?, ?, ?), (?, ?, ?)` +
+			` ON CONFLICT ("id") DO UPDATE SET "age" = excluded."age", "name" = excluded."name"`},
+		{sqliteDialect{}, modeReplace, "id", `INSERT OR REPLACE INTO "t" ("age", "id", "name") VALUES (?, ?, ?), (?, ?, ?)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.name()+" "+tt.mode, func(t *testing.T) {
+			s, _ := openSink(t, tt.dialect, map[string]string{"write_mode": tt.mode, "key_columns": tt.keys})
+			if got := s.insertStatement(2); got != tt.want {
+				t.Errorf("insertStatement(2) =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertWithoutUpdateColumns(t *testing.T) {
+	tests := []struct {
+		dialect dialect
+		want    string
+	}{
+		{mysqlDialect{}, " ON DUPLICATE KEY UPDATE `id` = `id`"},
+		{postgreDialect{}, ` ON CONFLICT ("id") DO NOTHING`},
+		{sqliteDialect{}, ` ON CONFLICT ("id") DO NOTHING`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.name(), func(t *testing.T) {
+			s, _ := openSink(t, tt.dialect, map[string]string{"write_mode": modeUpsert, "key_columns": "id", "exclude_update_columns": "age, name"})
+			if got := s.dialect.conflictClause(s.mode, s.quoteAll(s.keyColumns), s.quoteAll(s.updateColumns)); got != tt.want {
+				t.Errorf("conflictClause = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBatches(t *testing.T) {
+	records := []record.Record{
+		{"id": int64(1), "name": "a", "age": int64(10)},
+		{"id": int64(2), "name": "b", "age": int64(20)},
+		{"id": int64(3), "name": "c", "age": int64(30)},
+	}
+	tests := []struct {
+		name     string
+		dialect  dialect
+		config   map[string]string
+		want     []string
+		wantArgs [][]driver.Value
+	}{
+		{
+			// 每条语句最多 6 个参数，3 列的记录每 2 行一条语句
+			name:    "insert split by max params",
+			dialect: limitedDialect{mysqlDialect{}, 6},
+			config:  map[string]string{"write_mode": modeInsert},
+			want: []string{
+				"BEGIN",
+				"INSERT INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?), (?, ?, ?)",
+				"INSERT INTO `t` (`age`, `id`, `name`) VALUES (?, ?, ?)",
+				"COMMIT",
+			},
+			wantArgs: [][]driver.Value{nil, {int64(10), int64(1), "a", int64(20), int64(2), "b"}, {int64(30), int64(3), "c"}, nil},
+		},
+		{
+			name:    "fewer params than columns",
+			dialect: limitedDialect{sqliteDialect{}, 2},
+			config:  map[string]string{"write_mode": modeInsert},
+			want: []string{
+				"BEGIN",
+				`INSERT INTO "t" ("age", "id", "name") VALUES (?, ?, ?)`,
+				`INSERT INTO "t" ("age", "id", "name") VALUES (?, ?, ?)`,
+				`INSERT INTO "t" ("age", "id", "name") VALUES (?, ?, ?)`,
+				"COMMIT",
+			},
+		},
+		{
+			// postgre 没有 REPLACE，先按主键删除每个批次的行再插入
+			name:    "postgre replace",
+			dialect: limitedDialect{postgreDialect{}, 6},
+			config:  map[string]string{"write_mode": modeReplace, "key_columns": "id"},
+			want: []string{
+				"BEGIN",
+				`DELETE FROM "t" WHERE ("id") IN (($1), ($2))`,
+				`INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3), ($4, $5, $6)`,
+				`DELETE FROM "t" WHERE ("id") IN (($1))`,
+				`INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3)`,
+				"COMMIT",
+			},
+			wantArgs: [][]driver.Value{
+				nil,
+				{int64(1), int64(2)},
+				{int64(10), int64(1), "a", int64(20), int64(2), "b"},
+				{int64(3)},
+				{int64(30), int64(3), "c"},
+				nil,
+			},
+		},
+		{
+			name:    "update only",
+			dialect: postgreDialect{},
+			config:  map[string]string{"write_mode": modeUpdateOnly, "key_columns": "id", "exclude_update_columns": "age"},
+			want: []string{
+				"BEGIN",
+				`UPDATE "t" SET "name" = $1 WHERE "id" = $2`,
+				`UPDATE "t" SET "name" = $1 WHERE "id" = $2`,
+				`UPDATE "t" SET "name" = $1 WHERE "id" = $2`,
+				"COMMIT",
+			},
+			wantArgs: [][]driver.Value{nil, {"a", int64(1)}, {"b", int64(2)}, {"c", int64(3)}, nil},
+		},
+		{
+			name:    "mysql update only",
+			dialect: mysqlDialect{},
+			config:  map[string]string{"write_mode": modeUpdateOnly, "key_columns": "id"},
+			want: []string{
+				"BEGIN",
+				"UPDATE `t` SET `age` = ?, `name` = ? WHERE `id` = ?",
+				"UPDATE `t` SET `age` = ?, `name` = ? WHERE `id` = ?",
+				"UPDATE `t` SET `age` = ?, `name` = ? WHERE `id` = ?",
+				"COMMIT",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, r := openSink(t, tt.dialect, tt.config)
+			if err := s.Write("test", records); err != nil {
+				t.Fatal(err)
+			}
+			checkStatements(t, r, tt.want, tt.wantArgs)
+		})
+	}
+}
+
+func TestUpsertKeepsLastRowPerKey(t *testing.T) {
+	s, r := openSink(t, postgreDialect{}, map[string]string{"write_mode": modeUpsert, "key_columns": "id"})
+	err := s.Write("test", []record.Record{
+		{"id": int64(1), "name": "a", "age": int64(10)},
+		{"id": int64(1), "name": "b", "age": int64(20)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, r, []string{
+		"BEGIN",
+		`INSERT INTO "t" ("age", "id", "name") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "age" = EXCLUDED."age", "name" = EXCLUDED."name"`,
+		"COMMIT",
+	}, [][]driver.Value{nil, {int64(20), int64(1), "b"}, nil})
+}
+
+func TestPlaceholderGroups(t *testing.T) {
+	tests := []struct {
+		dialect dialect
+		rows    int
+		width   int
+		first   int
+		want    string
+	}{
+		{mysqlDialect{}, 2, 3, 1, "(?, ?, ?), (?, ?, ?)"},
+		{postgreDialect{}, 2, 3, 1, "($1, $2, $3), ($4, $5, $6)"},
+		{postgreDialect{}, 3, 1, 4, "($4), ($5), ($6)"},
+		{sqliteDialect{}, 1, 2, 3, "(?, ?)"},
+	}
+	for _, tt := range tests {
+		s := &Sink{dialect: tt.dialect}
+		if got := s.placeholderGroups(tt.rows, tt.width, tt.first); got != tt.want {
+			t.Errorf("%s placeholderGroups(%d, %d, %d) = %q, want %q", tt.dialect.name(), tt.rows, tt.width, tt.first, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package xlsxSink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/sink"
+	"github.com/xuri/excelize/v2"
+)
+
+const defaultSheet = "Sheet1"
+
+// Sink 实现了 core.Sink 接口，用于将数据写入 Excel(.xlsx) 文件。
+//
+// 每个工作表使用 excelize 的流式写入器逐行写入，文件在 Close 时保存到 file_name 与 file_ext 对应的输出文件。
+// 配置 split_column 后记录会按该列的值写入不同的工作表。
+type Sink struct {
+	ID          string
+	filePath    string // 输出文件路径。
+	file        *excelize.File
+	header      []string // 表头字段。
+	sheetName   string
+	splitColumn string
+	sheets      map[string]*sheetWriter
+	order       []string          // 工作表的创建顺序
+	splitSheets map[string]string // split_column 的值 -> 工作表名称
+	headerStyle int
+}
+
+// sheetWriter 是一个工作表的流式写入器与已写入的行数。
+type sheetWriter struct {
+	stream *excelize.StreamWriter
+	rows   int
+}
+
+func SinkCreator() (string, sink.Sink, *string, []params.Params) {
+	return "xlsx", &Sink{}, nil, []params.Params{
+		{
+			Key:         "file_name",
+			Description: "The name of the output file",
+			Required:    true,
+		},
+		{
+			Key:          "file_ext",
+			Description:  "The extension of the output file",
+			DefaultValue: "xlsx",
+			Required:     true,
+		},
+		{
+			Key:          "sheet_name",
+			Description:  "name of the sheet records are written to",
+			DefaultValue: defaultSheet,
+			Required:     false,
+		},
+		{
+			Key:          "split_column",
+			Description:  "column whose value selects the sheet of each record, empty writes all records to one sheet",
+			DefaultValue: "",
+			Required:     false,
+		},
+	}
+}
+
+// Open 创建工作簿并记录表头。
+func (s *Sink) Open(config map[string]string, columnMapping map[string]string, _ *datasource.Datasource) error {
+	filePath, ok := config["file_path"]
+	if !ok {
+		return fmt.Errorf("xlsx sink: config is missing or has invalid 'file_name'")
+	}
+	s.filePath = filePath
+	s.sheetName = sanitizeSheetName(config["sheet_name"])
+	s.splitColumn = strings.TrimSpace(config["split_column"])
+	s.sheets = make(map[string]*sheetWriter)
+	s.splitSheets = make(map[string]string)
+	s.order = nil
+
+	s.header = s.header[:0]
+	for _, key := range columnMapping {
+		s.header = append(s.header, key)
+	}
+	sort.Strings(s.header)
+	if s.splitColumn != "" {
+		if !containsString(s.header, s.splitColumn) {
+			return fmt.Errorf("xlsx sink: split column '%s' is not in the output columns", s.splitColumn)
+		}
+	}
+
+	s.file = excelize.NewFile()
+	var err error
+	s.headerStyle, err = s.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return fmt.Errorf("xlsx sink: failed to create header style: %w", err)
+	}
+	return nil
+}
+
+// Write 将一批记录按行写入对应的工作表。
+func (s *Sink) Write(ID string, records []record.Record) error {
+	s.ID = ID
+	if len(records) == 0 {
+		return nil
+	}
+	if s.file == nil {
+		return fmt.Errorf("xlsx sink: workbook is not initialized")
+	}
+
+	row := make([]interface{}, len(s.header))
+	for _, r := range records {
+		sheetName := s.sheetName
+		if s.splitColumn != "" {
+			sheetName = s.splitSheet(record.Format(r[s.splitColumn]))
+		}
+		w, err := s.sheet(sheetName)
+		if err != nil {
+			return err
+		}
+		if w.rows >= excelize.TotalRows {
+			return fmt.Errorf("xlsx sink: sheet '%s' exceeds the maximum of %d rows", sheetName, excelize.TotalRows)
+		}
+		for i, key := range s.header {
+			row[i] = cellValue(r[key])
+		}
+		w.rows++
+		cell, _ := excelize.CoordinatesToCellName(1, w.rows)
+		if err := w.stream.SetRow(cell, row); err != nil {
+			return fmt.Errorf("xlsx sink: failed to write row: %w", err)
+		}
+	}
+	return nil
+}
+
+// splitSheet 返回 split_column 的值对应的工作表名称。不同的值在清理非法字符、截断或忽略大小写后
+// 可能得到相同的名称，此时为后出现的值追加 ~2、~3 等后缀，避免它们写入同一个工作表。
+func (s *Sink) splitSheet(value string) string {
+	if sheetName, ok := s.splitSheets[value]; ok {
+		return sheetName
+	}
+	base := sanitizeSheetName(value)
+	sheetName := base
+	for n := 2; s.sheets[strings.ToLower(sheetName)] != nil; n++ {
+		suffix := fmt.Sprintf("~%d", n)
+		runes := []rune(base)
+		if limit := excelize.MaxSheetNameLength - len(suffix); len(runes) > limit {
+			runes = runes[:limit]
+		}
+		sheetName = string(runes) + suffix
+	}
+	s.splitSheets[value] = sheetName
+	return sheetName
+}
+
+// sheet 返回工作表的写入器，工作表不存在时创建并写入表头。
+func (s *Sink) sheet(sheetName string) (*sheetWriter, error) {
+	// 工作表名称不区分大小写
+	key := strings.ToLower(sheetName)
+	if w, ok := s.sheets[key]; ok {
+		return w, nil
+	}
+	var err error
+	if len(s.order) == 0 {
+		// 新建的工作簿自带一个工作表，第一个工作表直接复用它
+		if sheetName != defaultSheet {
+			err = s.file.SetSheetName(defaultSheet, sheetName)
+		}
+	} else {
+		_, err = s.file.NewSheet(sheetName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("xlsx sink: failed to create sheet '%s': %w", sheetName, err)
+	}
+	stream, err := s.file.NewStreamWriter(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx sink: failed to create writer of sheet '%s': %w", sheetName, err)
+	}
+	w := &sheetWriter{stream: stream}
+	if len(s.header) > 0 {
+		header := make([]interface{}, len(s.header))
+		for i, key := range s.header {
+			header[i] = excelize.Cell{StyleID: s.headerStyle, Value: key}
+		}
+		if err := stream.SetRow("A1", header); err != nil {
+			return nil, fmt.Errorf("xlsx sink: failed to write header: %w", err)
+		}
+		w.rows = 1
+	}
+	s.sheets[key] = w
+	s.order = append(s.order, sheetName)
+	return w, nil
+}
+
+// Close 刷新所有工作表并保存文件。
+func (s *Sink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	var errs []error
+	// 没有任何记录时仍然输出只有表头的工作表
+	if len(s.order) == 0 {
+		if _, err := s.sheet(s.sheetName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, sheetName := range s.order {
+		if err := s.sheets[strings.ToLower(sheetName)].stream.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("xlsx sink: failed to flush sheet '%s': %w", sheetName, err))
+		}
+	}
+	if len(errs) == 0 {
+		if err := s.file.SaveAs(s.filePath); err != nil {
+			errs = append(errs, fmt.Errorf("xlsx sink: failed to save file: %w", err))
+		}
+	}
+	if err := s.file.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("xlsx sink: failed to close workbook: %w", err))
+	}
+	s.file = nil
+	return errors.Join(errs...)
+}
+
+// cellValue 将记录中的值转换为 excelize 可写入的类型，嵌套对象与数组以 JSON 文本写入。
+func cellValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return v
+	}
+}
+
+// sanitizeSheetName 将任意文本转换为合法的工作表名称：去掉 \ / ? * [ ] : 并截断到 31 个字符。
+func sanitizeSheetName(sheetName string) string {
+	sheetName = strings.Map(func(r rune) rune {
+		switch r {
+		case '\\', '/', '?', '*', '[', ']', ':':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(sheetName))
+	sheetName = strings.Trim(sheetName, "'")
+	if runes := []rune(sheetName); len(runes) > excelize.MaxSheetNameLength {
+		sheetName = string(runes[:excelize.MaxSheetNameLength])
+	}
+	if sheetName == "" {
+		return defaultSheet
+	}
+	return sheetName
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,15 +1,20 @@
 package csv
 
 import (
+	"bufio"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"strconv"
+	"strings"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/files"
 	"github.com/BernardSimon/etl-go/etl/core/params"
 	"github.com/BernardSimon/etl-go/etl/core/record"
 	"github.com/BernardSimon/etl-go/etl/core/source"
+	"golang.org/x/text/encoding"
 )
 
 var name = "csv"
@@ -18,14 +23,37 @@ func SetCustomName(customName string) {
 	name = customName
 }
 
+// 列数与表头不一致的行的处理方式。
+const (
+	raggedError    = "error"    // 报错
+	raggedPad      = "pad"      // 缺少的列补 NULL，多出的列报错
+	raggedTruncate = "truncate" // 缺少的列补 NULL，多出的列丢弃
+)
+
 // Source 实现了 core.Source 接口，用于从 CSV 文件读取数据。
+//
+// 配置 file_ids 时多个文件按顺序作为一个数据流读取，每个文件都以表头开始，且表头必须包含相同的列。
+// gzip、zstd 压缩文件与 zip 压缩包中的文件会被透明地解压，非 UTF-8 文件按 encoding 转换为 UTF-8。
 type Source struct {
-	filePath  string      // 要读取的CSV文件路径
-	file      *os.File    // 文件句柄
-	reader    *csv.Reader // Go 标准库的 CSV 读取器
-	header    []string    // 如果有表头，存储表头列名
-	delimiter rune        // CSV文件的分隔符，默认为逗号
-	line      int         // 当前已读取的行数，用于精确的错误报告
+	files      *files.Reader
+	stream     *files.Stream // 当前读取的文件
+	reader     *csv.Reader   // Go 标准库的 CSV 读取器
+	header     []string      // 输出列名，作为 Record 的键
+	order      []int         // 当前文件的列在 header 中的位置
+	pending    []string      // 无表头且未指定列名时，为确定列数而预先读取的第一行
+	skipped    int           // 当前文件开头跳过的行数，用于换算错误行号
+	sourceFile bool          // 是否输出来源文件列
+
+	delimiter   rune // CSV文件的分隔符，默认为逗号
+	comment     rune
+	lazyQuotes  bool
+	trimSpace   bool
+	hasHeader   bool
+	columnNames []string // 指定的列名，按位置对应
+	skipRows    int      // 每个文件开头跳过的行数
+	ragged      string
+	encoding    encoding.Encoding
+	stripBOM    bool
 }
 
 func SourceCreator() (string, source.Source, *string, []params.Params) {
@@ -34,91 +62,352 @@ func SourceCreator() (string, source.Source, *string, []params.Params) {
 		{
 			Key:          "file_id",
 			DefaultValue: "",
-			Required:     true,
+			Required:     false,
 			Description:  "The file_id to the CSV file",
 		},
+		{
+			Key:          "file_ids",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Comma separated file_ids read in order as one stream, used instead of file_id",
+		},
 		{
 			Key:          "delimiter",
 			DefaultValue: ",",
 			Required:     true,
-			Description:  "The delimiter used in the CSV file, default is comma",
+			Description:  "The delimiter used in the CSV file, default is comma, \\t for tab",
+		},
+		{
+			Key:          "encoding",
+			DefaultValue: "utf-8",
+			Required:     false,
+			Description:  "Character encoding of the file: utf-8, gbk, gb18030, utf-16, utf-16le, utf-16be, latin-1",
+		},
+		{
+			Key:          "strip_bom",
+			DefaultValue: "true",
+			Required:     false,
+			Description:  "Remove a byte order mark at the start of the file",
+		},
+		{
+			Key:          "has_header",
+			DefaultValue: "true",
+			Required:     false,
+			Description:  "Whether the first row (after skip_rows) is a header",
+		},
+		{
+			Key:          "column_names",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Comma separated column names by position, replaces the header; without a header empty means column_1, column_2, ...",
+		},
+		{
+			Key:          "skip_rows",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "Number of leading lines skipped in each file before the header",
+		},
+		{
+			Key:          "comment",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Lines starting with this character are ignored",
+		},
+		{
+			Key:          "lazy_quotes",
+			DefaultValue: "false",
+			Required:     false,
+			Description:  "Allow quotes in unquoted fields and unescaped quotes in quoted fields",
+		},
+		{
+			Key:          "trim_space",
+			DefaultValue: "false",
+			Required:     false,
+			Description:  "Trim leading and trailing white space of every field",
+		},
+		{
+			Key:          "ragged_rows",
+			DefaultValue: raggedError,
+			Required:     false,
+			Description:  "Rows with a different column count: error, pad (fill missing columns with null) or truncate (also drop extra fields)",
+		},
+		{
+			Key:          "include_source_file",
+			DefaultValue: "false",
+			Required:     false,
+			Description:  "Add a _source_file column with the name of the file each row was read from",
 		},
 	}
 
 	return name, &Source{}, nil, paramList
 }
 
-// Open 负责解析配置、打开 CSV 文件并准备读取。
-// 它会处理文件路径、是否包含表头、自定义分隔符等配置项。
-// 如果配置了 has_header: true，它会预先读取第一行作为后续 Record 的键。
+// Open 负责解析配置、打开第一个 CSV 文件并读取表头。
+// 空文件会被跳过，所有文件都为空时报错。
 func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
-	// 直接从 config 中获取 file_path，而不是通过 file_id 获取
-	filePath, ok := config["file_path"]
-	if !ok {
-		return fmt.Errorf("csv source: config is missing required key 'file_path'")
+	inputs, err := files.Inputs(config)
+	if err != nil {
+		return fmt.Errorf("csv source: %w", err)
 	}
-	s.filePath = filePath
+	if err := s.configure(config); err != nil {
+		return fmt.Errorf("csv source: %w", err)
+	}
+
+	s.files = files.NewReader(inputs)
+	s.header = nil
+	s.pending = nil
+	if err := s.nextFile(); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("csv source: file is empty or contains only a header")
+		}
+		return err
+	}
+	return nil
+}
 
+// configure 解析方言相关的配置。
+func (s *Source) configure(config map[string]string) error {
 	// 'delimiter' 是可选配置，默认为 ','。
-	if delimiterStr, ok := config["delimiter"]; ok && len(delimiterStr) > 0 {
+	s.delimiter = ','
+	if delimiterStr := config["delimiter"]; len(delimiterStr) > 0 {
+		if delimiterStr == `\t` {
+			delimiterStr = "\t"
+		}
 		// 只取第一个字符作为分隔符。
 		s.delimiter = []rune(delimiterStr)[0]
 	}
+	s.comment = 0
+	if comment := strings.TrimSpace(config["comment"]); comment != "" {
+		if len([]rune(comment)) != 1 {
+			return fmt.Errorf("'comment' must be a single character, got %q", comment)
+		}
+		s.comment = []rune(comment)[0]
+	}
 
 	var err error
-	s.file, err = os.Open(s.filePath)
-	if err != nil {
-		return fmt.Errorf("csv source: failed to open file %s: %w", s.filePath, err)
+	if s.lazyQuotes, err = boolOption(config, "lazy_quotes", false); err != nil {
+		return err
+	}
+	if s.trimSpace, err = boolOption(config, "trim_space", false); err != nil {
+		return err
+	}
+	if s.hasHeader, err = boolOption(config, "has_header", true); err != nil {
+		return err
+	}
+	if s.stripBOM, err = boolOption(config, "strip_bom", true); err != nil {
+		return err
+	}
+	if s.sourceFile, err = boolOption(config, "include_source_file", false); err != nil {
+		return err
+	}
+	if s.encoding, err = files.LookupEncoding(config["encoding"], s.stripBOM); err != nil {
+		return err
 	}
 
-	s.reader = csv.NewReader(s.file)
-	s.reader.Comma = s.delimiter
+	s.columnNames = nil
+	if v := strings.TrimSpace(config["column_names"]); v != "" {
+		for _, column := range strings.Split(v, ",") {
+			s.columnNames = append(s.columnNames, strings.TrimSpace(column))
+		}
+	}
+	s.skipRows = 0
+	if v := strings.TrimSpace(config["skip_rows"]); v != "" {
+		if s.skipRows, err = strconv.Atoi(v); err != nil || s.skipRows < 0 {
+			return fmt.Errorf("invalid 'skip_rows' %q", v)
+		}
+	}
+	s.ragged = strings.ToLower(strings.TrimSpace(config["ragged_rows"]))
+	switch s.ragged {
+	case "":
+		s.ragged = raggedError
+	case raggedError, raggedPad, raggedTruncate:
+	default:
+		return fmt.Errorf("unsupported 'ragged_rows' %q, expected error, pad or truncate", s.ragged)
+	}
+	return nil
+}
 
-	s.line = 0
+// nextFile 关闭当前文件并打开下一个非空文件，读取其表头。
+// 第一个文件的表头决定输出列，之后的文件的表头必须包含相同的列，列的顺序可以不同；
+// 指定了 column_names 或文件没有表头时按位置对应。
+func (s *Source) nextFile() error {
+	for {
+		if err := s.closeStream(); err != nil {
+			return err
+		}
+		stream, err := s.files.Next()
+		if err != nil {
+			if err == io.EOF {
+				return io.EOF
+			}
+			return fmt.Errorf("csv source: %w", err)
+		}
+		s.stream = stream
+		if err := s.openReader(); err != nil {
+			return err
+		}
 
-	// 如果有表头，立即读取并存储，以便后续的 Read() 调用使用。
-	s.header, err = s.reader.Read()
-	if err != nil {
-		if err == io.EOF {
-			return fmt.Errorf("csv source: file is empty or contains only a header")
+		var header []string
+		if s.hasHeader || (s.header == nil && s.columnNames == nil) {
+			row, err := s.reader.Read()
+			if err == io.EOF {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("csv source: failed to read header of %s: %w", stream.Name, s.positionError(err))
+			}
+			if s.hasHeader {
+				header = s.trim(row)
+			} else {
+				// 没有表头也没有指定列名时，由第一行的列数生成列名，这一行仍作为数据输出
+				s.pending = row
+			}
+		}
+
+		if s.header == nil {
+			if err := s.initHeader(header); err != nil {
+				return err
+			}
+			return nil
 		}
-		return fmt.Errorf("csv source: failed to read header: %w", err)
+		s.order = nil
+		if s.hasHeader && s.columnNames == nil {
+			order, err := matchHeader(s.header, header)
+			if err != nil {
+				return fmt.Errorf("csv source: header of %s does not match the first file: %w", stream.Name, err)
+			}
+			s.order = order
+		}
+		return nil
 	}
-	s.line++
+}
 
+// openReader 在当前文件上按编码与方言配置创建 CSV 读取器，并跳过开头的 skip_rows 行。
+func (s *Source) openReader() error {
+	buffered := bufio.NewReader(files.Decode(s.stream, s.encoding, s.stripBOM))
+	s.skipped = 0
+	for ; s.skipped < s.skipRows; s.skipped++ {
+		if _, err := buffered.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("csv source: failed to read %s: %w", s.stream.Name, err)
+		}
+	}
+	s.reader = csv.NewReader(buffered)
+	s.reader.Comma = s.delimiter
+	s.reader.Comment = s.comment
+	s.reader.LazyQuotes = s.lazyQuotes
+	s.reader.TrimLeadingSpace = s.trimSpace
+	if s.ragged != raggedError {
+		s.reader.FieldsPerRecord = -1
+	}
 	return nil
 }
 
-// Read 读取 CSV 文件中的下一行，并将其转换为一个 core.Record。
-// 如果文件定义了表头，则使用表头作为键；否则，自动生成 "column_1", "column_2", ... 作为键。
-// 它还会校验每行数据的列数是否与表头匹配，以确保数据规整。
-func (s *Source) Read() (record.Record, error) {
-	row, err := s.reader.Read()
-	if err != nil {
-		if err == io.EOF {
-			return nil, io.EOF // 这是数据流正常结束的信号
+// initHeader 根据第一个文件确定输出列。
+func (s *Source) initHeader(header []string) error {
+	switch {
+	case s.columnNames != nil:
+		if header != nil && len(header) != len(s.columnNames) && s.ragged == raggedError {
+			return fmt.Errorf("csv source: 'column_names' has %d columns but the header of %s has %d", len(s.columnNames), s.stream.Name, len(header))
+		}
+		s.header = s.columnNames
+	case header != nil:
+		s.header = header
+	default:
+		s.header = make([]string, len(s.pending))
+		for i := range s.pending {
+			s.header[i] = "column_" + strconv.Itoa(i+1)
+		}
+	}
+	seen := make(map[string]bool, len(s.header))
+	for _, column := range s.header {
+		if seen[column] {
+			return fmt.Errorf("csv source: duplicate column '%s' in %s", column, s.stream.Name)
 		}
-		return nil, fmt.Errorf("csv source: error reading data at line %d: %w", s.line+1, err)
+		seen[column] = true
+	}
+	if s.sourceFile && seen[files.SourceFileColumn] {
+		return fmt.Errorf("csv source: column '%s' of %s conflicts with the source file column", files.SourceFileColumn, s.stream.Name)
 	}
-	s.line++
+	s.order = nil
+	return nil
+}
 
-	r := make(record.Record)
+// Read 读取下一行并转换为一个 core.Record，当前文件读完时自动切换到下一个文件。
+// 列数与表头不一致的行按 ragged_rows 报错、补 NULL 或截断。
+func (s *Source) Read() (record.Record, error) {
+	if s.reader == nil {
+		return nil, io.EOF
+	}
+	var row []string
+	var err error
+	if s.pending != nil {
+		row, s.pending = s.pending, nil
+	} else {
+		row, err = s.reader.Read()
+	}
+	for err == io.EOF {
+		if err = s.nextFile(); err != nil {
+			return nil, err
+		}
+		if s.pending != nil {
+			row, s.pending = s.pending, nil
+		} else {
+			row, err = s.reader.Read()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv source: error reading %s: %w", s.stream.Name, s.positionError(err))
+	}
 
 	// 关键的数据完整性校验：确保每行数据的列数与表头一致。
-	if len(row) != len(s.header) {
-		return nil, fmt.Errorf("csv source: column count mismatch at line %d. Expected %d, got %d", s.line, len(s.header), len(row))
+	if len(row) > len(s.header) && s.ragged != raggedTruncate || len(row) < len(s.header) && s.ragged == raggedError {
+		line, _ := s.reader.FieldPos(0)
+		return nil, fmt.Errorf("csv source: column count mismatch in %s at line %d. Expected %d, got %d", s.stream.Name, line+s.skipped, len(s.header), len(row))
 	}
-	for i, value := range row {
-		r[s.header[i]] = value
+	r := make(record.Record, len(s.header)+1)
+	for i := range s.header {
+		column := s.header[i]
+		if s.order != nil {
+			column = s.header[s.order[i]]
+		}
+		if i >= len(row) {
+			r[column] = nil
+			continue
+		}
+		value := row[i]
+		if s.trimSpace {
+			value = strings.TrimSpace(value)
+		}
+		r[column] = value
+	}
+	if s.sourceFile {
+		r[files.SourceFileColumn] = s.stream.Name
 	}
-
 	return r, nil
 }
 
 // Close 实现了 core.Source 接口，负责关闭已打开的文件句柄，释放资源。
 func (s *Source) Close() error {
-	if s.file != nil {
-		return s.file.Close()
+	err := s.closeStream()
+	if s.files != nil {
+		err = errors.Join(err, s.files.Close())
+		s.files = nil
+	}
+	return err
+}
+
+func (s *Source) closeStream() error {
+	s.reader = nil
+	if s.stream == nil {
+		return nil
+	}
+	err := s.stream.Close()
+	s.stream = nil
+	if err != nil {
+		return fmt.Errorf("csv source: failed to close file: %w", err)
 	}
 	return nil
 }
@@ -129,5 +418,59 @@ func (s *Source) Column() map[string]string {
 	for _, v := range s.header {
 		columns[v] = v
 	}
+	if s.sourceFile {
+		columns[files.SourceFileColumn] = files.SourceFileColumn
+	}
 	return columns
 }
+
+func (s *Source) trim(row []string) []string {
+	if !s.trimSpace {
+		return row
+	}
+	for i, value := range row {
+		row[i] = strings.TrimSpace(value)
+	}
+	return row
+}
+
+// positionError 将 csv.ParseError 转换为带行号与列号的错误信息，行号包含跳过的行。
+func (s *Source) positionError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("line %d, column %d: %w", parseErr.Line+s.skipped, parseErr.Column, parseErr.Err)
+	}
+	return err
+}
+
+// matchHeader 返回 header 中每一列在 expected 中的位置，两者的列不同时报错。
+func matchHeader(expected []string, header []string) ([]int, error) {
+	if len(header) != len(expected) {
+		return nil, fmt.Errorf("expected %d columns, got %d", len(expected), len(header))
+	}
+	index := make(map[string]int, len(expected))
+	for i, column := range expected {
+		index[column] = i
+	}
+	order := make([]int, len(header))
+	for i, column := range header {
+		position, ok := index[column]
+		if !ok {
+			return nil, fmt.Errorf("unexpected column '%s'", column)
+		}
+		order[i] = position
+	}
+	return order, nil
+}
+
+func boolOption(config map[string]string, key string, defaultValue bool) (bool, error) {
+	v := strings.TrimSpace(config[key])
+	if v == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid '%s' %q", key, v)
+	}
+	return parsed, nil
+}
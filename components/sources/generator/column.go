@@ -0,0 +1,337 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+// columnSpec 是 columns 参数中一列的定义，min 与 max 按列类型解析为数字或日期。
+type columnSpec struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Start       *int64          `json:"start"`
+	Step        *int64          `json:"step"`
+	Min         json.RawMessage `json:"min"`
+	Max         json.RawMessage `json:"max"`
+	Precision   *int            `json:"precision"`
+	Values      []any           `json:"values"`
+	Weights     []float64       `json:"weights"`
+	Format      string          `json:"format"`
+	Locale      string          `json:"locale"`
+	NullPercent float64         `json:"null_percent"`
+}
+
+// column 是解析后的列生成器。
+type column struct {
+	name        string
+	kind        string // record 包中的通用类型名
+	nullPercent float64
+	value       func(random *rand.Rand, row int64) any
+}
+
+// 日期列未指定范围时使用的默认范围，固定取值以保证指定 seed 时输出可复现。
+var (
+	defaultMinDate = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defaultMaxDate = time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func newColumn(spec columnSpec) (*column, error) {
+	c := &column{name: strings.TrimSpace(spec.Name), nullPercent: spec.NullPercent}
+	if c.name == "" {
+		return nil, fmt.Errorf("column spec has no name")
+	}
+	if spec.NullPercent < 0 || spec.NullPercent > 100 {
+		return nil, fmt.Errorf("column '%s': 'null_percent' must be between 0 and 100", c.name)
+	}
+	var err error
+	switch strings.ToLower(strings.TrimSpace(spec.Type)) {
+	case "sequence":
+		err = c.sequence(spec)
+	case "int":
+		err = c.integer(spec)
+	case "float":
+		err = c.float(spec)
+	case "bool":
+		c.kind = record.TypeBoolean
+		c.value = func(random *rand.Rand, _ int64) any { return random.IntN(2) == 1 }
+	case "choice":
+		err = c.choice(spec)
+	case "date", "datetime":
+		err = c.date(spec)
+	case "uuid":
+		c.kind = record.TypeString
+		c.value = func(random *rand.Rand, _ int64) any { return uuid(random) }
+	case "name":
+		err = c.personName(spec)
+	default:
+		return nil, fmt.Errorf("column '%s': unsupported type %q, expected sequence, int, float, bool, choice, date, datetime, uuid or name", c.name, spec.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("column '%s': %w", c.name, err)
+	}
+	return c, nil
+}
+
+// generate 生成第 row 行（从 0 开始）的值，按 null_percent 的概率输出 nil。
+// 无论是否输出 nil 都先生成值，使同一 seed 下各列的随机序列不受空值比例影响。
+func (c *column) generate(random *rand.Rand, row int64) any {
+	value := c.value(random, row)
+	if c.nullPercent > 0 && random.Float64()*100 < c.nullPercent {
+		return nil
+	}
+	return value
+}
+
+// sequence 生成 start、start+step、start+2*step ... 的整数序列，默认从 1 开始、步长为 1。
+func (c *column) sequence(spec columnSpec) error {
+	start, step := int64(1), int64(1)
+	if spec.Start != nil {
+		start = *spec.Start
+	}
+	if spec.Step != nil {
+		step = *spec.Step
+	}
+	c.kind = record.TypeInteger
+	c.value = func(_ *rand.Rand, row int64) any { return start + row*step }
+	return nil
+}
+
+// integer 生成 [min, max] 内均匀分布的整数，默认 0 到 100。
+func (c *column) integer(spec columnSpec) error {
+	low, high := int64(0), int64(100)
+	if err := parseNumber(spec.Min, "min", &low); err != nil {
+		return err
+	}
+	if err := parseNumber(spec.Max, "max", &high); err != nil {
+		return err
+	}
+	if low > high {
+		return fmt.Errorf("'min' %d is greater than 'max' %d", low, high)
+	}
+	span := uint64(high - low)
+	c.kind = record.TypeInteger
+	c.value = func(random *rand.Rand, _ int64) any {
+		if span == math.MaxUint64 {
+			return int64(random.Uint64())
+		}
+		return low + int64(random.Uint64N(span+1))
+	}
+	return nil
+}
+
+// float 生成 [min, max) 内均匀分布的小数，默认 0 到 1，设置 precision 时按小数位数四舍五入。
+func (c *column) float(spec columnSpec) error {
+	low, high := 0.0, 1.0
+	if err := parseNumber(spec.Min, "min", &low); err != nil {
+		return err
+	}
+	if err := parseNumber(spec.Max, "max", &high); err != nil {
+		return err
+	}
+	if low > high {
+		return fmt.Errorf("'min' %v is greater than 'max' %v", low, high)
+	}
+	scale := 0.0
+	if spec.Precision != nil {
+		if *spec.Precision < 0 || *spec.Precision > 15 {
+			return fmt.Errorf("'precision' must be between 0 and 15")
+		}
+		scale = math.Pow10(*spec.Precision)
+	}
+	c.kind = record.TypeFloat
+	c.value = func(random *rand.Rand, _ int64) any {
+		value := low + random.Float64()*(high-low)
+		if scale > 0 {
+			value = math.Round(value*scale) / scale
+		}
+		return value
+	}
+	return nil
+}
+
+// choice 从 values 中随机选取，weights 指定各值的相对权重。values 须为同一种 JSON 类型。
+func (c *column) choice(spec columnSpec) error {
+	if len(spec.Values) == 0 {
+		return fmt.Errorf("'values' is required for choice")
+	}
+	values := make([]any, len(spec.Values))
+	copy(values, spec.Values)
+	switch values[0].(type) {
+	case string:
+		c.kind = record.TypeString
+	case bool:
+		c.kind = record.TypeBoolean
+	case float64:
+		c.kind = record.TypeInteger
+	default:
+		return fmt.Errorf("'values' must be strings, numbers or booleans")
+	}
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			if c.kind != record.TypeString {
+				return fmt.Errorf("'values' must all have the same type")
+			}
+		case bool:
+			if c.kind != record.TypeBoolean {
+				return fmt.Errorf("'values' must all have the same type")
+			}
+		case float64:
+			if c.kind != record.TypeInteger && c.kind != record.TypeFloat {
+				return fmt.Errorf("'values' must all have the same type")
+			}
+			if v != math.Trunc(v) {
+				c.kind = record.TypeFloat
+			}
+		default:
+			return fmt.Errorf("'values' must be strings, numbers or booleans")
+		}
+	}
+	if c.kind == record.TypeInteger {
+		for i, v := range values {
+			values[i] = int64(v.(float64))
+		}
+	}
+
+	if len(spec.Weights) == 0 {
+		c.value = func(random *rand.Rand, _ int64) any { return values[random.IntN(len(values))] }
+		return nil
+	}
+	if len(spec.Weights) != len(values) {
+		return fmt.Errorf("'weights' has %d entries but 'values' has %d", len(spec.Weights), len(values))
+	}
+	// 累计权重，按均匀随机数落入的区间选取
+	cumulative := make([]float64, len(spec.Weights))
+	total := 0.0
+	for i, weight := range spec.Weights {
+		if weight < 0 {
+			return fmt.Errorf("'weights' cannot be negative")
+		}
+		total += weight
+		cumulative[i] = total
+	}
+	if total == 0 {
+		return fmt.Errorf("'weights' cannot all be 0")
+	}
+	c.value = func(random *rand.Rand, _ int64) any {
+		target := random.Float64() * total
+		for i, bound := range cumulative {
+			if target < bound {
+				return values[i]
+			}
+		}
+		return values[len(values)-1]
+	}
+	return nil
+}
+
+// date 生成 [min, max] 内均匀分布的日期（date 按天，datetime 按秒），
+// 默认输出 time.Time，设置 format（Go 时间布局，如 2006-01-02）时输出格式化后的字符串。
+func (c *column) date(spec columnSpec) error {
+	datetime := strings.EqualFold(strings.TrimSpace(spec.Type), "datetime")
+	low, high := defaultMinDate, defaultMaxDate
+	if err := parseDate(spec.Min, "min", &low); err != nil {
+		return err
+	}
+	if err := parseDate(spec.Max, "max", &high); err != nil {
+		return err
+	}
+	if low.After(high) {
+		return fmt.Errorf("'min' %s is after 'max' %s", low.Format(time.RFC3339), high.Format(time.RFC3339))
+	}
+	unit := 24 * time.Hour
+	c.kind = record.TypeDate
+	if datetime {
+		unit = time.Second
+		c.kind = record.TypeDatetime
+	} else {
+		low = low.Truncate(unit)
+	}
+	steps := uint64(high.Sub(low) / unit)
+	format := spec.Format
+	if format != "" {
+		c.kind = record.TypeString
+	}
+	c.value = func(random *rand.Rand, _ int64) any {
+		value := low.Add(time.Duration(random.Uint64N(steps+1)) * unit)
+		if format != "" {
+			return value.Format(format)
+		}
+		return value
+	}
+	return nil
+}
+
+// personName 生成随机姓名，locale 为 en（默认）或 zh。
+func (c *column) personName(spec columnSpec) error {
+	var given, family []string
+	separator := " "
+	switch strings.ToLower(strings.TrimSpace(spec.Locale)) {
+	case "", "en":
+		given, family = englishGivenNames, englishFamilyNames
+	case "zh":
+		given, family = chineseGivenNames, chineseFamilyNames
+		separator = ""
+	default:
+		return fmt.Errorf("unsupported 'locale' %q, expected en or zh", spec.Locale)
+	}
+	c.kind = record.TypeString
+	c.value = func(random *rand.Rand, _ int64) any {
+		first := given[random.IntN(len(given))]
+		last := family[random.IntN(len(family))]
+		if separator == "" {
+			return last + first
+		}
+		return first + separator + last
+	}
+	return nil
+}
+
+// parseNumber 解析数字形式的 min/max，未设置时保留默认值。
+func parseNumber[T int64 | float64](raw json.RawMessage, key string, out *T) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("invalid '%s' %s", key, raw)
+	}
+	return nil
+}
+
+// parseDate 解析 2006-01-02、2006-01-02 15:04:05 或 RFC3339 形式的 min/max，未设置时保留默认值。
+func parseDate(raw json.RawMessage, key string, out *time.Time) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return fmt.Errorf("invalid '%s' %s, expected a date string", key, raw)
+	}
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			*out = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid '%s' %q, expected 2006-01-02, 2006-01-02 15:04:05 or RFC3339", key, value)
+}
+
+// uuid 由随机数生成版本 4 的 UUID，使用源自身的随机数以便按 seed 复现。
+func uuid(random *rand.Rand) string {
+	var b [16]byte
+	for i := 0; i < 16; i += 8 {
+		v := random.Uint64()
+		for j := 0; j < 8; j++ {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
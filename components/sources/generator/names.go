@@ -0,0 +1,25 @@
+package generator
+
+// 生成姓名使用的常见名与姓。
+var (
+	englishGivenNames = []string{
+		"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda",
+		"William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+		"Thomas", "Sarah", "Charles", "Karen", "Daniel", "Nancy", "Matthew", "Lisa",
+		"Anthony", "Emily", "Mark", "Olivia", "Steven", "Emma", "Andrew", "Sophia",
+	}
+	englishFamilyNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+		"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor",
+		"Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark",
+	}
+	chineseGivenNames = []string{
+		"伟", "芳", "娜", "敏", "静", "丽", "强", "磊", "洋", "艳", "勇", "军",
+		"杰", "娟", "涛", "明", "超", "秀英", "霞", "平", "刚", "桂英", "建华", "晓东",
+		"子涵", "欣怡", "浩然", "梓萱", "宇轩", "思远", "雨桐", "俊杰",
+	}
+	chineseFamilyNames = []string{
+		"王", "李", "张", "刘", "陈", "杨", "黄", "赵", "吴", "周", "徐", "孙",
+		"马", "朱", "胡", "郭", "何", "高", "林", "罗", "郑", "梁", "谢", "宋",
+	}
+)
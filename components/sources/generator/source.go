@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+)
+
+var name = "generator"
+
+func SetCustomName(customName string) {
+	name = customName
+}
+
+// Source 实现了 core.Source 接口，按列定义生成模拟数据，不需要数据源，
+// 用于在没有真实数据时演示与测试处理器、输出组件以及对引擎做压力测试。
+//
+// 生成 rows 行后结束，设置 duration 时在时长用尽后结束（rows 为 0 时不限行数）。
+// 指定 seed 时相同的配置总是生成相同的数据。
+type Source struct {
+	columns  []*column
+	random   *rand.Rand
+	rows     int64
+	deadline time.Time // 为零值时不限时长
+	emitted  int64
+}
+
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "columns",
+			DefaultValue: "",
+			Required:     true,
+			Description:  `column spec as [{"name":"id","type":"sequence"},{"name":"age","type":"int","min":18,"max":65,"null_percent":5}], types: sequence(start,step), int(min,max), float(min,max,precision), bool, choice(values,weights), date/datetime(min,max,format), uuid, name(locale en/zh)`,
+		},
+		{
+			Key:          "rows",
+			DefaultValue: "1000",
+			Required:     false,
+			Description:  "number of rows to generate, 0 means unlimited when duration is set",
+		},
+		{
+			Key:          "duration",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "stop generating after this duration such as 30s or 5m",
+		},
+		{
+			Key:          "seed",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "random seed for reproducible output, empty uses a random seed",
+		},
+	}
+	return name, &Source{}, nil, paramList
+}
+
+// Open 解析列定义与生成的行数、时长和随机种子。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	columnsVal := strings.TrimSpace(config["columns"])
+	if columnsVal == "" {
+		return fmt.Errorf("generator source: config is missing required key 'columns'")
+	}
+	var specs []columnSpec
+	if err := json.Unmarshal([]byte(columnsVal), &specs); err != nil {
+		return fmt.Errorf("generator source: 'columns' must be an array of column specs: %w", err)
+	}
+	if len(specs) == 0 {
+		return fmt.Errorf("generator source: 'columns' array cannot be empty")
+	}
+	s.columns = s.columns[:0]
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		c, err := newColumn(spec)
+		if err != nil {
+			return fmt.Errorf("generator source: %w", err)
+		}
+		if seen[c.name] {
+			return fmt.Errorf("generator source: duplicate column '%s'", c.name)
+		}
+		seen[c.name] = true
+		s.columns = append(s.columns, c)
+	}
+
+	s.rows = 1000
+	if v := strings.TrimSpace(config["rows"]); v != "" {
+		rows, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || rows < 0 {
+			return fmt.Errorf("generator source: invalid 'rows' %q", v)
+		}
+		s.rows = rows
+	}
+	s.deadline = time.Time{}
+	if v := strings.TrimSpace(config["duration"]); v != "" {
+		duration, err := time.ParseDuration(v)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("generator source: invalid 'duration' %q", v)
+		}
+		s.deadline = time.Now().Add(duration)
+	} else if s.rows == 0 {
+		return fmt.Errorf("generator source: 'rows' must be greater than 0 when 'duration' is not set")
+	}
+
+	var seed uint64
+	if v := strings.TrimSpace(config["seed"]); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("generator source: invalid 'seed' %q", v)
+		}
+		seed = uint64(parsed)
+	} else {
+		seed = rand.Uint64()
+	}
+	s.random = rand.New(rand.NewPCG(seed, seed))
+	s.emitted = 0
+	return nil
+}
+
+// Read 生成下一行，行数或时长用尽时返回 io.EOF。
+func (s *Source) Read() (record.Record, error) {
+	if s.rows > 0 && s.emitted >= s.rows {
+		return nil, io.EOF
+	}
+	if !s.deadline.IsZero() && !time.Now().Before(s.deadline) {
+		return nil, io.EOF
+	}
+	r := make(record.Record, len(s.columns))
+	for _, c := range s.columns {
+		r[c.name] = c.generate(s.random, s.emitted)
+	}
+	s.emitted++
+	return r, nil
+}
+
+// Close 是一个无操作方法，生成器不持有任何资源。
+func (s *Source) Close() error {
+	return nil
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, c := range s.columns {
+		columns[c.name] = c.name
+	}
+	return columns
+}
+
+// ColumnTypes 返回每列生成的值的通用类型。
+func (s *Source) ColumnTypes() map[string]string {
+	types := make(map[string]string)
+	for _, c := range s.columns {
+		types[c.name] = c.kind
+	}
+	return types
+}
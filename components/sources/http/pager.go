@@ -0,0 +1,271 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 分页策略。
+const (
+	paginationNone       = "none"        // 只请求一次
+	paginationPage       = "page"        // 页码递增
+	paginationOffset     = "offset"      // 偏移量按已读记录数递增
+	paginationCursor     = "cursor"      // 响应中给出下一页的游标
+	paginationNextLink   = "next_link"   // 响应中给出下一页的地址
+	paginationLinkHeader = "link_header" // 响应头 Link 中 rel="next" 给出下一页的地址
+)
+
+// pager 保存分页状态，生成每一页的请求地址并根据响应判断是否还有下一页。
+type pager struct {
+	strategy    string
+	pageParam   string
+	offsetParam string
+	sizeParam   string
+	cursorParam string
+	cursorPath  []string
+	page        int
+	offset      int
+	limit       int
+	cursor      string
+	nextURL     string // 下一页的完整地址，只用于 next_link 与 link_header
+	done        bool
+}
+
+// pageState 是请求体模板中可以使用的分页状态。
+type pageState struct {
+	Page   int
+	Offset int
+	Limit  int
+	Cursor string
+}
+
+func newPager(config map[string]string) (*pager, error) {
+	p := &pager{
+		strategy:    strings.ToLower(strings.TrimSpace(config["pagination"])),
+		pageParam:   paramName(config, "page_param", "page"),
+		offsetParam: paramName(config, "offset_param", "offset"),
+		sizeParam:   paramName(config, "page_size_param", "page_size"),
+		cursorParam: paramName(config, "cursor_param", "cursor"),
+		cursorPath:  parsePath(config["cursor_path"]),
+	}
+	var err error
+	if p.page, err = intOption(config, "page_start", 1); err != nil {
+		return nil, err
+	}
+	if p.limit, err = intOption(config, "page_size", 100); err != nil {
+		return nil, err
+	}
+	switch p.strategy {
+	case "":
+		p.strategy = paginationNone
+	case paginationNone, paginationPage, paginationOffset, paginationLinkHeader:
+	case paginationCursor, paginationNextLink:
+		if p.cursorPath == nil {
+			return nil, fmt.Errorf("'cursor_path' is required for %s pagination", p.strategy)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported 'pagination' %q, expected none, page, offset, cursor, next_link or link_header", p.strategy)
+	}
+	return p, nil
+}
+
+func (p *pager) state() pageState {
+	return pageState{Page: p.page, Offset: p.offset, Limit: p.limit, Cursor: p.cursor}
+}
+
+// url 返回当前页的请求地址，分页参数追加到 path 原有的查询参数之后。
+func (p *pager) url(path string, query string) (string, error) {
+	if p.nextURL != "" {
+		return p.nextURL, nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query string in 'path': %w", err)
+	}
+	switch p.strategy {
+	case paginationPage:
+		setParam(values, p.pageParam, strconv.Itoa(p.page))
+		setParam(values, p.sizeParam, strconv.Itoa(p.limit))
+	case paginationOffset:
+		setParam(values, p.offsetParam, strconv.Itoa(p.offset))
+		setParam(values, p.sizeParam, strconv.Itoa(p.limit))
+	case paginationCursor:
+		if p.cursor != "" {
+			setParam(values, p.cursorParam, p.cursor)
+		}
+	}
+	if len(values) == 0 {
+		return path, nil
+	}
+	return path + "?" + values.Encode(), nil
+}
+
+// paramName 返回分页参数名，未配置时使用默认值，配置为空表示不放入查询参数。
+func paramName(config map[string]string, key string, defaultValue string) string {
+	v, ok := config[key]
+	if !ok {
+		return defaultValue
+	}
+	return strings.TrimSpace(v)
+}
+
+func setParam(values url.Values, key string, value string) {
+	if key != "" {
+		values.Set(key, value)
+	}
+}
+
+// advance 根据刚读取的一页推进分页状态，没有下一页时将 done 置为 true。
+// 页码与偏移量分页在某一页的记录数少于 page_size 时结束，游标与链接分页在响应不再给出下一页时结束。
+func (p *pager) advance(resp *nethttp.Response, document any, count int) error {
+	switch p.strategy {
+	case paginationPage, paginationOffset:
+		if count == 0 || (p.limit > 0 && count < p.limit) {
+			p.done = true
+			return nil
+		}
+		p.page++
+		p.offset += count
+	case paginationCursor:
+		value, _ := lookup(document, p.cursorPath)
+		cursor := formatScalar(value)
+		if cursor == "" {
+			p.done = true
+			return nil
+		}
+		if cursor == p.cursor {
+			return fmt.Errorf("cursor %q did not advance", cursor)
+		}
+		p.cursor = cursor
+	case paginationNextLink:
+		value, _ := lookup(document, p.cursorPath)
+		return p.follow(resp, formatScalar(value))
+	case paginationLinkHeader:
+		return p.follow(resp, nextLink(resp.Header))
+	default:
+		p.done = true
+	}
+	return nil
+}
+
+// follow 将下一页地址解析为相对当前请求的完整地址，地址为空时结束分页。
+func (p *pager) follow(resp *nethttp.Response, next string) error {
+	if next == "" {
+		p.done = true
+		return nil
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return fmt.Errorf("invalid next page url %q: %w", next, err)
+	}
+	if resp.Request != nil && resp.Request.URL.IsAbs() {
+		ref = resp.Request.URL.ResolveReference(ref)
+		if ref.String() == resp.Request.URL.String() {
+			return fmt.Errorf("next page url %q is the current page", next)
+		}
+	}
+	p.nextURL = ref.String()
+	return nil
+}
+
+// nextLink 从 RFC 8288 格式的 Link 响应头中取出 rel="next" 的地址。
+// 地址以 <...> 界定，其中可以包含逗号，因此按 <...> 逐个读取链接而不是按逗号拆分。
+func nextLink(header nethttp.Header) string {
+	for _, value := range header.Values("Link") {
+		for {
+			start := strings.IndexByte(value, '<')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(value[start:], '>')
+			if end < 0 {
+				break
+			}
+			target := value[start+1 : start+end]
+			var rel string
+			rel, value = linkRel(value[start+end+1:])
+			for _, v := range strings.Fields(rel) {
+				if strings.EqualFold(v, "next") {
+					return target
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// linkRel 读取一个链接的参数，直到分隔下一个链接的逗号，返回 rel 参数的值与其后剩余的文本。
+// 参数值可以是带引号的字符串，引号中的逗号与分号不作为分隔符。
+func linkRel(s string) (rel string, rest string) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return rel, ""
+		}
+		switch s[0] {
+		case ',':
+			return rel, s[1:]
+		case ';':
+			s = s[1:]
+		default:
+			// 不符合格式的内容，跳到下一个分隔符
+			i := strings.IndexAny(s, ";,")
+			if i < 0 {
+				return rel, ""
+			}
+			s = s[i:]
+			continue
+		}
+		i := strings.IndexAny(s, "=;,")
+		if i < 0 {
+			return rel, ""
+		}
+		key := strings.TrimSpace(s[:i])
+		if s[i] != '=' {
+			s = s[i:]
+			continue
+		}
+		s = strings.TrimLeft(s[i+1:], " \t")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			j := 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			value, s = b.String(), s[min(j+1, len(s)):]
+		} else {
+			j := strings.IndexAny(s, ";,")
+			if j < 0 {
+				j = len(s)
+			}
+			value, s = strings.TrimSpace(s[:j]), s[j:]
+		}
+		if strings.EqualFold(key, "rel") {
+			rel = value
+		}
+	}
+}
+
+// formatScalar 将游标等标量值转换为文本，null 与对象返回空字符串。
+func formatScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBackoff 是两次重试之间的最长等待时间。
+const maxBackoff = time.Minute
+
+// do 发送请求并读取完整的响应体，网络错误、429 与 5xx 响应按指数退避重试。
+func (s *Source) do(req *nethttp.Request) (*nethttp.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("http source: failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+		s.limiter.wait()
+		resp, err := s.client.Do(req)
+		var data []byte
+		if err == nil {
+			data, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return resp, data, nil
+			}
+		}
+
+		retryable := err != nil || resp.StatusCode == nethttp.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= s.maxRetries {
+			if err != nil {
+				return nil, nil, fmt.Errorf("http source: request to %s failed: %w", req.URL.Redacted(), err)
+			}
+			return nil, nil, fmt.Errorf("http source: request to %s failed with status %s: %s", req.URL.Redacted(), resp.Status, snippet(data))
+		}
+		delay := s.backoff << attempt
+		if delay > maxBackoff || delay <= 0 {
+			delay = maxBackoff
+		}
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = after
+			}
+		}
+		time.Sleep(delay)
+	}
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数与 HTTP 日期两种格式。
+func retryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := nethttp.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// snippet 截取响应体的开头用于错误提示。
+func snippet(data []byte) string {
+	const limit = 512
+	text := strings.TrimSpace(string(data))
+	if len(text) > limit {
+		return text[:limit] + "..."
+	}
+	return text
+}
+
+// limiter 保证两次请求之间至少间隔 interval，interval 为 0 时不限速。
+type limiter struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return &limiter{}
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+func (l *limiter) wait() {
+	if l.interval <= 0 {
+		return
+	}
+	if wait := time.Until(l.last.Add(l.interval)); wait > 0 {
+		time.Sleep(wait)
+	}
+	l.last = time.Now()
+}
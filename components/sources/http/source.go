@@ -0,0 +1,457 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+)
+
+var name = "http"
+var datasourceName = "http"
+
+func SetCustomName(customName string, customDatasourceName string) {
+	name = customName
+	datasourceName = customDatasourceName
+}
+
+// Source 实现了 core.Source 接口，用于从 HTTP 接口分页读取 JSON 数据。
+//
+// 请求通过 http 数据源提供的客户端发送，地址、认证与 TLS 由数据源负责。
+// 每次请求一页数据并缓存在内存中，当前页读完后再请求下一页，直到分页策略判断没有更多数据。
+type Source struct {
+	client      *nethttp.Client
+	method      string
+	path        string
+	query       string
+	headers     nethttp.Header
+	body        *template.Template // 请求体模板，为空时不发送请求体
+	recordsPath []string           // 记录数组在响应中的位置
+	pager       *pager
+	limiter     *limiter
+	maxRetries  int
+	backoff     time.Duration
+	maxPages    int
+	pages       int // 已请求的页数
+	buffer      []record.Record
+	next        int // buffer 中下一条待输出的记录
+	columns     []string
+}
+
+// SourceCreator 实现了源组件的创建接口，返回组件名称、实例、数据源类型和参数定义
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "path",
+			DefaultValue: "",
+			Required:     true,
+			Description:  "request path relative to the base_url of the datasource, or an absolute url; may contain a query string",
+		},
+		{
+			Key:          "method",
+			DefaultValue: "GET",
+			Required:     false,
+			Description:  "GET or POST",
+		},
+		{
+			Key:          "headers",
+			DefaultValue: "",
+			Required:     false,
+			Description:  `extra request headers as a json object, e.g. {"Accept": "application/json"}`,
+		},
+		{
+			Key:          "body",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "request body template, {{.Page}}, {{.Offset}}, {{.Limit}} and {{json .Cursor}} are replaced with the pagination state",
+		},
+		{
+			Key:          "records_path",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "JSON pointer (/data/items) or dotted path (data.items) of the record array in the response, empty means the response itself",
+		},
+		{
+			Key:          "columns",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "comma separated output columns, empty uses the keys of the records on the first page",
+		},
+		{
+			Key:          "pagination",
+			DefaultValue: paginationNone,
+			Required:     false,
+			Description:  "none, page, offset, cursor, next_link or link_header",
+		},
+		{
+			Key:          "page_param",
+			DefaultValue: "page",
+			Required:     false,
+			Description:  "query parameter of the page number for page pagination, empty passes it only to the body template",
+		},
+		{
+			Key:          "offset_param",
+			DefaultValue: "offset",
+			Required:     false,
+			Description:  "query parameter of the offset for offset pagination, empty passes it only to the body template",
+		},
+		{
+			Key:          "page_start",
+			DefaultValue: "1",
+			Required:     false,
+			Description:  "number of the first page for page pagination",
+		},
+		{
+			Key:          "page_size_param",
+			DefaultValue: "page_size",
+			Required:     false,
+			Description:  "query parameter of the page size, empty passes it only to the body template",
+		},
+		{
+			Key:          "page_size",
+			DefaultValue: "100",
+			Required:     false,
+			Description:  "records requested per page; page and offset pagination stop at a page with fewer records",
+		},
+		{
+			Key:          "cursor_param",
+			DefaultValue: "cursor",
+			Required:     false,
+			Description:  "query parameter of the cursor for cursor pagination, empty passes it only to the body template",
+		},
+		{
+			Key:          "cursor_path",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "path of the next cursor (cursor) or next page url (next_link) in the response",
+		},
+		{
+			Key:          "max_pages",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "maximum number of pages requested, 0 means no limit",
+		},
+		{
+			Key:          "rate_limit",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "maximum requests per second, 0 means no limit",
+		},
+		{
+			Key:          "max_retries",
+			DefaultValue: "3",
+			Required:     false,
+			Description:  "retries of a request failed with a network error, 429 or 5xx",
+		},
+		{
+			Key:          "retry_backoff",
+			DefaultValue: "1s",
+			Required:     false,
+			Description:  "wait before the first retry, doubled for each further retry; Retry-After of the response takes precedence",
+		},
+	}
+	return name, &Source{}, &datasourceName, paramList
+}
+
+// Open 解析配置并请求第一页数据，输出列默认取自第一页记录的键。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	if dataSource == nil {
+		return fmt.Errorf("http source: datasource is required")
+	}
+	client, ok := (*dataSource).Open().(*nethttp.Client)
+	if !ok || client == nil {
+		return fmt.Errorf("http source: datasource is not an http datasource")
+	}
+	s.client = client
+	if err := s.configure(config); err != nil {
+		return fmt.Errorf("http source: %w", err)
+	}
+
+	s.pages = 0
+	s.buffer, s.next = nil, 0
+	if err := s.fetch(); err != nil {
+		return err
+	}
+	if s.columns == nil {
+		seen := make(map[string]bool)
+		for _, r := range s.buffer {
+			for key := range r {
+				if !seen[key] {
+					seen[key] = true
+					s.columns = append(s.columns, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Source) configure(config map[string]string) error {
+	s.path = strings.TrimSpace(config["path"])
+	if s.path == "" {
+		return fmt.Errorf("config is missing required key 'path'")
+	}
+	if i := strings.Index(s.path, "?"); i >= 0 {
+		s.path, s.query = s.path[:i], s.path[i+1:]
+	} else {
+		s.query = ""
+	}
+	s.method = strings.ToUpper(strings.TrimSpace(config["method"]))
+	switch s.method {
+	case "":
+		s.method = nethttp.MethodGet
+	case nethttp.MethodGet, nethttp.MethodPost:
+	default:
+		return fmt.Errorf("unsupported 'method' %q, expected GET or POST", config["method"])
+	}
+	s.headers = make(nethttp.Header)
+	if v := strings.TrimSpace(config["headers"]); v != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(v), &headers); err != nil {
+			return fmt.Errorf("'headers' is not a json object of strings: %w", err)
+		}
+		for key, value := range headers {
+			s.headers.Set(key, value)
+		}
+	}
+	s.body = nil
+	if v := config["body"]; strings.TrimSpace(v) != "" {
+		var err error
+		s.body, err = template.New("body").Funcs(template.FuncMap{"json": toJSON}).Parse(v)
+		if err != nil {
+			return fmt.Errorf("invalid 'body' template: %w", err)
+		}
+		if s.headers.Get("Content-Type") == "" {
+			s.headers.Set("Content-Type", "application/json")
+		}
+	}
+	s.recordsPath = parsePath(config["records_path"])
+	s.columns = nil
+	if v := strings.TrimSpace(config["columns"]); v != "" {
+		for _, column := range strings.Split(v, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				s.columns = append(s.columns, column)
+			}
+		}
+	}
+
+	var err error
+	if s.pager, err = newPager(config); err != nil {
+		return err
+	}
+	if s.maxPages, err = intOption(config, "max_pages", 0); err != nil {
+		return err
+	}
+	if s.maxRetries, err = intOption(config, "max_retries", 3); err != nil {
+		return err
+	}
+	s.backoff = time.Second
+	if v := strings.TrimSpace(config["retry_backoff"]); v != "" {
+		if s.backoff, err = time.ParseDuration(v); err != nil || s.backoff < 0 {
+			return fmt.Errorf("invalid 'retry_backoff' %q", v)
+		}
+	}
+	rate := 0.0
+	if v := strings.TrimSpace(config["rate_limit"]); v != "" {
+		if rate, err = strconv.ParseFloat(v, 64); err != nil || rate < 0 {
+			return fmt.Errorf("invalid 'rate_limit' %q", v)
+		}
+	}
+	s.limiter = newLimiter(rate)
+	return nil
+}
+
+// Read 返回下一条记录，当前页读完时请求下一页，没有更多数据时返回 io.EOF。
+func (s *Source) Read() (record.Record, error) {
+	for s.next >= len(s.buffer) {
+		if s.pager == nil || s.pager.done || (s.maxPages > 0 && s.pages >= s.maxPages) {
+			return nil, io.EOF
+		}
+		if err := s.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	r := s.buffer[s.next]
+	s.buffer[s.next] = nil
+	s.next++
+	return r, nil
+}
+
+// fetch 请求当前页并用其中的记录替换缓存，然后推进分页状态。
+func (s *Source) fetch() error {
+	req, err := s.newRequest()
+	if err != nil {
+		return err
+	}
+	resp, data, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	s.pages++
+
+	var document any
+	if len(strings.TrimSpace(string(data))) > 0 {
+		// 数字解码为 json.Number，超过 2^53 的整数 id 不会因转换为 float64 而丢失精度
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err := decoder.Decode(&document)
+		if err == nil && decoder.More() {
+			err = errors.New("invalid character after top-level value")
+		}
+		if err != nil {
+			return fmt.Errorf("http source: page %d of %s is not valid json: %w", s.pages, req.URL.Redacted(), err)
+		}
+	}
+	records, err := extractRecords(document, s.recordsPath)
+	if err != nil {
+		return fmt.Errorf("http source: page %d of %s: %w", s.pages, req.URL.Redacted(), err)
+	}
+	s.buffer, s.next = records, 0
+	if err := s.pager.advance(resp, document, len(records)); err != nil {
+		return fmt.Errorf("http source: page %d of %s: %w", s.pages, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// newRequest 按分页状态生成当前页的请求。
+func (s *Source) newRequest() (*nethttp.Request, error) {
+	target, err := s.pager.url(s.path, s.query)
+	if err != nil {
+		return nil, fmt.Errorf("http source: %w", err)
+	}
+	var body io.Reader
+	var text string
+	if s.body != nil {
+		var buf strings.Builder
+		if err := s.body.Execute(&buf, s.pager.state()); err != nil {
+			return nil, fmt.Errorf("http source: failed to render 'body': %w", err)
+		}
+		text = buf.String()
+		body = strings.NewReader(text)
+	}
+	req, err := nethttp.NewRequest(s.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("http source: invalid request url %q: %w", target, err)
+	}
+	if s.body != nil {
+		// 重试时需要重新读取请求体
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(text)), nil
+		}
+	}
+	for key, values := range s.headers {
+		req.Header[key] = values
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	return req, nil
+}
+
+// Close 释放缓存的记录。
+func (s *Source) Close() error {
+	s.buffer, s.next = nil, 0
+	s.pager = nil
+	return nil
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, v := range s.columns {
+		columns[v] = v
+	}
+	return columns
+}
+
+// extractRecords 取出 path 指向的记录数组，单个对象作为一条记录，null 或不存在表示没有记录。
+func extractRecords(document any, path []string) ([]record.Record, error) {
+	value, ok := lookup(document, path)
+	if !ok || value == nil {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case []any:
+		records := make([]record.Record, 0, len(v))
+		for i, item := range v {
+			object, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("record #%d is not a json object", i+1)
+			}
+			records = append(records, object)
+		}
+		return records, nil
+	case map[string]any:
+		return []record.Record{v}, nil
+	default:
+		return nil, fmt.Errorf("records at '/%s' are neither an array nor an object", strings.Join(path, "/"))
+	}
+}
+
+// lookup 在解码后的 JSON 文档中按路径取值。
+func lookup(document any, path []string) (any, bool) {
+	value := document
+	for _, segment := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			var ok bool
+			if value, ok = v[segment]; !ok {
+				return nil, false
+			}
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// parsePath 解析 JSON 中的位置，支持 JSON Pointer(/data/items) 与点号分隔(data.items)两种写法。
+func parsePath(path string) []string {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "/" {
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return strings.Split(path, ".")
+	}
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		// RFC 6901 转义：~1 表示 /，~0 表示 ~
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	return segments
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func intOption(config map[string]string, key string, defaultValue int) (int, error) {
+	v := strings.TrimSpace(config[key])
+	if v == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid '%s' %q", key, v)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,292 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+// clientDatasource 将测试服务器的客户端作为 http 数据源传给 Source。
+type clientDatasource struct{ client *nethttp.Client }
+
+func (d clientDatasource) Init(map[string]string) error { return nil }
+func (d clientDatasource) Open() any                    { return d.client }
+func (d clientDatasource) Close() error                 { return nil }
+
+// fixtureServer 记录收到的每个请求的地址，handler 返回响应体。
+type fixtureServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+// seen 返回目前收到的请求地址。
+func (s *fixtureServer) seen() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func newFixtureServer(t *testing.T, handler func(w nethttp.ResponseWriter, r *nethttp.Request) any) *fixtureServer {
+	t.Helper()
+	s := &fixtureServer{}
+	s.Server = httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.URL.RequestURI())
+		s.mu.Unlock()
+		if body := handler(w, r); body != nil {
+			_ = json.NewEncoder(w).Encode(body)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// items 返回 id 为 [from, to) 的记录，to 不超过 total。
+func items(from, to, total int) []map[string]any {
+	list := []map[string]any{}
+	for id := from; id < min(to, total); id++ {
+		list = append(list, map[string]any{"id": id})
+	}
+	return list
+}
+
+func readAll(t *testing.T, server *fixtureServer, config map[string]string) []record.Record {
+	t.Helper()
+	s := &Source{}
+	var ds datasource.Datasource = clientDatasource{client: server.Client()}
+	if err := s.Open(config, &ds); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var records []record.Record
+	for {
+		r, err := s.Read()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+}
+
+func checkIDs(t *testing.T, records []record.Record, total int) {
+	t.Helper()
+	if len(records) != total {
+		t.Fatalf("read %d records, want %d", len(records), total)
+	}
+	for i, r := range records {
+		if r["id"] != json.Number(strconv.Itoa(i)) {
+			t.Errorf("record %d has id %v", i, r["id"])
+		}
+	}
+}
+
+func checkRequests(t *testing.T, server *fixtureServer, want []string) {
+	t.Helper()
+	if got := server.seen(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestPagePagination(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		return map[string]any{"data": items((page-1)*size, page*size, 5)}
+	})
+	records := readAll(t, server, map[string]string{
+		"path":         server.URL + "/items?q=x",
+		"records_path": "data",
+		"pagination":   paginationPage,
+		"page_size":    "2",
+	})
+	checkIDs(t, records, 5)
+	checkRequests(t, server, []string{
+		"/items?page=1&page_size=2&q=x",
+		"/items?page=2&page_size=2&q=x",
+		"/items?page=3&page_size=2&q=x",
+	})
+}
+
+func TestOffsetPagination(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		return items(offset, offset+limit, 4)
+	})
+	records := readAll(t, server, map[string]string{
+		"path":            server.URL + "/items",
+		"pagination":      paginationOffset,
+		"offset_param":    "skip",
+		"page_size_param": "limit",
+		"page_size":       "2",
+	})
+	checkIDs(t, records, 4)
+	// 最后一页刚好取满时还需要再请求一次空页才能判断结束
+	checkRequests(t, server, []string{
+		"/items?limit=2&skip=0",
+		"/items?limit=2&skip=2",
+		"/items?limit=2&skip=4",
+	})
+}
+
+func TestCursorPagination(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		from, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("after"), "c"))
+		var next any
+		if from+2 < 5 {
+			next = "c" + strconv.Itoa(from+2)
+		}
+		return map[string]any{"data": items(from, from+2, 5), "meta": map[string]any{"next": next}}
+	})
+	records := readAll(t, server, map[string]string{
+		"path":         server.URL + "/items",
+		"records_path": "/data",
+		"pagination":   paginationCursor,
+		"cursor_param": "after",
+		"cursor_path":  "/meta/next",
+	})
+	checkIDs(t, records, 5)
+	checkRequests(t, server, []string{"/items", "/items?after=c2", "/items?after=c4"})
+}
+
+func TestLargeIntegers(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		// 2^53 + 1 与 2^53 + 3 无法用 float64 精确表示
+		if r.URL.Query().Get("after") == "" {
+			return json.RawMessage(`{"data": [{"id": 9007199254740993, "price": 1.25}], "meta": {"next": 9007199254740995}}`)
+		}
+		return json.RawMessage(`{"data": [], "meta": {"next": null}}`)
+	})
+	records := readAll(t, server, map[string]string{
+		"path":         server.URL + "/items",
+		"records_path": "data",
+		"pagination":   paginationCursor,
+		"cursor_param": "after",
+		"cursor_path":  "meta.next",
+	})
+	if len(records) != 1 || records[0]["id"] != json.Number("9007199254740993") || records[0]["price"] != json.Number("1.25") {
+		t.Fatalf("records = %v, want id 9007199254740993 and price 1.25 as json.Number", records)
+	}
+	checkRequests(t, server, []string{"/items", "/items?after=9007199254740995"})
+}
+
+func TestNextLinkPagination(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		body := map[string]any{"data": items(from, from+2, 5)}
+		if from+2 < 5 {
+			// 相对地址按当前请求解析
+			body["next"] = "list?from=" + strconv.Itoa(from+2)
+		}
+		return body
+	})
+	records := readAll(t, server, map[string]string{
+		"path":         server.URL + "/v1/list",
+		"records_path": "data",
+		"pagination":   paginationNextLink,
+		"cursor_path":  "next",
+	})
+	checkIDs(t, records, 5)
+	checkRequests(t, server, []string{"/v1/list", "/v1/list?from=2", "/v1/list?from=4"})
+}
+
+func TestLinkHeaderPagination(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		links := []string{`</items?from=0&fields=id,name>; rel="first"`}
+		if from+2 < 5 {
+			links = append(links, `</items?from=`+strconv.Itoa(from+2)+`&fields=id,name>; title="a, b; c"; rel="next"`)
+		}
+		w.Header().Set("Link", strings.Join(links, ", "))
+		return items(from, from+2, 5)
+	})
+	records := readAll(t, server, map[string]string{
+		"path":       server.URL + "/items",
+		"pagination": paginationLinkHeader,
+	})
+	checkIDs(t, records, 5)
+	checkRequests(t, server, []string{"/items", "/items?from=2&fields=id,name", "/items?from=4&fields=id,name"})
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"single", []string{`<https://api.example.com/items?page=2>; rel="next"`}, "https://api.example.com/items?page=2"},
+		{"comma in url", []string{`<https://api.example.com/items?ids=1,2&page=2>; rel="next"`}, "https://api.example.com/items?ids=1,2&page=2"},
+		{"after other links", []string{`<https://a/1>; rel="prev", <https://a/3>; rel="next", <https://a/9>; rel="last"`}, "https://a/3"},
+		{"quoted parameter with separators", []string{`<https://a/1>; title="x, <y>; rel=next"; rel="prev", <https://a/2>; rel=next`}, "https://a/2"},
+		{"multiple relations", []string{`<https://a/2>; rel="next last"`}, "https://a/2"},
+		{"case insensitive", []string{`<https://a/2>; REL="Next"`}, "https://a/2"},
+		{"separate header values", []string{`<https://a/1>; rel="prev"`, `<https://a/2>; rel="next"`}, "https://a/2"},
+		{"no next", []string{`<https://a/1>; rel="prev"`}, ""},
+		{"malformed", []string{`https://a/2; rel="next"`, `<https://a/3; rel="next"`}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := nethttp.Header{"Link": tt.values}
+			if got := nextLink(header); got != tt.want {
+				t.Errorf("nextLink(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterOn429(t *testing.T) {
+	var calls atomic.Int32
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(nethttp.StatusTooManyRequests)
+			return nil
+		}
+		return items(0, 2, 2)
+	})
+	started := time.Now()
+	records := readAll(t, server, map[string]string{
+		"path": server.URL + "/items",
+		// Retry-After 优先于退避时间
+		"retry_backoff": "1ms",
+	})
+	checkIDs(t, records, 2)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s of Retry-After", elapsed)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server := newFixtureServer(t, func(w nethttp.ResponseWriter, r *nethttp.Request) any {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(nethttp.StatusTooManyRequests)
+		return nil
+	})
+	s := &Source{}
+	var ds datasource.Datasource = clientDatasource{client: server.Client()}
+	err := s.Open(map[string]string{"path": server.URL + "/items", "max_retries": "2"}, &ds)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("Open() error = %v, want a 429 error", err)
+	}
+	if n := len(server.seen()); n != 3 {
+		t.Errorf("server got %d requests, want 1 plus 2 retries", n)
+	}
+}
@@ -0,0 +1,287 @@
+package json
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/files"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+const (
+	formatAuto   = "auto"
+	formatArray  = "array"
+	formatNdjson = "ndjson"
+)
+
+// reader 顺序读取一个文件中的记录，Open 中的键扫描与 Read 各使用独立的 reader。
+type reader struct {
+	stream  *files.Stream
+	lines   *lineCounter
+	buffer  *bufio.Reader
+	decoder *json.Decoder
+	format  string
+	count   int // 已读取的记录数，用于错误提示
+}
+
+// openReader 在已打开的文件上定位到第一条记录之前。
+// path 不为空时文件被视为单个 JSON 文档，记录数组位于 path 指向的位置；
+// 否则根据首个非空白字符判断是对象数组还是每行一个对象的 NDJSON。
+func openReader(stream *files.Stream, format string, path []string) (*reader, error) {
+	lines := &lineCounter{reader: stream, line: 1}
+	buffer := bufio.NewReader(lines)
+	r := &reader{stream: stream, lines: lines, buffer: buffer, decoder: json.NewDecoder(buffer), format: format}
+	if err := r.locate(path); err != nil {
+		_ = stream.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reader) locate(path []string) error {
+	if r.format == formatAuto {
+		if len(path) > 0 {
+			r.format = formatArray
+		} else {
+			first, err := r.peek()
+			if err != nil {
+				return err
+			}
+			if first == '[' {
+				r.format = formatArray
+			} else {
+				r.format = formatNdjson
+			}
+		}
+	}
+	if r.format == formatNdjson {
+		if len(path) > 0 {
+			return fmt.Errorf("json source: %s: 'path' is not supported for ndjson files", r.stream.Name)
+		}
+		return nil
+	}
+	for i, segment := range path {
+		if err := r.enter(segment); err != nil {
+			return fmt.Errorf("json source: %s: path /%s: %w", r.stream.Name, strings.Join(path[:i+1], "/"), err)
+		}
+	}
+	// 验证记录位置是一个 JSON 数组，这是一种"快速失败"策略，可以及早确认文件格式是否符合预期。
+	token, err := r.decoder.Token()
+	if err != nil {
+		return r.errorf(err, "failed to read opening bracket of json array: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return r.errorf(nil, "expected a json array '[', but got '%v'", token)
+	}
+	return nil
+}
+
+// peek 返回文件中首个非空白字符，不移动解码位置。
+func (r *reader) peek() (byte, error) {
+	for n := 1; ; n++ {
+		buf, err := r.buffer.Peek(n)
+		if len(buf) == n {
+			if c := buf[n-1]; c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				return c, nil
+			}
+			continue
+		}
+		if err == io.EOF || err == bufio.ErrBufferFull {
+			// 开头超过缓冲区大小的空白也按空文件处理
+			return 0, fmt.Errorf("json source: %s: file is empty", r.stream.Name)
+		}
+		return 0, fmt.Errorf("json source: failed to read %s: %w", r.stream.Name, err)
+	}
+}
+
+// enter 在当前值中找到 segment 对应的成员或数组元素，并将解码位置停在该值之前。
+// 其余成员按 token 跳过，不会被完整解码到内存中。
+func (r *reader) enter(segment string) error {
+	token, err := r.decoder.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := token.(json.Delim)
+	switch {
+	case ok && delim == '{':
+		for r.decoder.More() {
+			keyToken, err := r.decoder.Token()
+			if err != nil {
+				return err
+			}
+			if key, _ := keyToken.(string); key == segment {
+				return nil
+			}
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("member %q not found", segment)
+	case ok && delim == '[':
+		index, err := strconv.Atoi(segment)
+		if err != nil || index < 0 {
+			return fmt.Errorf("expected an array index, got %q", segment)
+		}
+		for i := 0; r.decoder.More(); i++ {
+			if i == index {
+				return nil
+			}
+			if err := r.skip(); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("array index %d out of range", index)
+	default:
+		return fmt.Errorf("cannot descend into scalar value '%v'", token)
+	}
+}
+
+// skip 跳过一个完整的 JSON 值。
+func (r *reader) skip() error {
+	depth := 0
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// next 读取下一条记录，记录读完时返回 io.EOF。
+func (r *reader) next() (record.Record, error) {
+	if r.format == formatArray && !r.decoder.More() {
+		// 当没有更多元素时，我们期望读到数组的结束符 ']'，这确认了记录数组的结构是完整的。
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, r.errorf(err, "error reading closing bracket of json array: %w", err)
+		}
+		if delim, ok := token.(json.Delim); ok && delim == ']' {
+			return nil, io.EOF
+		}
+		return nil, r.errorf(nil, "expected end of json array ']', but got '%v'", token)
+	}
+	var value any
+	if err := r.decoder.Decode(&value); err != nil {
+		if err == io.EOF {
+			if r.format == formatNdjson {
+				return nil, io.EOF
+			}
+			return nil, r.errorf(nil, "unexpected end of file, json array not closed with ']'")
+		}
+		return nil, r.errorf(err, "failed to decode record #%d: %w", r.count+1, err)
+	}
+	r.count++
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, r.errorf(nil, "record #%d is not a json object", r.count)
+	}
+	// 及时推进行号，释放已经越过的换行位置
+	r.lines.lineAt(r.decoder.InputOffset())
+	return object, nil
+}
+
+// errorf 生成带文件名与行号的错误，语法错误使用出错的位置，其余错误使用当前的解码位置。
+func (r *reader) errorf(cause error, format string, args ...any) error {
+	offset := r.decoder.InputOffset()
+	var syntaxErr *json.SyntaxError
+	if errors.As(cause, &syntaxErr) && syntaxErr.Offset > offset {
+		offset = syntaxErr.Offset
+	}
+	return fmt.Errorf("json source: %s line %d: %w", r.stream.Name, r.lines.lineAt(offset), fmt.Errorf(format, args...))
+}
+
+func (r *reader) close() error {
+	return r.stream.Close()
+}
+
+// lineCounter 记录读取过的换行位置，用于将解码器的字节偏移换算为行号。
+// 解码器会预读数据，因此只保存尚未越过的换行位置，查询的偏移必须单调递增。
+type lineCounter struct {
+	reader   io.Reader
+	offset   int64   // 已读取的字节数
+	newlines []int64 // 尚未越过的换行位置
+	line     int     // 已越过的换行数加一
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.newlines = append(c.newlines, c.offset+int64(i))
+		}
+	}
+	c.offset += int64(n)
+	return n, err
+}
+
+// lineAt 返回字节偏移 offset 所在的行号。
+func (c *lineCounter) lineAt(offset int64) int {
+	passed := 0
+	for passed < len(c.newlines) && c.newlines[passed] < offset {
+		passed++
+	}
+	c.line += passed
+	c.newlines = c.newlines[passed:]
+	return c.line
+}
+
+// parsePath 解析记录数组的位置，支持 JSON Pointer(/data/items) 与点号分隔(data.items)两种写法。
+func parsePath(path string) []string {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "/" {
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return strings.Split(path, ".")
+	}
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		// RFC 6901 转义：~1 表示 /，~0 表示 ~
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	return segments
+}
+
+// flatten 将嵌套对象展开为 parent.child 形式的列，depth 为展开的层数，小于 0 表示不限层数。
+// 数组保持原值，空对象展开为 NULL。
+func flatten(r record.Record, depth int, separator string) record.Record {
+	if depth == 0 {
+		return r
+	}
+	out := make(record.Record, len(r))
+	flattenInto(out, "", r, depth, separator)
+	return out
+}
+
+func flattenInto(out record.Record, prefix string, object map[string]any, depth int, separator string) {
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + separator + key
+		}
+		nested, ok := value.(map[string]any)
+		if !ok || depth == 0 {
+			out[key] = value
+			continue
+		}
+		if len(nested) == 0 {
+			out[key] = nil
+			continue
+		}
+		flattenInto(out, key, nested, depth-1, separator)
+	}
+}
@@ -1,13 +1,14 @@
 package json
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"strconv"
+	"strings"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/files"
 	"github.com/BernardSimon/etl-go/etl/core/params"
 	"github.com/BernardSimon/etl-go/etl/core/record"
 	"github.com/BernardSimon/etl-go/etl/core/source"
@@ -17,16 +18,22 @@ var name = "json"
 
 // Source 实现了 core.Source 接口，用于从 JSON 文件读取数据。
 //
-// 它被设计用来流式处理一个包含对象数组的 JSON 文件。
-// 示例格式: [{"id": 1, "name": "A"}, {"id": 2, "name": "B"}]
+// 支持两种文件格式：
+//   - 对象数组: [{"id": 1, "name": "A"}, {"id": 2, "name": "B"}]，数组可以位于文档内部，由 path 指定位置；
+//   - JSON Lines / NDJSON: 每行一个独立的 JSON 对象。
 //
-// 注意：此实现不支持 JSON Lines (.jsonl) 或 NDJSON 格式（即每行一个独立的JSON对象）。
-// 它通过使用标准库的 json.Decoder，实现了高效的内存使用，可以处理G字节级别的大文件。
+// 它通过使用标准库的 json.Decoder 流式解码，实现了高效的内存使用，可以处理G字节级别的大文件。
+// 配置 file_ids 时多个文件按顺序作为一个数据流读取，gzip、zstd 压缩文件与 zip 压缩包中的文件会被透明地解压。
 type Source struct {
-	filePath string        // 要读取的JSON文件路径
-	file     *os.File      // 文件句柄
-	decoder  *json.Decoder // Go 标准库的流式 JSON 解码器
-	keys     []string      // 所有可能的键集合
+	inputs           []files.Input
+	files            *files.Reader
+	format           string   // auto、array 或 ndjson
+	path             []string // 记录数组在文档中的位置
+	flattenDepth     int      // 嵌套对象展开的层数
+	flattenSeparator string   // 展开后列名的分隔符
+	reader           *reader  // 当前文件的 reader
+	keys             []string // 所有记录的键的并集
+	sourceFile       bool     // 是否输出来源文件列
 }
 
 // SourceCreator 实现了源组件的创建接口，返回组件名称、实例和参数定义
@@ -35,134 +42,200 @@ func SourceCreator() (string, source.Source, *string, []params.Params) {
 		{
 			Key:          "file_id",
 			DefaultValue: "",
-			Required:     true,
+			Required:     false,
 			Description:  "The file_id to the JSON file",
 		},
+		{
+			Key:          "file_ids",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Comma separated file_ids read in order as one stream, used instead of file_id",
+		},
+		{
+			Key:          "format",
+			DefaultValue: formatAuto,
+			Required:     false,
+			Description:  "auto, array or ndjson; auto detects a json array or one object per line",
+		},
+		{
+			Key:          "path",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "JSON pointer (/data/items) or dotted path (data.items) of the record array, empty means the document root",
+		},
+		{
+			Key:          "flatten_depth",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "levels of nested objects flattened into parent.child columns, 0 keeps nested objects, -1 flattens all levels",
+		},
+		{
+			Key:          "flatten_separator",
+			DefaultValue: ".",
+			Required:     false,
+			Description:  "separator between parent and child names of flattened columns",
+		},
 		{
 			Key:          "keys_sample_rows",
-			DefaultValue: "100",
-			Required:     true,
-			Description:  "Number of rows to sample for determining keys, default is 100",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "Number of rows scanned for determining keys, 0 scans all rows",
+		},
+		{
+			Key:          "include_source_file",
+			DefaultValue: "false",
+			Required:     false,
+			Description:  "Add a _source_file column with the name of the file each record was read from",
 		},
 	}
 
 	return name, &Source{}, nil, paramList
 }
 
-// Open 负责解析配置、打开文件，并验证 JSON 格式的起始部分。
-// 一个关键步骤是它会立即尝试读取 JSON 数组的起始符'['。
-// 这是一种"快速失败"策略，可以及早确认文件格式是否符合预期。
+// Open 负责解析配置并打开文件。
+// 为了得到所有记录的键的并集，Open 会先流式扫描一遍文件，只保留键而不保留记录，然后重新打开文件供 Read 读取。
 func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
-	filePath, ok := config["file_path"]
-	if !ok {
-		return fmt.Errorf("json source: config is missing required key 'file_path'")
+	var err error
+	s.inputs, err = files.Inputs(config)
+	if err != nil {
+		return fmt.Errorf("json source: %w", err)
 	}
-	s.filePath = filePath
 
-	// 采样行数
-	keysSampleRows := 100
+	s.format = strings.ToLower(strings.TrimSpace(config["format"]))
+	switch s.format {
+	case "":
+		s.format = formatAuto
+	case formatAuto, formatArray, formatNdjson:
+	case "jsonl":
+		s.format = formatNdjson
+	default:
+		return fmt.Errorf("json source: unsupported 'format' %q, expected auto, array or ndjson", s.format)
+	}
+	s.path = parsePath(config["path"])
+	s.flattenDepth = 0
+	if v := strings.TrimSpace(config["flatten_depth"]); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("json source: invalid 'flatten_depth' %q", v)
+		}
+		s.flattenDepth = parsed
+	}
+	s.flattenSeparator = config["flatten_separator"]
+	if s.flattenSeparator == "" {
+		s.flattenSeparator = "."
+	}
+	keysSampleRows := 0
 	if keysSampleRowsStr, ok := config["keys_sample_rows"]; ok {
 		if parsed, err := strconv.Atoi(keysSampleRowsStr); err == nil && parsed >= 0 {
 			keysSampleRows = parsed
 		}
 	}
-	var err error
-	s.file, err = os.Open(s.filePath)
-	if err != nil {
-		return fmt.Errorf("json source: failed to open file %s: %w", s.filePath, err)
-	}
-
-	s.decoder = json.NewDecoder(s.file)
+	s.sourceFile = strings.EqualFold(strings.TrimSpace(config["include_source_file"]), "true")
 
-	// 验证文件是否以 `[` 开头。
-	token, err := s.decoder.Token()
-	if err != nil {
-		return fmt.Errorf("json source: failed to read opening bracket of json array: %w", err)
-	}
-	if delim, ok := token.(json.Delim); !ok || delim != '[' {
-		return fmt.Errorf("json source: expected file to start with a json array '[', but got '%v'", token)
+	if err := s.scanKeys(keysSampleRows); err != nil {
+		return err
 	}
+	s.files = files.NewReader(s.inputs)
+	s.reader = nil
+	return nil
+}
 
-	// 新增逻辑：预读取所有对象的 keys 并集
-	keysSet := make(map[string]bool)
-	sampleRows := 0
-
-	for s.decoder.More() {
-		sampleRows++
-		var r record.Record
-		if err := s.decoder.Decode(&r); err != nil {
+// scanKeys 流式读取记录并按首次出现的顺序收集键的并集，limit 大于 0 时只扫描前 limit 条记录。
+func (s *Source) scanKeys(limit int) error {
+	inputs := files.NewReader(s.inputs)
+	defer inputs.Close()
+	seen := make(map[string]bool)
+	s.keys = nil
+	rows := 0
+	for limit <= 0 || rows < limit {
+		stream, err := inputs.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("json source: %w", err)
+		}
+		r, err := openReader(stream, s.format, s.path)
+		if err != nil {
+			return err
+		}
+		for ; limit <= 0 || rows < limit; rows++ {
+			rec, err := r.next()
 			if err == io.EOF {
 				break
 			}
-			return fmt.Errorf("json source: failed to decode json object: %w", err)
-		}
-
-		for key := range r {
-			keysSet[key] = true
+			if err != nil {
+				_ = r.close()
+				return err
+			}
+			for key := range flatten(rec, s.flattenDepth, s.flattenSeparator) {
+				if !seen[key] {
+					seen[key] = true
+					s.keys = append(s.keys, key)
+				}
+			}
 		}
-
-		if sampleRows >= keysSampleRows && keysSampleRows > 0 {
-			break
+		if err := r.close(); err != nil {
+			return fmt.Errorf("json source: failed to close %s: %w", stream.Name, err)
 		}
 	}
-
-	// 将 keysSet 转换为 slice 存储到 s.keys
-	s.keys = make([]string, 0, len(keysSet))
-	for key := range keysSet {
-		s.keys = append(s.keys, key)
-	}
-
-	// 回到文件开始位置以便后续正常读取
-	_, err = s.file.Seek(0, 0)
-	if err != nil {
-		return err
+	if s.sourceFile {
+		if seen[files.SourceFileColumn] {
+			return fmt.Errorf("json source: key '%s' conflicts with the source file column", files.SourceFileColumn)
+		}
+		s.keys = append(s.keys, files.SourceFileColumn)
 	}
-	s.decoder = json.NewDecoder(s.file)
-	_, err = s.decoder.Token()
-	if err != nil {
-		return err
-	} // Skip the opening '['
-
 	return nil
 }
 
-// Read 从 JSON 数组流中解码下一个对象，并将其转换为 core.Record。
-// 它依赖 `decoder.More()` 来判断数组中是否还有更多元素。
-// 当 `More()` 返回 false 时，表示已到达数组末尾，此时方法会返回 io.EOF 来通知管道数据已耗尽。
+// Read 读取下一条记录，并按配置展开嵌套对象。当前文件读完时自动切换到下一个文件，
+// 所有文件读完时返回 io.EOF 来通知管道数据已耗尽。
 func (s *Source) Read() (record.Record, error) {
-	// `decoder.More()` 是驱动流式读取的核心。
-	if !s.decoder.More() {
-		// 当没有更多元素时，我们期望读到数组的结束符 ']'。
-		// 这确认了 JSON 文件的结构是完整的。
-		token, err := s.decoder.Token()
-		if err != nil {
-			return nil, fmt.Errorf("json source: error reading closing bracket of json array: %w", err)
-		}
-		if delim, ok := token.(json.Delim); ok && delim == ']' {
-			return nil, io.EOF // 成功到达数组末尾，这是数据流正常结束的信号。
+	for {
+		if s.reader == nil {
+			stream, err := s.files.Next()
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			if err != nil {
+				return nil, fmt.Errorf("json source: %w", err)
+			}
+			if s.reader, err = openReader(stream, s.format, s.path); err != nil {
+				return nil, err
+			}
 		}
-		return nil, fmt.Errorf("json source: expected end of json array ']', but got '%v'", token)
-	}
-
-	// 解码下一个 JSON 对象到 record 中。
-	var r record.Record
-	if err := s.decoder.Decode(&r); err != nil {
-		// 如果在解码过程中遇到 io.EOF，说明文件在数组结束前被截断了。
+		r, err := s.reader.next()
 		if err == io.EOF {
-			return nil, fmt.Errorf("json source: unexpected end of file, json array not closed with ']'")
+			err = s.reader.close()
+			s.reader = nil
+			if err != nil {
+				return nil, fmt.Errorf("json source: failed to close file: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r = flatten(r, s.flattenDepth, s.flattenSeparator)
+		if s.sourceFile {
+			r[files.SourceFileColumn] = s.reader.stream.Name
 		}
-		return nil, fmt.Errorf("json source: failed to decode json object: %w", err)
+		return r, nil
 	}
-
-	return r, nil
 }
 
 // Close 关闭文件句柄，释放资源。
 func (s *Source) Close() error {
-	if s.file != nil {
-		return s.file.Close()
+	var err error
+	if s.reader != nil {
+		err = s.reader.close()
+		s.reader = nil
 	}
-	return nil
+	if s.files != nil {
+		err = errors.Join(err, s.files.Close())
+		s.files = nil
+	}
+	return err
 }
 
 // Column 返回源数据的列映射关系
@@ -0,0 +1,184 @@
+package mysqlCdc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/google/uuid"
+)
+
+const (
+	opInsert = "insert"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// handle 处理一个 binlog 事件：行事件转换为待输出的记录，事务提交时推进水位线，在终点之后的第一个提交处结束读取。
+func (s *Source) handle(event *replication.BinlogEvent) error {
+	switch e := event.Event.(type) {
+	case *replication.RotateEvent:
+		s.file = string(e.NextLogName)
+	case *replication.GTIDEvent:
+		s.gtid = e
+	case *replication.XIDEvent:
+		if err := s.commit(event.Header.LogPos); err != nil {
+			return err
+		}
+	case *replication.QueryEvent:
+		// DDL 以 QueryEvent 单独提交，BEGIN 只是事务的开始
+		if !strings.EqualFold(strings.TrimSpace(string(e.Query)), "BEGIN") {
+			if err := s.commit(event.Header.LogPos); err != nil {
+				return err
+			}
+		}
+	case *replication.RowsEvent:
+		if err := s.rows(event.Header, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit 在事务提交处记录已提交的位置，GTID 模式下把当前事务的 GTID 加入已读取的集合。
+// 只在提交处判断是否到达终点：在事务中间结束会输出该事务的部分行而不推进水位线，下次运行将重复输出。
+func (s *Source) commit(logPos uint32) error {
+	if s.file != "" && logPos > 0 {
+		s.committed = mysql.Position{Name: s.file, Pos: logPos}
+		if s.committed.Compare(s.end) >= 0 {
+			s.done = true
+		}
+	}
+	if s.gtid != nil && s.gtidSet != nil {
+		sid, err := uuid.FromBytes(s.gtid.SID)
+		if err != nil {
+			return fmt.Errorf("invalid GTID source id: %w", err)
+		}
+		s.gtidSet.AddGTID(sid, s.gtid.GNO)
+	}
+	s.gtid = nil
+	return nil
+}
+
+// rows 将一个行事件中被捕获表的行转换为记录，更新事件的行按变更前、变更后成对出现。
+func (s *Source) rows(header *replication.EventHeader, e *replication.RowsEvent) error {
+	if e.Table == nil {
+		return nil
+	}
+	key := string(e.Table.Schema) + "." + string(e.Table.Table)
+	t, ok := s.tables[key]
+	if !ok {
+		return nil
+	}
+	var op string
+	switch header.EventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2, replication.MARIADB_WRITE_ROWS_COMPRESSED_EVENT_V1:
+		op = opInsert
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2, replication.PARTIAL_UPDATE_ROWS_EVENT, replication.MARIADB_UPDATE_ROWS_COMPRESSED_EVENT_V1:
+		op = opUpdate
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2, replication.MARIADB_DELETE_ROWS_COMPRESSED_EVENT_V1:
+		op = opDelete
+	default:
+		return nil
+	}
+
+	// binlog_row_metadata=FULL 时表映射事件带有列名，表结构变更后仍能正确对应
+	columns := e.Table.ColumnNameString()
+	if len(columns) != int(e.Table.ColumnCount) {
+		if len(t.columns) != int(e.Table.ColumnCount) {
+			return fmt.Errorf("table %s has %d columns in the binlog but %d in information_schema, the table structure has changed; set binlog_row_metadata=FULL or restart from a new position",
+				key, e.Table.ColumnCount, len(t.columns))
+		}
+		columns = t.columns
+	}
+	unsigned := e.Table.UnsignedMap()
+	timestamp := time.Unix(int64(header.Timestamp), 0)
+
+	step := 1
+	if op == opUpdate {
+		step = 2
+	}
+	for i := 0; i+step <= len(e.Rows); i += step {
+		var before, after []any
+		switch op {
+		case opInsert:
+			after = e.Rows[i]
+		case opUpdate:
+			before, after = e.Rows[i], e.Rows[i+1]
+		case opDelete:
+			before = e.Rows[i]
+		}
+		r := record.Record{opColumn: op, tableColumn: key, timestampColumn: timestamp}
+		image := after
+		if image == nil {
+			image = before
+		}
+		for j, column := range columns {
+			isUnsigned := t.unsigned != nil && j < len(t.unsigned) && t.unsigned[j]
+			if unsigned != nil {
+				isUnsigned = unsigned[j]
+			}
+			r[column] = value(image, j, isUnsigned, e.Table.ColumnType[j])
+			if s.includeBefore {
+				r[s.beforePrefix+column] = value(before, j, isUnsigned, e.Table.ColumnType[j])
+			}
+		}
+		s.pending = append(s.pending, r)
+		s.counts[op]++
+	}
+	return nil
+}
+
+// value 取出行镜像中的第 i 列并统一为 int64、float64、string、[]byte、time.Time 等值。
+// binlog 中无符号整数按有符号存储，需要按列定义还原。
+func value(row []any, i int, unsigned bool, columnType byte) any {
+	if i >= len(row) {
+		return nil
+	}
+	switch v := row[i].(type) {
+	case int8:
+		if unsigned {
+			return int64(uint8(v))
+		}
+		return int64(v)
+	case int16:
+		if unsigned {
+			return int64(uint16(v))
+		}
+		return int64(v)
+	case int32:
+		if unsigned {
+			if columnType == mysql.MYSQL_TYPE_INT24 {
+				return int64(uint32(v) & 0xFFFFFF)
+			}
+			return int64(uint32(v))
+		}
+		return int64(v)
+	case int64:
+		if unsigned && v < 0 {
+			return uint64(v)
+		}
+		return v
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// position 返回事件在 binlog 中的位置，用于错误信息。
+func (s *Source) position(event *replication.BinlogEvent) string {
+	if event == nil || event.Header == nil {
+		return s.file
+	}
+	return fmt.Sprintf("%s:%d", s.file, event.Header.LogPos)
+}
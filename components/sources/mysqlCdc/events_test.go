@@ -0,0 +1,241 @@
+package mysqlCdc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/google/uuid"
+)
+
+// replay 依次返回预先构造的事件，事件用完后等待到 ctx 超时，与没有新事件的复制连接一致。
+type replay struct {
+	events []*replication.BinlogEvent
+}
+
+func (r *replay) GetEvent(ctx context.Context) (*replication.BinlogEvent, error) {
+	if len(r.events) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	event := r.events[0]
+	r.events = r.events[1:]
+	return event, nil
+}
+
+var fixtureSID = uuid.MustParse("3e11fa47-71ca-11e1-9e33-c80aa9429562")
+
+// fixtureTable 对应 shop.items(id INT UNSIGNED, name VARCHAR, small TINYINT, mid MEDIUMINT UNSIGNED, big BIGINT UNSIGNED)。
+func fixtureTable() *replication.TableMapEvent {
+	return &replication.TableMapEvent{
+		TableID:     1,
+		Schema:      []byte("shop"),
+		Table:       []byte("items"),
+		ColumnCount: 5,
+		ColumnType: []byte{
+			mysql.MYSQL_TYPE_LONG, mysql.MYSQL_TYPE_VARCHAR, mysql.MYSQL_TYPE_TINY,
+			mysql.MYSQL_TYPE_INT24, mysql.MYSQL_TYPE_LONGLONG,
+		},
+		ColumnName: [][]byte{[]byte("id"), []byte("name"), []byte("small"), []byte("mid"), []byte("big")},
+		// 数值列依次为 id、small、mid、big，只有 small 有符号
+		SignednessBitmap: []byte{0xB0},
+	}
+}
+
+func event(logPos uint32, eventType replication.EventType, e replication.Event) *replication.BinlogEvent {
+	return &replication.BinlogEvent{
+		Header: &replication.EventHeader{Timestamp: 1700000000, EventType: eventType, LogPos: logPos},
+		Event:  e,
+	}
+}
+
+// transaction 构造一个事务：GTID、BEGIN、插入、更新、删除各一行，最后以 XID 提交。
+func transaction(start uint32, gno int64, tableMap *replication.TableMapEvent) []*replication.BinlogEvent {
+	row := func(id int32, name string) []any {
+		return []any{id, name, int8(-1), int32(-1), int64(-1)}
+	}
+	return []*replication.BinlogEvent{
+		event(start, replication.GTID_EVENT, &replication.GTIDEvent{SID: fixtureSID[:], GNO: gno}),
+		event(start+50, replication.QUERY_EVENT, &replication.QueryEvent{Query: []byte("BEGIN")}),
+		event(start+100, replication.WRITE_ROWS_EVENTv2, &replication.RowsEvent{Table: tableMap, Rows: [][]any{row(-1, "new")}}),
+		event(start+150, replication.UPDATE_ROWS_EVENTv2, &replication.RowsEvent{Table: tableMap, Rows: [][]any{row(2, "old"), row(2, "changed")}}),
+		event(start+200, replication.DELETE_ROWS_EVENTv2, &replication.RowsEvent{Table: tableMap, Rows: [][]any{row(3, "gone")}}),
+		event(start+250, replication.XID_EVENT, &replication.XIDEvent{XID: uint64(gno)}),
+	}
+}
+
+func newFixtureSource(mode string, end mysql.Position, events []*replication.BinlogEvent) *Source {
+	s := &Source{
+		tables:        map[string]*table{"shop.items": {schema: "shop", name: "items", columns: []string{"id", "name", "small", "mid", "big"}}},
+		mode:          mode,
+		includeBefore: true,
+		beforePrefix:  "before.",
+		idleTimeout:   50 * time.Millisecond,
+	}
+	s.reset()
+	s.end = end
+	s.events = &replay{events: events}
+	return s
+}
+
+func readAll(t *testing.T, s *Source) []record.Record {
+	t.Helper()
+	var records []record.Record
+	for {
+		r, err := s.Read()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+}
+
+func TestReplayStopsAtCommitAfterEnd(t *testing.T) {
+	tableMap := fixtureTable()
+	events := []*replication.BinlogEvent{
+		event(0, replication.ROTATE_EVENT, &replication.RotateEvent{Position: 4, NextLogName: []byte("mysql-bin.000002")}),
+	}
+	events = append(events, transaction(100, 7, tableMap)...)
+	events = append(events, transaction(400, 8, tableMap)...)
+	// 终点落在第一个事务的更新事件上，读取应在该事务提交后结束，不读取第二个事务
+	s := newFixtureSource(modePosition, mysql.Position{Name: "mysql-bin.000002", Pos: 250}, events)
+
+	records := readAll(t, s)
+	if len(records) != 3 {
+		t.Fatalf("read %d records, want the 3 rows of the first transaction", len(records))
+	}
+	if got, want := s.Watermark(), "mysql-bin.000002:350"; got != want {
+		t.Errorf("Watermark() = %q, want %q", got, want)
+	}
+
+	insert, update, del := records[0], records[1], records[2]
+	for i, want := range []string{opInsert, opUpdate, opDelete} {
+		if records[i][opColumn] != want || records[i][tableColumn] != "shop.items" {
+			t.Errorf("record %d is %v on %v, want %s on shop.items", i, records[i][opColumn], records[i][tableColumn], want)
+		}
+		if records[i][timestampColumn] != time.Unix(1700000000, 0) {
+			t.Errorf("record %d has timestamp %v", i, records[i][timestampColumn])
+		}
+	}
+
+	// 无符号列按列定义还原，有符号列保持负数
+	if insert["id"] != int64(math.MaxUint32) {
+		t.Errorf("unsigned INT id = %#v, want %d", insert["id"], int64(math.MaxUint32))
+	}
+	if insert["small"] != int64(-1) {
+		t.Errorf("signed TINYINT small = %#v, want -1", insert["small"])
+	}
+	if insert["mid"] != int64(0xFFFFFF) {
+		t.Errorf("unsigned MEDIUMINT mid = %#v, want %d", insert["mid"], 0xFFFFFF)
+	}
+	if insert["big"] != uint64(math.MaxUint64) {
+		t.Errorf("unsigned BIGINT big = %#v, want %d", insert["big"], uint64(math.MaxUint64))
+	}
+	if insert["before.id"] != nil || insert["before.name"] != nil {
+		t.Errorf("insert has a before image: %v", insert)
+	}
+
+	if update["name"] != "changed" || update["before.name"] != "old" || update["id"] != int64(2) {
+		t.Errorf("update images = %v / %v, want changed / old", update["name"], update["before.name"])
+	}
+	if del["name"] != "gone" || del["before.name"] != "gone" {
+		t.Errorf("delete images = %v / %v, want the deleted row in both", del["name"], del["before.name"])
+	}
+	if got := s.Progress(); got["mysql_cdc insert"] != 1 || got["mysql_cdc update"] != 1 || got["mysql_cdc delete"] != 1 {
+		t.Errorf("Progress() = %v", got)
+	}
+}
+
+func TestReplayWithoutCommitKeepsWatermark(t *testing.T) {
+	events := []*replication.BinlogEvent{
+		event(0, replication.ROTATE_EVENT, &replication.RotateEvent{Position: 4, NextLogName: []byte("mysql-bin.000003")}),
+	}
+	// 去掉 XID，事务没有提交
+	events = append(events, transaction(100, 1, fixtureTable())[:5]...)
+	s := newFixtureSource(modePosition, mysql.Position{Name: "mysql-bin.000003", Pos: 150}, events)
+	s.committed = mysql.Position{Name: "mysql-bin.000002", Pos: 900}
+
+	readAll(t, s)
+	if got, want := s.Watermark(), "mysql-bin.000002:900"; got != want {
+		t.Errorf("Watermark() = %q, want the start position %q", got, want)
+	}
+}
+
+func TestReplayGTID(t *testing.T) {
+	events := []*replication.BinlogEvent{
+		event(0, replication.ROTATE_EVENT, &replication.RotateEvent{Position: 4, NextLogName: []byte("mysql-bin.000002")}),
+	}
+	events = append(events, transaction(100, 7, fixtureTable())...)
+	// DDL 以 QueryEvent 单独提交
+	events = append(events,
+		event(400, replication.GTID_EVENT, &replication.GTIDEvent{SID: fixtureSID[:], GNO: 8}),
+		event(450, replication.QUERY_EVENT, &replication.QueryEvent{Query: []byte("ALTER TABLE shop.items ADD COLUMN note TEXT")}),
+	)
+	s := newFixtureSource(modeGTID, mysql.Position{Name: "mysql-bin.000002", Pos: 450}, events)
+	var err error
+	if s.gtidSet, err = parseGTIDSet(fixtureSID.String() + ":1-6"); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := readAll(t, s); len(records) != 3 {
+		t.Fatalf("read %d records, want 3", len(records))
+	}
+	if got, want := s.Watermark(), fixtureSID.String()+":1-8"; got != want {
+		t.Errorf("Watermark() = %q, want %q", got, want)
+	}
+}
+
+func TestRowsSkipsUncapturedTables(t *testing.T) {
+	other := fixtureTable()
+	other.Table = []byte("other")
+	s := newFixtureSource(modePosition, mysql.Position{Name: "mysql-bin.000002", Pos: 1000}, nil)
+	header := &replication.EventHeader{EventType: replication.WRITE_ROWS_EVENTv2}
+	if err := s.rows(header, &replication.RowsEvent{Table: other, Rows: [][]any{{int32(1), "x", int8(0), int32(0), int64(0)}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("captured %d rows of an uncaptured table", len(s.pending))
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      any
+		unsigned   bool
+		columnType byte
+		want       any
+	}{
+		{"signed tinyint", int8(-1), false, mysql.MYSQL_TYPE_TINY, int64(-1)},
+		{"unsigned tinyint", int8(-1), true, mysql.MYSQL_TYPE_TINY, int64(math.MaxUint8)},
+		{"unsigned smallint", int16(-1), true, mysql.MYSQL_TYPE_SHORT, int64(math.MaxUint16)},
+		{"unsigned mediumint", int32(-1), true, mysql.MYSQL_TYPE_INT24, int64(0xFFFFFF)},
+		{"unsigned int", int32(-1), true, mysql.MYSQL_TYPE_LONG, int64(math.MaxUint32)},
+		{"signed bigint", int64(-1), false, mysql.MYSQL_TYPE_LONGLONG, int64(-1)},
+		{"unsigned bigint in range", int64(5), true, mysql.MYSQL_TYPE_LONGLONG, int64(5)},
+		{"unsigned bigint overflow", int64(-1), true, mysql.MYSQL_TYPE_LONGLONG, uint64(math.MaxUint64)},
+		{"uint64 in range", uint64(5), true, mysql.MYSQL_TYPE_LONGLONG, int64(5)},
+		{"float", float32(1.5), false, mysql.MYSQL_TYPE_FLOAT, float64(1.5)},
+		{"string", "x", false, mysql.MYSQL_TYPE_VARCHAR, "x"},
+		{"null", nil, false, mysql.MYSQL_TYPE_VARCHAR, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := value([]any{tt.value}, 0, tt.unsigned, tt.columnType); got != tt.want {
+				t.Errorf("value(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+	if got := value(nil, 0, false, mysql.MYSQL_TYPE_LONG); got != nil {
+		t.Errorf("value of a missing column = %#v, want nil", got)
+	}
+}
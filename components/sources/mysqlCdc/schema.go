@@ -0,0 +1,169 @@
+package mysqlCdc
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// table 是被捕获的表结构，列按 ORDINAL_POSITION 排列，与 binlog 行镜像中的列顺序一致。
+type table struct {
+	schema   string
+	name     string
+	columns  []string
+	unsigned []bool
+	kinds    []string
+}
+
+// tableFilter 是 tables 参数中的一项，name 为 * 时匹配库中所有表。
+type tableFilter struct {
+	schema string
+	name   string
+}
+
+// parseTables 解析逗号分隔的 db.table、table 或 db.* 形式的表名，未指定库名时使用数据源的库。
+func parseTables(spec string, database string) ([]tableFilter, error) {
+	var filters []tableFilter
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		schema, name, ok := strings.Cut(item, ".")
+		if !ok {
+			schema, name = database, item
+		}
+		schema, name = strings.Trim(strings.TrimSpace(schema), "`"), strings.Trim(strings.TrimSpace(name), "`")
+		if schema == "" || name == "" {
+			return nil, fmt.Errorf("invalid table %q, expected db.table, table or db.*", item)
+		}
+		filters = append(filters, tableFilter{schema: schema, name: name})
+	}
+	if len(filters) == 0 {
+		if database == "" {
+			return nil, fmt.Errorf("'tables' is required when the datasource has no database")
+		}
+		filters = append(filters, tableFilter{schema: database, name: "*"})
+	}
+	return filters, nil
+}
+
+// loadTables 从 information_schema 读取被捕获表的列定义，键为 db.table。
+func loadTables(db *sql.DB, filters []tableFilter) (map[string]*table, error) {
+	var conditions []string
+	var args []any
+	for _, f := range filters {
+		if f.name == "*" {
+			conditions = append(conditions, "(TABLE_SCHEMA = ?)")
+			args = append(args, f.schema)
+		} else {
+			conditions = append(conditions, "(TABLE_SCHEMA = ? AND TABLE_NAME = ?)")
+			args = append(args, f.schema, f.name)
+		}
+	}
+	rows, err := db.Query("SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, DATA_TYPE, COLUMN_TYPE FROM information_schema.COLUMNS WHERE "+
+		strings.Join(conditions, " OR ")+" ORDER BY TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table columns: %w", err)
+	}
+	defer rows.Close()
+	tables := make(map[string]*table)
+	for rows.Next() {
+		var schema, name, column, dataType, columnType string
+		if err := rows.Scan(&schema, &name, &column, &dataType, &columnType); err != nil {
+			return nil, fmt.Errorf("failed to scan table columns: %w", err)
+		}
+		key := schema + "." + name
+		t, ok := tables[key]
+		if !ok {
+			t = &table{schema: schema, name: name}
+			tables[key] = t
+		}
+		t.columns = append(t.columns, column)
+		t.unsigned = append(t.unsigned, strings.Contains(strings.ToLower(columnType), "unsigned"))
+		t.kinds = append(t.kinds, columnKind(dataType))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read table columns: %w", err)
+	}
+	for _, f := range filters {
+		if f.name != "*" && tables[f.schema+"."+f.name] == nil {
+			return nil, fmt.Errorf("table %s.%s does not exist", f.schema, f.name)
+		}
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables to capture")
+	}
+	return tables, nil
+}
+
+// columnKind 将 information_schema 的 DATA_TYPE 映射为 record 包中的通用类型。
+func columnKind(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return record.TypeInteger
+	case "float", "double", "real":
+		return record.TypeFloat
+	case "decimal", "numeric":
+		return record.TypeDecimal
+	case "date":
+		return record.TypeDate
+	case "datetime", "timestamp":
+		return record.TypeDatetime
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit", "geometry":
+		return record.TypeBytes
+	default:
+		return record.TypeString
+	}
+}
+
+// masterStatus 返回当前 binlog 的写入位置与已执行的 GTID 集合，即本次运行读取的终点。
+// MySQL 8.4 起 SHOW MASTER STATUS 更名为 SHOW BINARY LOG STATUS。
+func masterStatus(db *sql.DB) (mysql.Position, string, error) {
+	rows, err := db.Query("SHOW BINARY LOG STATUS")
+	if err != nil {
+		rows, err = db.Query("SHOW MASTER STATUS")
+	}
+	if err != nil {
+		return mysql.Position{}, "", fmt.Errorf("failed to query binlog status: %w", err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return mysql.Position{}, "", fmt.Errorf("failed to query binlog status: %w", err)
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return mysql.Position{}, "", fmt.Errorf("failed to query binlog status: %w", err)
+		}
+		return mysql.Position{}, "", fmt.Errorf("binary logging is not enabled on the server")
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return mysql.Position{}, "", fmt.Errorf("failed to scan binlog status: %w", err)
+	}
+	var position mysql.Position
+	var gtid string
+	for i, column := range columns {
+		switch strings.ToLower(column) {
+		case "file":
+			position.Name = values[i].String
+		case "position":
+			var pos uint32
+			if _, err := fmt.Sscan(values[i].String, &pos); err != nil {
+				return mysql.Position{}, "", fmt.Errorf("invalid binlog position %q", values[i].String)
+			}
+			position.Pos = pos
+		case "executed_gtid_set":
+			gtid = strings.ReplaceAll(values[i].String, "\n", "")
+		}
+	}
+	return position, gtid, nil
+}
@@ -0,0 +1,388 @@
+package mysqlCdc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+)
+
+var name = "mysql_cdc"
+var datasourceName = "mysql"
+
+func SetCustomName(customName string, customDatasourceName string) {
+	name = customName
+	datasourceName = customDatasourceName
+}
+
+const (
+	modePosition = "position"
+	modeGTID     = "gtid"
+)
+
+// 输出记录中的元数据列。
+const (
+	opColumn        = "_op"
+	tableColumn     = "_table"
+	timestampColumn = "_timestamp"
+)
+
+// eventStream 是 binlog 事件流，由复制连接的 BinlogStreamer 提供，也可以由回放的事件代替。
+type eventStream interface {
+	GetEvent(ctx context.Context) (*replication.BinlogEvent, error)
+}
+
+// Source 实现了 core.Source 接口，以复制协议读取 MySQL binlog 中的行事件（变更数据捕获）。
+//
+// 每次运行从上一次成功运行提交的位置开始，读到打开时服务端的 binlog 写入位置为止，
+// 每个行变更输出一条记录：_op 为 insert、update 或 delete，_table 为 库.表，_timestamp 为事件时间，
+// 表的列为变更后的行（删除时为被删除的行），include_before 时以 before_prefix 为前缀的列为变更前的行。
+// 读取位置按 file:pos 或 GTID 集合作为水位线由任务持久化，只在事务提交处推进。
+// 要求服务端 binlog_format=ROW 且 binlog_row_image=FULL。
+type Source struct {
+	syncer        *replication.BinlogSyncer
+	events        eventStream
+	tables        map[string]*table // 键为 db.table
+	columns       []string
+	kinds         map[string]string
+	mode          string
+	includeBefore bool
+	beforePrefix  string
+	idleTimeout   time.Duration
+
+	watermark string         // 已提交的水位线，由引擎在 Open 之前注入
+	file      string         // 当前 binlog 文件
+	committed mysql.Position // 最后一个已提交事务的结束位置
+	gtidSet   *mysql.MysqlGTIDSet
+	gtid      *replication.GTIDEvent // 当前事务的 GTID，提交时加入 gtidSet
+	end       mysql.Position         // 本次运行读取的终点
+	done      bool
+	pending   []record.Record // 当前行事件中尚未输出的记录
+	counts    map[string]int64
+}
+
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "tables",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "comma separated tables to capture as db.table, table or db.*, empty captures all tables of the datasource database",
+		},
+		{
+			Key:          "mode",
+			DefaultValue: modePosition,
+			Required:     false,
+			Description:  "position tracks file:pos, gtid tracks the executed GTID set (requires gtid_mode=ON)",
+		},
+		{
+			Key:          "start_position",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "position used when no run has succeeded yet, mysql-bin.000001:4 in position mode or a GTID set in gtid mode, empty starts from the current position",
+		},
+		{
+			Key:          "server_id",
+			DefaultValue: "1001",
+			Required:     false,
+			Description:  "replica server id, must be unique among the replicas of the server",
+		},
+		{
+			Key:          "include_before",
+			DefaultValue: "true",
+			Required:     false,
+			Description:  "add the before image of updated and deleted rows as prefixed columns",
+		},
+		{
+			Key:          "before_prefix",
+			DefaultValue: "before.",
+			Required:     false,
+			Description:  "column prefix of the before image",
+		},
+		{
+			Key:          "idle_timeout",
+			DefaultValue: "10s",
+			Required:     false,
+			Description:  "stop reading when no event arrives within this duration before reaching the end position",
+		},
+	}
+	return name, &Source{}, &datasourceName, paramList
+}
+
+// SetWatermark 注入上一次成功运行提交的读取位置。
+func (s *Source) SetWatermark(watermark string) {
+	s.watermark = watermark
+}
+
+// Watermark 返回本次运行最后一个已提交事务之后的读取位置。
+func (s *Source) Watermark() string {
+	if s.mode == modeGTID {
+		if s.gtidSet == nil {
+			return ""
+		}
+		return s.gtidSet.String()
+	}
+	if s.committed.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", s.committed.Name, s.committed.Pos)
+}
+
+// Open 读取表结构与当前 binlog 位置，并从起始位置建立复制连接。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	if dataSource == nil {
+		return fmt.Errorf("mysql_cdc source: a mysql datasource is required")
+	}
+	connection, ok := (*dataSource).(interface{ Config() map[string]string })
+	if !ok {
+		return fmt.Errorf("mysql_cdc source: datasource does not expose its connection config")
+	}
+	db, ok := (*dataSource).Open().(*sql.DB)
+	if !ok {
+		return fmt.Errorf("mysql_cdc source: datasource is not a mysql datasource")
+	}
+	dsConfig := connection.Config()
+
+	s.mode = strings.ToLower(strings.TrimSpace(config["mode"]))
+	if s.mode == "" {
+		s.mode = modePosition
+	}
+	if s.mode != modePosition && s.mode != modeGTID {
+		return fmt.Errorf("mysql_cdc source: unsupported 'mode' %q, expected position or gtid", config["mode"])
+	}
+	s.includeBefore = !strings.EqualFold(strings.TrimSpace(config["include_before"]), "false")
+	s.beforePrefix = config["before_prefix"]
+	if s.beforePrefix == "" {
+		s.beforePrefix = "before."
+	}
+	s.idleTimeout = 10 * time.Second
+	if v := strings.TrimSpace(config["idle_timeout"]); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("mysql_cdc source: invalid 'idle_timeout' %q", v)
+		}
+		s.idleTimeout = timeout
+	}
+	serverID, err := strconv.ParseUint(strings.TrimSpace(config["server_id"]), 10, 32)
+	if err != nil || serverID == 0 {
+		return fmt.Errorf("mysql_cdc source: invalid 'server_id' %q", config["server_id"])
+	}
+	port, err := strconv.ParseUint(strings.TrimSpace(dsConfig["port"]), 10, 16)
+	if err != nil {
+		return fmt.Errorf("mysql_cdc source: invalid datasource port %q", dsConfig["port"])
+	}
+
+	filters, err := parseTables(config["tables"], dsConfig["database"])
+	if err != nil {
+		return fmt.Errorf("mysql_cdc source: %w", err)
+	}
+	if s.tables, err = loadTables(db, filters); err != nil {
+		return fmt.Errorf("mysql_cdc source: %w", err)
+	}
+	if err := s.buildColumns(); err != nil {
+		return fmt.Errorf("mysql_cdc source: %w", err)
+	}
+
+	endPosition, endGTID, err := masterStatus(db)
+	if err != nil {
+		return fmt.Errorf("mysql_cdc source: %w", err)
+	}
+	start := s.watermark
+	if start == "" {
+		start = strings.TrimSpace(config["start_position"])
+	}
+
+	s.reset()
+	s.end = endPosition
+	if s.mode == modeGTID {
+		if start == "" {
+			start = endGTID
+		}
+		if s.gtidSet, err = parseGTIDSet(start); err != nil {
+			return fmt.Errorf("mysql_cdc source: invalid start GTID set %q: %w", start, err)
+		}
+		executed, err := parseGTIDSet(endGTID)
+		if err != nil {
+			return fmt.Errorf("mysql_cdc source: invalid executed GTID set %q: %w", endGTID, err)
+		}
+		s.done = s.gtidSet.Contain(executed)
+	} else {
+		s.committed = endPosition
+		if start != "" {
+			if s.committed, err = parsePosition(start); err != nil {
+				return fmt.Errorf("mysql_cdc source: %w", err)
+			}
+		}
+		s.done = s.committed.Compare(s.end) >= 0
+	}
+	if s.done {
+		return nil
+	}
+
+	s.syncer = replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		ServerID:  uint32(serverID),
+		Flavor:    mysql.MySQLFlavor,
+		Host:      dsConfig["host"],
+		Port:      uint16(port),
+		User:      dsConfig["user"],
+		Password:  dsConfig["password"],
+		ParseTime: true,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	var streamer *replication.BinlogStreamer
+	if s.mode == modeGTID {
+		streamer, err = s.syncer.StartSyncGTID(s.gtidSet.Clone())
+	} else {
+		streamer, err = s.syncer.StartSync(s.committed)
+	}
+	if err != nil {
+		s.syncer.Close()
+		s.syncer = nil
+		return fmt.Errorf("mysql_cdc source: failed to start binlog replication: %w", err)
+	}
+	s.events = streamer
+	return nil
+}
+
+// reset 清除上一次运行的读取状态，Source 实例在任务间共享。
+func (s *Source) reset() {
+	s.file = ""
+	s.committed = mysql.Position{}
+	s.gtidSet = nil
+	s.gtid = nil
+	s.done = false
+	s.pending = nil
+	s.events = nil
+	s.counts = make(map[string]int64)
+}
+
+// buildColumns 按表名顺序汇总所有被捕获表的列，同名列类型不一致时按字符串处理。
+func (s *Source) buildColumns() error {
+	s.columns = []string{opColumn, tableColumn, timestampColumn}
+	s.kinds = map[string]string{opColumn: record.TypeString, tableColumn: record.TypeString, timestampColumn: record.TypeDatetime}
+	var before []string
+	keys := make([]string, 0, len(s.tables))
+	for key := range s.tables {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		t := s.tables[key]
+		for i, column := range t.columns {
+			if column == opColumn || column == tableColumn || column == timestampColumn ||
+				(s.includeBefore && strings.HasPrefix(column, s.beforePrefix)) {
+				return fmt.Errorf("column '%s' of table %s conflicts with the change metadata columns", column, key)
+			}
+			kind, seen := s.kinds[column]
+			if !seen {
+				s.columns = append(s.columns, column)
+				s.kinds[column] = t.kinds[i]
+				if s.includeBefore {
+					before = append(before, column)
+				}
+			} else if kind != t.kinds[i] {
+				s.kinds[column] = record.TypeString
+			}
+		}
+	}
+	for _, column := range before {
+		s.columns = append(s.columns, s.beforePrefix+column)
+		s.kinds[s.beforePrefix+column] = s.kinds[column]
+	}
+	return nil
+}
+
+// Read 返回下一条变更记录，读到终点或在 idle_timeout 内没有新事件时返回 io.EOF。
+func (s *Source) Read() (record.Record, error) {
+	for {
+		if len(s.pending) > 0 {
+			r := s.pending[0]
+			s.pending = s.pending[1:]
+			return r, nil
+		}
+		if s.done || s.events == nil {
+			return nil, io.EOF
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), s.idleTimeout)
+		event, err := s.events.GetEvent(ctx)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			s.done = true
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mysql_cdc source: failed to read binlog event: %w", err)
+		}
+		if err := s.handle(event); err != nil {
+			return nil, fmt.Errorf("mysql_cdc source: %s: %w", s.position(event), err)
+		}
+	}
+}
+
+// Close 关闭复制连接。
+func (s *Source) Close() error {
+	if s.syncer != nil {
+		s.syncer.Close()
+		s.syncer = nil
+	}
+	s.events = nil
+	return nil
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, v := range s.columns {
+		columns[v] = v
+	}
+	return columns
+}
+
+// ColumnTypes 返回元数据列与被捕获表的列的通用类型。
+func (s *Source) ColumnTypes() map[string]string {
+	types := make(map[string]string, len(s.kinds))
+	for k, v := range s.kinds {
+		types[k] = v
+	}
+	return types
+}
+
+// Progress 返回本次运行各类变更的行数。
+func (s *Source) Progress() map[string]int64 {
+	progress := make(map[string]int64, len(s.counts))
+	for op, count := range s.counts {
+		progress["mysql_cdc "+op] = count
+	}
+	return progress
+}
+
+// parsePosition 解析 file:pos 形式的 binlog 位置。
+func parsePosition(position string) (mysql.Position, error) {
+	file, pos, ok := strings.Cut(strings.TrimSpace(position), ":")
+	offset, err := strconv.ParseUint(strings.TrimSpace(pos), 10, 32)
+	if !ok || file == "" || err != nil {
+		return mysql.Position{}, fmt.Errorf("invalid binlog position %q, expected file:pos such as mysql-bin.000001:4", position)
+	}
+	return mysql.Position{Name: strings.TrimSpace(file), Pos: uint32(offset)}, nil
+}
+
+func parseGTIDSet(set string) (*mysql.MysqlGTIDSet, error) {
+	parsed, err := mysql.ParseMysqlGTIDSet(strings.ReplaceAll(set, "\n", ""))
+	if err != nil {
+		return nil, err
+	}
+	return parsed.(*mysql.MysqlGTIDSet), nil
+}
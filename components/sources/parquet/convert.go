@@ -0,0 +1,258 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// convertValue 将按物理类型解码的值转换为列逻辑类型对应的 Go 类型，嵌套列递归转换。
+func convertValue(node pq.Node, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if !node.Leaf() {
+		return convertGroup(node, value)
+	}
+	logical := node.Type().LogicalType()
+	switch {
+	case logical == nil:
+		return convertPhysical(node, value), nil
+	case logical.UTF8 != nil, logical.Enum != nil, logical.Json != nil:
+		return toString(value), nil
+	case logical.Decimal != nil:
+		return convertDecimal(value, int(logical.Decimal.Scale))
+	case logical.Date != nil:
+		days, ok := toInt64(value)
+		if !ok {
+			return nil, fmt.Errorf("unexpected date value %T", value)
+		}
+		return time.Unix(days*86400, 0).UTC(), nil
+	case logical.Timestamp != nil:
+		n, ok := toInt64(value)
+		if !ok {
+			return nil, fmt.Errorf("unexpected timestamp value %T", value)
+		}
+		t := fromUnit(n, &logical.Timestamp.Unit)
+		if !logical.Timestamp.IsAdjustedToUTC {
+			// 本地时间语义的时间戳不带时区，按原样解释为墙上时间
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+		}
+		return t, nil
+	case logical.Time != nil:
+		n, ok := toInt64(value)
+		if !ok {
+			return nil, fmt.Errorf("unexpected time value %T", value)
+		}
+		return fromUnit(n, &logical.Time.Unit).Format("15:04:05.999999999"), nil
+	case logical.Integer != nil:
+		n, ok := toInt64(value)
+		if !ok {
+			return value, nil
+		}
+		if !logical.Integer.IsSigned {
+			// 无符号整数以有符号的物理类型存储，需要按位宽还原
+			switch logical.Integer.BitWidth {
+			case 64:
+				return uint64(n), nil
+			case 32:
+				return int64(uint32(n)), nil
+			}
+		}
+		return n, nil
+	case logical.UUID != nil:
+		b := toBytes(value)
+		if len(b) != 16 {
+			return toString(value), nil
+		}
+		h := hex.EncodeToString(b)
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+	default:
+		return convertPhysical(node, value), nil
+	}
+}
+
+// convertPhysical 转换没有逻辑类型的叶子列。
+func convertPhysical(node pq.Node, value any) any {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case deprecated.Int96:
+		// Hive/Spark 写出的 INT96 时间戳：低 8 字节为当天的纳秒数，高 4 字节为儒略日
+		nanos := int64(uint64(v[1])<<32 | uint64(v[0]))
+		days := int64(v[2]) - 2440588
+		return time.Unix(days*86400, nanos).UTC()
+	case string:
+		if node.Type().Kind() == pq.ByteArray || node.Type().Kind() == pq.FixedLenByteArray {
+			return []byte(v)
+		}
+		return v
+	case []byte:
+		return bytes.Clone(v)
+	default:
+		return v
+	}
+}
+
+// convertGroup 转换结构、列表与映射类型的列。
+func convertGroup(node pq.Node, value any) (any, error) {
+	logical := node.Type().LogicalType()
+	fields := node.Fields()
+	switch {
+	case logical != nil && logical.List != nil && len(fields) == 1 && len(fields[0].Fields()) == 1:
+		items, ok := value.([]any)
+		if !ok {
+			return value, nil
+		}
+		element := fields[0].Fields()[0]
+		for i, item := range items {
+			converted, err := convertValue(element, item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	case logical != nil && logical.Map != nil && len(fields) == 1 && len(fields[0].Fields()) == 2:
+		entries, ok := value.(map[string]any)
+		if !ok {
+			return value, nil
+		}
+		valueNode := fields[0].Fields()[1]
+		for key, entry := range entries {
+			converted, err := convertValue(valueNode, entry)
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = converted
+		}
+		return entries, nil
+	default:
+		members, ok := value.(map[string]any)
+		if !ok {
+			return value, nil
+		}
+		for _, field := range fields {
+			converted, err := convertValue(field, members[field.Name()])
+			if err != nil {
+				return nil, err
+			}
+			members[field.Name()] = converted
+		}
+		return members, nil
+	}
+}
+
+// convertDecimal 将未缩放的整数或大端补码字节转换为带小数位的字符串。
+func convertDecimal(value any, scale int) (string, error) {
+	unscaled := new(big.Int)
+	switch v := value.(type) {
+	case int32:
+		unscaled.SetInt64(int64(v))
+	case int64:
+		unscaled.SetInt64(v)
+	case string, []byte:
+		b := toBytes(v)
+		unscaled.SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			// 负数按补码还原
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+	default:
+		return "", fmt.Errorf("unexpected decimal value %T", value)
+	}
+	if scale <= 0 {
+		return unscaled.String(), nil
+	}
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)).FloatString(scale), nil
+}
+
+func fromUnit(n int64, unit *format.TimeUnit) time.Time {
+	switch {
+	case unit.Millis != nil:
+		return time.UnixMilli(n).UTC()
+	case unit.Micros != nil:
+		return time.UnixMicro(n).UTC()
+	default:
+		return time.Unix(0, n).UTC()
+	}
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func toBytes(value any) []byte {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	default:
+		return []byte(fmt.Sprintf("%v", v))
+	}
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// columnKind 将列的逻辑类型与物理类型归类为通用类型名。
+func columnKind(node pq.Node) string {
+	if !node.Leaf() {
+		return record.TypeString
+	}
+	if logical := node.Type().LogicalType(); logical != nil {
+		switch {
+		case logical.Decimal != nil:
+			return fmt.Sprintf("%s(%d,%d)", record.TypeDecimal, logical.Decimal.Precision, logical.Decimal.Scale)
+		case logical.Date != nil:
+			return record.TypeDate
+		case logical.Timestamp != nil:
+			return record.TypeDatetime
+		case logical.Integer != nil:
+			return record.TypeInteger
+		case logical.UTF8 != nil, logical.Enum != nil, logical.Json != nil, logical.UUID != nil, logical.Time != nil:
+			return record.TypeString
+		}
+	}
+	switch node.Type().Kind() {
+	case pq.Boolean:
+		return record.TypeBoolean
+	case pq.Int32, pq.Int64:
+		return record.TypeInteger
+	case pq.Float, pq.Double:
+		return record.TypeFloat
+	case pq.Int96:
+		return record.TypeDatetime
+	case pq.ByteArray, pq.FixedLenByteArray:
+		return record.TypeBytes
+	default:
+		return record.TypeString
+	}
+}
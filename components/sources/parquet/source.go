@@ -0,0 +1,169 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+	pq "github.com/parquet-go/parquet-go"
+)
+
+var name = "parquet"
+
+// Source 实现了 core.Source 接口，用于从 Parquet 文件读取数据。
+//
+// 文件按行组(row group)顺序流式读取，每次从当前行组读取一批行，内存占用与文件大小无关。
+// 叶子列按逻辑类型转换为 int64、float64、bool、time.Time、[]byte、string 等值，
+// 定点数输出为字符串以免丢失精度，嵌套的结构、列表与映射分别输出为 map、slice 与 map。
+type Source struct {
+	filePath  string
+	file      *os.File
+	parquet   *pq.File
+	rowGroups []pq.RowGroup
+	group     int     // 当前行组的序号
+	rows      pq.Rows // 当前行组的行迭代器
+	buffer    []pq.Row
+	buffered  int // buffer 中已读取的行数
+	next      int // buffer 中下一条待输出的行
+	columns   []string
+}
+
+const readBatchSize = 1000
+
+// SourceCreator 实现了源组件的创建接口，返回组件名称、实例和参数定义
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "file_id",
+			DefaultValue: "",
+			Required:     true,
+			Description:  "The file_id to the Parquet file",
+		},
+	}
+	return name, &Source{}, nil, paramList
+}
+
+// Open 打开文件并读取文件尾部的元数据与 schema。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	filePath, ok := config["file_path"]
+	if !ok {
+		return fmt.Errorf("parquet source: config is missing required key 'file_path'")
+	}
+	s.filePath = filePath
+
+	var err error
+	s.file, err = os.Open(s.filePath)
+	if err != nil {
+		return fmt.Errorf("parquet source: failed to open file %s: %w", s.filePath, err)
+	}
+	stat, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("parquet source: failed to stat file %s: %w", s.filePath, err)
+	}
+	s.parquet, err = pq.OpenFile(s.file, stat.Size())
+	if err != nil {
+		return fmt.Errorf("parquet source: failed to read parquet metadata of %s: %w", s.filePath, err)
+	}
+	s.rowGroups = s.parquet.RowGroups()
+	s.group = 0
+	s.rows = nil
+	s.buffer = make([]pq.Row, readBatchSize)
+	s.buffered, s.next = 0, 0
+	s.columns = s.columns[:0]
+	for _, field := range s.parquet.Schema().Fields() {
+		s.columns = append(s.columns, field.Name())
+	}
+	return nil
+}
+
+// Read 返回下一行数据，当前行组读完后自动切换到下一个行组，所有行组读完时返回 io.EOF。
+func (s *Source) Read() (record.Record, error) {
+	for s.next >= s.buffered {
+		if err := s.fill(); err != nil {
+			return nil, err
+		}
+	}
+	row := s.buffer[s.next]
+	s.next++
+
+	values := make(map[string]any, len(s.columns))
+	schema := s.parquet.Schema()
+	if err := schema.Reconstruct(&values, row); err != nil {
+		return nil, fmt.Errorf("parquet source: failed to decode row in row group %d: %w", s.group, err)
+	}
+	r := make(record.Record, len(values))
+	for _, field := range schema.Fields() {
+		value, err := convertValue(field, values[field.Name()])
+		if err != nil {
+			return nil, fmt.Errorf("parquet source: column '%s': %w", field.Name(), err)
+		}
+		r[field.Name()] = value
+	}
+	return r, nil
+}
+
+// fill 从当前行组读取下一批行，当前行组读完时打开下一个行组。
+func (s *Source) fill() error {
+	if s.rows == nil {
+		if s.group >= len(s.rowGroups) {
+			return io.EOF
+		}
+		s.rows = s.rowGroups[s.group].Rows()
+		s.group++
+	}
+	n, err := s.rows.ReadRows(s.buffer)
+	s.buffered, s.next = n, 0
+	if err == io.EOF {
+		closeErr := s.rows.Close()
+		s.rows = nil
+		if closeErr != nil {
+			return fmt.Errorf("parquet source: failed to close row group %d: %w", s.group, closeErr)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("parquet source: failed to read row group %d: %w", s.group, err)
+	}
+	return nil
+}
+
+// Close 关闭行迭代器与文件句柄，释放资源。
+func (s *Source) Close() error {
+	if s.rows != nil {
+		_ = s.rows.Close()
+		s.rows = nil
+	}
+	s.parquet = nil
+	s.rowGroups = nil
+	if s.file != nil {
+		err := s.file.Close()
+		s.file = nil
+		return err
+	}
+	return nil
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, v := range s.columns {
+		columns[v] = v
+	}
+	return columns
+}
+
+// ColumnTypes 返回顶层列的通用类型，嵌套列按字符串处理。
+func (s *Source) ColumnTypes() map[string]string {
+	types := make(map[string]string)
+	if s.parquet == nil {
+		return types
+	}
+	for _, field := range s.parquet.Schema().Fields() {
+		types[field.Name()] = columnKind(field)
+	}
+	return types
+}
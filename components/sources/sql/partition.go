@@ -0,0 +1,271 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+var partitionParams = []params.Params{
+	{
+		Key:          "partition_column",
+		DefaultValue: "",
+		Required:     false,
+		Description:  "numeric or date column of the query result, splits the query into ranges read in parallel when set",
+	},
+	{
+		Key:          "partition_count",
+		DefaultValue: "4",
+		Required:     false,
+		Description:  "number of ranges read concurrently on separate connections",
+	},
+	{
+		Key:          "partition_lower",
+		DefaultValue: "",
+		Required:     false,
+		Description:  "lower bound of the partition column, queried with MIN() when empty",
+	},
+	{
+		Key:          "partition_upper",
+		DefaultValue: "",
+		Required:     false,
+		Description:  "upper bound of the partition column, queried with MAX() when empty",
+	},
+}
+
+// partition 是分段读取中的一个范围，每个范围独占一个数据库连接。
+type partition struct {
+	name string
+	rows *sql.Rows
+	read atomic.Int64
+}
+
+// partitionedReader 并发读取所有分段，并将结果合并到一个通道中供 Read 消费。
+type partitionedReader struct {
+	partitions []*partition
+	records    chan record.Record
+	err        error
+	errOnce    sync.Once
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// openPartitions 将查询按 partition_column 切分为多个范围，并在各自的连接上执行。
+// 第一个范围同时包含该列为 NULL 的行，首尾两个范围不设外侧边界，以免遗漏超出边界的数据。
+func (s *Source) openPartitions(query string, args []any, column string, config map[string]string) error {
+	count := 4
+	if v, ok := config["partition_count"]; ok && v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("sql source: invalid 'partition_count' %q", v)
+		}
+		count = parsed
+	}
+	lower, upper := config["partition_lower"], config["partition_upper"]
+	if lower == "" || upper == "" {
+		var minValue, maxValue sql.NullString
+		boundQuery := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM (%s) etl_partition", quoteIdentifier(column, s.dialect), quoteIdentifier(column, s.dialect), query)
+		if err := s.db.QueryRow(boundQuery, args...).Scan(&minValue, &maxValue); err != nil {
+			return fmt.Errorf("sql source: failed to query partition bounds: %w", err)
+		}
+		if lower == "" {
+			lower = minValue.String
+		}
+		if upper == "" {
+			upper = maxValue.String
+		}
+	}
+	var bounds []any
+	if lower != "" && upper != "" && count > 1 {
+		var err error
+		bounds, err = splitRange(lower, upper, count)
+		if err != nil {
+			return fmt.Errorf("sql source: failed to split partition column '%s': %w", column, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := &partitionedReader{
+		records: make(chan record.Record, 1000),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	quoted := quoteIdentifier(column, s.dialect)
+	for i := 0; i <= len(bounds); i++ {
+		partQuery := query
+		partArgs := append([]any{}, args...)
+		var condition string
+		switch {
+		case len(bounds) == 0:
+			condition = ""
+		case i == 0:
+			partArgs = append(partArgs, bounds[0])
+			condition = fmt.Sprintf("(%s < %s OR %s IS NULL)", quoted, placeholder(s.dialect, len(partArgs)), quoted)
+		case i == len(bounds):
+			partArgs = append(partArgs, bounds[i-1])
+			condition = fmt.Sprintf("%s >= %s", quoted, placeholder(s.dialect, len(partArgs)))
+		default:
+			partArgs = append(partArgs, bounds[i-1], bounds[i])
+			condition = fmt.Sprintf("%s >= %s AND %s < %s", quoted, placeholder(s.dialect, len(partArgs)-1), quoted, placeholder(s.dialect, len(partArgs)))
+		}
+		name := fmt.Sprintf("partition #%d", i+1)
+		if condition != "" {
+			partQuery = fmt.Sprintf("SELECT * FROM (%s) etl_partition WHERE %s", query, condition)
+			name = fmt.Sprintf("partition #%d (%s)", i+1, describeRange(column, bounds, i))
+		}
+		rows, err := s.db.QueryContext(ctx, partQuery, partArgs...)
+		if err != nil {
+			reader.close()
+			return fmt.Errorf("sql source: failed to executor query of %s: %w", name, err)
+		}
+		reader.partitions = append(reader.partitions, &partition{name: name, rows: rows})
+	}
+
+	var err error
+	s.scanner, err = newRowScanner(reader.partitions[0].rows, config["value_mode"])
+	if err != nil {
+		reader.close()
+		return err
+	}
+	s.columnNames = s.scanner.columnNames
+	s.partitioned = reader
+	reader.start(s.scanner)
+	return nil
+}
+
+func (r *partitionedReader) start(scanner *rowScanner) {
+	r.wg.Add(len(r.partitions))
+	for _, p := range r.partitions {
+		go r.run(p, scanner)
+	}
+	go func() {
+		r.wg.Wait()
+		close(r.records)
+	}()
+}
+
+func (r *partitionedReader) run(p *partition, scanner *rowScanner) {
+	defer r.wg.Done()
+	for p.rows.Next() {
+		rec, err := scanner.scan(p.rows)
+		if err != nil {
+			r.fail(fmt.Errorf("sql source: %s: %w", p.name, err))
+			return
+		}
+		select {
+		case r.records <- rec:
+			p.read.Add(1)
+		case <-r.ctx.Done():
+			return
+		}
+	}
+	if err := p.rows.Err(); err != nil {
+		r.fail(fmt.Errorf("sql source: error during row iteration of %s: %w", p.name, err))
+	}
+}
+
+// fail 记录第一个发生的错误并取消其余分段的读取。
+func (r *partitionedReader) fail(err error) {
+	r.errOnce.Do(func() {
+		r.err = err
+		r.cancel()
+	})
+}
+
+func (r *partitionedReader) read() (record.Record, error) {
+	rec, ok := <-r.records
+	if !ok {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, io.EOF
+	}
+	return rec, nil
+}
+
+// close 取消所有分段的读取并释放连接。
+func (r *partitionedReader) close() error {
+	r.cancel()
+	r.wg.Wait()
+	var errs []error
+	for _, p := range r.partitions {
+		if err := p.rows.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("sql source: failed to close rows of %s: %w", p.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *partitionedReader) progress() map[string]int64 {
+	progress := make(map[string]int64, len(r.partitions))
+	for _, p := range r.partitions {
+		progress[p.name] = p.read.Load()
+	}
+	return progress
+}
+
+func placeholder(dialect string, n int) string {
+	if dialect == dialectPostgre {
+		return params.DollarPlaceholder(n)
+	}
+	return params.QuestionPlaceholder(n)
+}
+
+func describeRange(column string, bounds []any, i int) string {
+	switch i {
+	case 0:
+		return fmt.Sprintf("%s < %v", column, bounds[0])
+	case len(bounds):
+		return fmt.Sprintf("%s >= %v", column, bounds[i-1])
+	default:
+		return fmt.Sprintf("%s in [%v, %v)", column, bounds[i-1], bounds[i])
+	}
+}
+
+// splitRange 将 [lower, upper] 均分为 count 段，返回 count-1 个内部切分点。
+// 两端都能解析为整数、浮点数或时间时分别按对应类型切分，切分点以对应的 Go 类型绑定为查询参数。
+func splitRange(lower string, upper string, count int) ([]any, error) {
+	var bounds []any
+	if lo, err := strconv.ParseInt(lower, 10, 64); err == nil {
+		if hi, err := strconv.ParseInt(upper, 10, 64); err == nil {
+			step := (hi - lo) / int64(count)
+			if step == 0 {
+				step = 1
+			}
+			for i := 1; i < count && lo+step*int64(i) <= hi; i++ {
+				bounds = append(bounds, lo+step*int64(i))
+			}
+			return bounds, nil
+		}
+	}
+	if lo, err := strconv.ParseFloat(lower, 64); err == nil {
+		if hi, err := strconv.ParseFloat(upper, 64); err == nil {
+			step := (hi - lo) / float64(count)
+			for i := 1; i < count && step > 0; i++ {
+				bounds = append(bounds, lo+step*float64(i))
+			}
+			return bounds, nil
+		}
+	}
+	if lo, ok := parseWatermarkTime(strings.TrimSpace(lower)); ok {
+		if hi, ok := parseWatermarkTime(strings.TrimSpace(upper)); ok {
+			step := hi.Sub(lo) / time.Duration(count)
+			for i := 1; i < count && step > 0; i++ {
+				bounds = append(bounds, lo.Add(step*time.Duration(i)).Format("2006-01-02 15:04:05.999999999"))
+			}
+			return bounds, nil
+		}
+	}
+	return nil, fmt.Errorf("bounds %q and %q are neither numeric nor date values", lower, upper)
+}
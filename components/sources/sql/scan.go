@@ -0,0 +1,220 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+const (
+	valueModeNative = "native"
+	valueModeString = "string"
+)
+
+// rowScanner 根据结果集的列类型将一行数据转换为 `core.Record`。
+//
+// native 模式下各驱动返回的值会被统一为 int64、float64、bool、time.Time、[]byte 与 string，
+// 定点数保持为字符串以免丢失精度；string 模式保留旧的行为，所有非 NULL 值都以字符串输出。
+// 没有 value_mode 参数的任务（在该参数出现之前保存的任务）按 string 模式运行，输出保持不变。
+type rowScanner struct {
+	columnNames []string
+	kinds       []string // 每列的通用类型名，见 record 包
+	native      bool
+}
+
+func newRowScanner(rows *sql.Rows, valueMode string) (*rowScanner, error) {
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("sql source: failed to get column names from result set: %w", err)
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("sql source: failed to get column types from result set: %w", err)
+	}
+	kinds := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		kinds[i] = columnKind(ct)
+	}
+	return &rowScanner{
+		columnNames: columnNames,
+		kinds:       kinds,
+		native:      strings.EqualFold(strings.TrimSpace(valueMode), valueModeNative),
+	}, nil
+}
+
+func (rs *rowScanner) scan(rows *sql.Rows) (record.Record, error) {
+	if !rs.native {
+		return rs.scanString(rows)
+	}
+	values := make([]any, len(rs.columnNames))
+	scanArgs := make([]any, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, fmt.Errorf("sql source: failed to scan row: %w", err)
+	}
+	r := make(record.Record, len(rs.columnNames))
+	for i, colName := range rs.columnNames {
+		r[colName] = normalizeValue(values[i], rs.kinds[i])
+	}
+	return r, nil
+}
+
+func (rs *rowScanner) scanString(rows *sql.Rows) (record.Record, error) {
+	// 使用 `sql.RawBytes` 接收所有列，避免数据库类型与 Go 类型不匹配导致的扫描错误，
+	// NULL 值对应的 RawBytes 切片为 nil，类型转换的责任留给下游的 Processor。
+	values := make([]sql.RawBytes, len(rs.columnNames))
+	scanArgs := make([]interface{}, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	// 将当前行的数据扫描到 `values` 中。
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, fmt.Errorf("sql source: failed to scan row: %w", err)
+	}
+
+	r := make(record.Record, len(rs.columnNames))
+	for i, colName := range rs.columnNames {
+		if values[i] == nil {
+			r[colName] = nil
+		} else {
+			r[colName] = string(values[i])
+		}
+	}
+	return r, nil
+}
+
+// columnTypes 返回列名到通用类型名的映射。
+func (rs *rowScanner) columnTypes() map[string]string {
+	types := make(map[string]string, len(rs.columnNames))
+	for i, colName := range rs.columnNames {
+		types[colName] = rs.kinds[i]
+	}
+	return types
+}
+
+// integerTypes 是按完整类型名匹配的整数类型，避免 POINT 等以 INT 结尾的类型被误判。
+var integerTypes = map[string]bool{
+	"INT": true, "INTEGER": true, "TINYINT": true, "SMALLINT": true, "MEDIUMINT": true, "BIGINT": true,
+	"INT2": true, "INT4": true, "INT8": true, "BIG INT": true,
+	"SERIAL": true, "SMALLSERIAL": true, "BIGSERIAL": true, "SERIAL2": true, "SERIAL4": true, "SERIAL8": true,
+}
+
+// columnKind 将驱动报告的数据库类型名归类为通用类型名。
+func columnKind(ct *sql.ColumnType) string {
+	name := strings.ToUpper(strings.TrimSpace(ct.DatabaseTypeName()))
+	// SQLite 会原样返回建表时声明的类型，例如 DECIMAL(10,2) 或 INT(11) UNSIGNED
+	if i := strings.IndexByte(name, '('); i > 0 {
+		if j := strings.IndexByte(name[i:], ')'); j > 0 {
+			name = name[:i] + name[i+j+1:]
+		} else {
+			name = name[:i]
+		}
+	}
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(name), "UNSIGNED "), " UNSIGNED"))
+	switch {
+	case name == "":
+		return record.TypeString
+	case integerTypes[name]:
+		return record.TypeInteger
+	case name == "FLOAT" || name == "DOUBLE" || name == "REAL" || name == "FLOAT4" || name == "FLOAT8" || name == "DOUBLE PRECISION":
+		return record.TypeFloat
+	case name == "DECIMAL" || name == "NUMERIC":
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			return fmt.Sprintf("%s(%d,%d)", record.TypeDecimal, precision, scale)
+		}
+		return record.TypeDecimal
+	case name == "BOOL" || name == "BOOLEAN":
+		return record.TypeBoolean
+	case name == "DATE":
+		return record.TypeDate
+	case name == "DATETIME" || strings.HasPrefix(name, "TIMESTAMP"):
+		return record.TypeDatetime
+	case strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || name == "BYTEA":
+		return record.TypeBytes
+	default:
+		return record.TypeString
+	}
+}
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// normalizeValue 将驱动返回的值转换为该列通用类型对应的 Go 类型。
+// 转换失败时退回字符串，保证数据不会因为驱动差异而丢失。
+func normalizeValue(value any, kind string) any {
+	if value == nil {
+		return nil
+	}
+	var text string
+	switch v := value.(type) {
+	case []byte:
+		if kind == record.TypeBytes {
+			return v
+		}
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		if kind == record.TypeBoolean {
+			return v != 0
+		}
+		return v
+	case float64:
+		// SQLite 以浮点数存储定点数，按声明的小数位格式化为字符串
+		if strings.HasPrefix(kind, record.TypeDecimal) {
+			return strconv.FormatFloat(v, 'f', decimalScale(kind), 64)
+		}
+		return v
+	case time.Time:
+		return v
+	default:
+		return v
+	}
+	switch {
+	case kind == record.TypeInteger:
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(text, 10, 64); err == nil {
+			return u
+		}
+	case kind == record.TypeFloat:
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	case kind == record.TypeBoolean:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case kind == record.TypeDate || kind == record.TypeDatetime:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, text); err == nil {
+				return t
+			}
+		}
+	case kind == record.TypeBytes:
+		return []byte(text)
+	}
+	return text
+}
+
+// decimalScale 从 "decimal(精度,小数位)" 中取出小数位，未声明时返回 -1 表示按最短形式输出。
+func decimalScale(kind string) int {
+	var precision, scale int
+	if _, err := fmt.Sscanf(kind, record.TypeDecimal+"(%d,%d)", &precision, &scale); err != nil {
+		return -1
+	}
+	return scale
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
 	"github.com/BernardSimon/etl-go/etl/core/params"
@@ -18,6 +19,11 @@ type Source struct {
 	rows        *sql.Rows // SQL 查询结果集的前向只读迭代器。
 	datasource  *datasource.Datasource
 	columnNames []string // 预先获取的查询结果列名。
+	scanner     *rowScanner
+	dialect     string // 数据库方言，决定占位符与标识符的写法
+	incremental *incremental
+	watermark   string             // 上一次成功运行提交的水位线，由引擎注入
+	partitioned *partitionedReader // 分段并行读取时使用，否则为 nil
 }
 
 var mysqlName = "mysql"
@@ -35,9 +41,15 @@ func SourceCreatorMysql() (string, source.Source, *string, []params.Params) {
 			Required:     true,
 			Description:  "",
 		},
+		{
+			Key:          "value_mode",
+			DefaultValue: "native",
+			Required:     false,
+			Description:  "native emits typed values (int64, float64, bool, time.Time, []byte, decimal as string), string keeps every value as text; tasks saved without this parameter run in string mode",
+		},
 	}
 
-	return mysqlName, &Source{}, &mysqlDatasourceName, paramList
+	return mysqlName, &Source{dialect: dialectMysql}, &mysqlDatasourceName, append(append(paramList, incrementalParams...), partitionParams...)
 }
 
 var postgresqlName = "postgre"
@@ -55,9 +67,15 @@ func SourceCreatorPostgre() (string, source.Source, *string, []params.Params) {
 			Required:     true,
 			Description:  "",
 		},
+		{
+			Key:          "value_mode",
+			DefaultValue: "native",
+			Required:     false,
+			Description:  "native emits typed values (int64, float64, bool, time.Time, []byte, decimal as string), string keeps every value as text; tasks saved without this parameter run in string mode",
+		},
 	}
 
-	return postgresqlName, &Source{}, &postgresqlDatasourceName, paramList
+	return postgresqlName, &Source{dialect: dialectPostgre}, &postgresqlDatasourceName, append(append(paramList, incrementalParams...), partitionParams...)
 }
 
 var sqliteName = "sqlite"
@@ -75,9 +93,15 @@ func SourceCreatorSqlite() (string, source.Source, *string, []params.Params) {
 			Required:     true,
 			Description:  "",
 		},
+		{
+			Key:          "value_mode",
+			DefaultValue: "native",
+			Required:     false,
+			Description:  "native emits typed values (int64, float64, bool, time.Time, []byte, decimal as string), string keeps every value as text; tasks saved without this parameter run in string mode",
+		},
 	}
 
-	return sqliteName, &Source{}, &sqliteDatasourceName, paramList
+	return sqliteName, &Source{dialect: dialectSqlite}, &sqliteDatasourceName, append(append(paramList, incrementalParams...), partitionParams...)
 }
 
 func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
@@ -88,59 +112,69 @@ func (s *Source) Open(config map[string]string, dataSource *datasource.Datasourc
 		return fmt.Errorf("sql source: config is missing or has invalid 'query'")
 	}
 
-	var err error
+	// 将 :name 占位符替换为驱动参数，值来自任务变量
+	query, args, err := params.BindNamed(query, config, 0, func(n int) string {
+		return placeholder(s.dialect, n)
+	}, s.dialect == dialectMysql)
+	if err != nil {
+		return fmt.Errorf("sql source: %w", err)
+	}
+	s.incremental, err = newIncremental(config, s.watermark)
+	if err != nil {
+		return err
+	}
+	if s.incremental != nil {
+		query, args = s.incremental.wrap(query, args, s.dialect)
+	}
 	s.db = (*dataSource).Open().(*sql.DB)
+	s.partitioned = nil
+	if column := strings.TrimSpace(config["partition_column"]); column != "" {
+		// 分段读取时每个分段的结果集结构相同，列信息取自第一个分段
+		return s.openPartitions(query, args, column, config)
+	}
 	// 执行查询，获取结果集迭代器。
-	s.rows, err = s.db.Query(query)
+	s.rows, err = s.db.Query(query, args...)
 	if err != nil {
 		return fmt.Errorf("sql source: failed to executor query: %w", err)
 	}
-	// 预先获取并存储列名，这将在 Read 方法中用于构建 map[string]interface{} 格式的 Record。
-	s.columnNames, err = s.rows.Columns()
+	// 预先获取列名与列类型，这将在 Read 方法中用于构建 map[string]interface{} 格式的 Record。
+	s.scanner, err = newRowScanner(s.rows, config["value_mode"])
 	if err != nil {
-		return fmt.Errorf("sql source: failed to get column names from result set: %w", err)
+		return err
 	}
+	s.columnNames = s.scanner.columnNames
 
 	return nil
 }
 
 // Read 读取查询结果的下一行，并将其转换为一个 `core.Record`。
+// 分段并行读取时，记录来自各分段合并后的通道，顺序不做保证。
 func (s *Source) Read() (record.Record, error) {
-	// 检查结果集中是否还有下一行。
-	if !s.rows.Next() {
-		// 在迭代结束后，必须调用 .Err() 来检查循环期间是否发生错误。
-		if err := s.rows.Err(); err != nil {
-			return nil, fmt.Errorf("sql source: error during row iteration: %w", err)
+	var r record.Record
+	var err error
+	if s.partitioned != nil {
+		r, err = s.partitioned.read()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// 检查结果集中是否还有下一行。
+		if !s.rows.Next() {
+			// 在迭代结束后，必须调用 .Err() 来检查循环期间是否发生错误。
+			if err := s.rows.Err(); err != nil {
+				return nil, fmt.Errorf("sql source: error during row iteration: %w", err)
+			}
+			// 如果没有错误，说明已成功到达结果集末尾，返回 EOF 信号。
+			return nil, io.EOF
 		}
-		// 如果没有错误，说明已成功到达结果集末尾，返回 EOF 信号。
-		return nil, io.EOF
-	}
-
-	// 核心技巧：为了实现最大的通用性和健壮性，我们不直接扫描到具体的 Go 类型（如 int, string, time.Time），
-	// 而是使用 `sql.RawBytes`。这有几个好处：
-	// 1. 避免因数据库类型（如可为 NULL 的整数）与 Go 类型不匹配而导致的扫描错误。
-	// 2. 优雅地处理 NULL 值（此时 RawBytes 切片为 nil）。
-	// 3. 将所有值作为原始字节切片来处理，将类型转换的责任推迟到下游的 Processor，
-	//    这使得 Source 组件更通用，更符合 ETL 的分阶段处理思想。
-	values := make([]sql.RawBytes, len(s.columnNames))
-	scanArgs := make([]interface{}, len(values))
-	for i := range values {
-		scanArgs[i] = &values[i]
-	}
-
-	// 将当前行的数据扫描到 `values` 中。
-	if err := s.rows.Scan(scanArgs...); err != nil {
-		return nil, fmt.Errorf("sql source: failed to scan row: %w", err)
-	}
-
-	r := make(record.Record)
-	for i, colName := range s.columnNames {
-		// 如果数据库中的值是 NULL，对应的 RawBytes 切片将是 nil。
-		if values[i] == nil {
-			r[colName] = nil
-		} else {
-			// 否则，将其作为字符串存入 record。下游的 processor 可以根据需要再进行解析。
-			r[colName] = string(values[i])
+		r, err = s.scanner.scan(s.rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.incremental != nil {
+		if err := s.incremental.observe(r); err != nil {
+			return nil, err
 		}
 	}
 
@@ -153,6 +187,11 @@ func (s *Source) Close() error {
 	var errs []error
 
 	// 必须先关闭 rows。
+	if s.partitioned != nil {
+		if err := s.partitioned.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if s.rows != nil {
 		if err := s.rows.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("sql source: failed to close rows: %w", err))
@@ -173,3 +212,33 @@ func (s *Source) Column() map[string]string {
 	}
 	return k
 }
+
+// SetWatermark 实现了 source.Incremental 接口，注入上一次成功运行提交的水位线。
+func (s *Source) SetWatermark(watermark string) {
+	s.watermark = watermark
+}
+
+// Watermark 实现了 source.Incremental 接口，返回本次运行读取到的最大水位线。
+// 未开启增量模式时返回空字符串。
+func (s *Source) Watermark() string {
+	if s.incremental == nil {
+		return ""
+	}
+	return s.incremental.max
+}
+
+// Progress 实现了 source.Progress 接口，返回分段并行读取时各分段已读取的行数。
+func (s *Source) Progress() map[string]int64 {
+	if s.partitioned == nil {
+		return nil
+	}
+	return s.partitioned.progress()
+}
+
+// ColumnTypes 实现了 source.Schema 接口，返回查询结果各列的通用类型名。
+func (s *Source) ColumnTypes() map[string]string {
+	if s.scanner == nil {
+		return nil
+	}
+	return s.scanner.columnTypes()
+}
@@ -0,0 +1,122 @@
+package text
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+const (
+	trimBoth  = "both"
+	trimLeft  = "left"
+	trimRight = "right"
+	trimNone  = "none"
+)
+
+// fixedColumn 是定宽文件中的一列，start 从 0 开始。
+type fixedColumn struct {
+	name   string
+	start  int
+	length int
+	trim   string
+}
+
+// parseFixedColumns 解析 name:start:length[:trim] 形式的列定义，start 从 1 开始。
+func parseFixedColumns(spec string, defaultTrim string) ([]fixedColumn, error) {
+	if !validTrim(defaultTrim) {
+		return nil, fmt.Errorf("unsupported 'trim' %q, expected both, left, right or none", defaultTrim)
+	}
+	var columns []fixedColumn
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid column spec %q, expected name:start:length[:trim]", item)
+		}
+		c := fixedColumn{name: strings.TrimSpace(parts[0]), trim: defaultTrim}
+		start, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid start of column spec %q", item)
+		}
+		c.start = start - 1
+		if c.length, err = strconv.Atoi(strings.TrimSpace(parts[2])); err != nil || c.length < 1 {
+			return nil, fmt.Errorf("invalid length of column spec %q", item)
+		}
+		if len(parts) == 4 {
+			c.trim = strings.ToLower(strings.TrimSpace(parts[3]))
+			if !validTrim(c.trim) {
+				return nil, fmt.Errorf("invalid trim of column spec %q, expected both, left, right or none", item)
+			}
+		}
+		if c.name == "" {
+			return nil, fmt.Errorf("column spec %q has no name", item)
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("'columns' is required in fixed_width mode")
+	}
+	return columns, nil
+}
+
+func validTrim(trim string) bool {
+	switch trim {
+	case trimBoth, trimLeft, trimRight, trimNone:
+		return true
+	}
+	return false
+}
+
+// parseFixed 按列定义截取字段。行尾的空白常被发送方截掉，因此超出行尾的部分按实际长度截取，
+// 只有行的长度不足以到达某一列的起始位置时才视为无法解析。
+func (s *Source) parseFixed(line []byte) (record.Record, error) {
+	var text []rune
+	length := len(line)
+	if !s.byteWidth {
+		text = []rune(string(line))
+		length = len(text)
+	}
+	r := make(record.Record, len(s.fixed)+1)
+	for _, c := range s.fixed {
+		if c.start >= length {
+			return nil, fmt.Errorf("line has %d %s, column '%s' starts at %d", length, s.unitName(), c.name, c.start+1)
+		}
+		end := min(c.start+c.length, length)
+		var value string
+		if s.byteWidth {
+			field := line[c.start:end]
+			if s.encoding != nil {
+				decoded, err := s.encoding.NewDecoder().Bytes(field)
+				if err != nil {
+					return nil, fmt.Errorf("column '%s': failed to decode: %w", c.name, err)
+				}
+				field = decoded
+			}
+			value = string(field)
+		} else {
+			value = string(text[c.start:end])
+		}
+		switch c.trim {
+		case trimBoth:
+			value = strings.TrimSpace(value)
+		case trimLeft:
+			value = strings.TrimLeft(value, " \t")
+		case trimRight:
+			value = strings.TrimRight(value, " \t")
+		}
+		r[c.name] = value
+	}
+	return r, nil
+}
+
+func (s *Source) unitName() string {
+	if s.byteWidth {
+		return "bytes"
+	}
+	return "characters"
+}
@@ -0,0 +1,432 @@
+package text
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/files"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+	"golang.org/x/text/encoding"
+)
+
+var name = "text"
+
+func SetCustomName(customName string) {
+	name = customName
+}
+
+const (
+	modeFixedWidth = "fixed_width"
+	modeRegex      = "regex"
+
+	unmatchedError = "error"
+	unmatchedSkip  = "skip"
+)
+
+// Source 实现了 core.Source 与 core.Progress 接口，用于按行读取定宽文本文件或按正则表达式解析的文本文件。
+//
+// 定宽模式按列定义的起始位置与长度截取字段，正则模式使用命名捕获组作为列。
+// 无法解析的行默认返回错误并按管道的快速失败策略终止任务，on_unmatched 为 skip 时跳过并计数，
+// 计数通过 Progress 在读取结束时输出。
+type Source struct {
+	files      *files.Reader
+	stream     *files.Stream
+	reader     *bufio.Reader
+	line       int // 当前文件中已读取的行号
+	columns    []string
+	fixed      []fixedColumn
+	byteWidth  bool // 定宽模式按原始编码的字节截取字段
+	pattern    *regexp.Regexp
+	groups     []int // 命名捕获组的序号，与 columns 一一对应
+	skipLines  int
+	skipBlank  bool
+	skip       *regexp.Regexp // 匹配的行被忽略，例如文件头尾的汇总行
+	unmatched  string
+	encoding   encoding.Encoding
+	stripBOM   bool
+	matched    int64
+	skipped    int64 // 无法解析而跳过的行数
+	sourceFile bool
+}
+
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "file_id",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "The file_id to the text file",
+		},
+		{
+			Key:          "file_ids",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Comma separated file_ids read in order as one stream, used instead of file_id",
+		},
+		{
+			Key:          "mode",
+			DefaultValue: modeFixedWidth,
+			Required:     true,
+			Description:  "fixed_width or regex",
+		},
+		{
+			Key:          "columns",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "fixed_width column specs name:start:length[:trim], start is 1-based and trim is both, left, right or none, e.g. id:1:8,name:9:20:right",
+		},
+		{
+			Key:          "trim",
+			DefaultValue: "both",
+			Required:     false,
+			Description:  "default trim of fixed_width columns: both, left, right or none",
+		},
+		{
+			Key:          "width_unit",
+			DefaultValue: "char",
+			Required:     false,
+			Description:  "unit of fixed_width positions: char, or byte of the file encoding as in mainframe layouts",
+		},
+		{
+			Key:          "pattern",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "regex mode pattern, named groups (?P<name>...) become columns",
+		},
+		{
+			Key:          "encoding",
+			DefaultValue: "utf-8",
+			Required:     false,
+			Description:  "Character encoding of the file: utf-8, gbk, gb18030, utf-16, utf-16le, utf-16be, latin-1",
+		},
+		{
+			Key:          "strip_bom",
+			DefaultValue: "true",
+			Required:     false,
+			Description:  "Remove a byte order mark at the start of the file",
+		},
+		{
+			Key:          "skip_lines",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "Number of leading lines skipped in each file",
+		},
+		{
+			Key:          "skip_blank_lines",
+			DefaultValue: "true",
+			Required:     false,
+			Description:  "Skip lines that are empty or contain only white space",
+		},
+		{
+			Key:          "skip_pattern",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Lines matching this regex are skipped, e.g. ^(HDR|TRL) for header and trailer records",
+		},
+		{
+			Key:          "on_unmatched",
+			DefaultValue: unmatchedError,
+			Required:     false,
+			Description:  "Lines that cannot be parsed: error fails the task, skip drops them and reports the count at the end",
+		},
+		{
+			Key:          "include_source_file",
+			DefaultValue: "false",
+			Required:     false,
+			Description:  "Add a _source_file column with the name of the file each line was read from",
+		},
+	}
+	return name, &Source{}, nil, paramList
+}
+
+// Open 解析配置并打开第一个文件。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	inputs, err := files.Inputs(config)
+	if err != nil {
+		return fmt.Errorf("text source: %w", err)
+	}
+	if err := s.configure(config); err != nil {
+		return fmt.Errorf("text source: %w", err)
+	}
+	s.matched, s.skipped = 0, 0
+	s.files = files.NewReader(inputs)
+	if err := s.nextFile(); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+func (s *Source) configure(config map[string]string) error {
+	var err error
+	if s.stripBOM, err = boolOption(config, "strip_bom", true); err != nil {
+		return err
+	}
+	if s.skipBlank, err = boolOption(config, "skip_blank_lines", true); err != nil {
+		return err
+	}
+	if s.sourceFile, err = boolOption(config, "include_source_file", false); err != nil {
+		return err
+	}
+	if s.encoding, err = files.LookupEncoding(config["encoding"], s.stripBOM); err != nil {
+		return err
+	}
+	s.skipLines = 0
+	if v := strings.TrimSpace(config["skip_lines"]); v != "" {
+		if s.skipLines, err = strconv.Atoi(v); err != nil || s.skipLines < 0 {
+			return fmt.Errorf("invalid 'skip_lines' %q", v)
+		}
+	}
+	s.skip = nil
+	if v := config["skip_pattern"]; v != "" {
+		if s.skip, err = regexp.Compile(v); err != nil {
+			return fmt.Errorf("invalid 'skip_pattern': %w", err)
+		}
+	}
+	s.unmatched = strings.ToLower(strings.TrimSpace(config["on_unmatched"]))
+	switch s.unmatched {
+	case "":
+		s.unmatched = unmatchedError
+	case unmatchedError, unmatchedSkip:
+	default:
+		return fmt.Errorf("unsupported 'on_unmatched' %q, expected error or skip", s.unmatched)
+	}
+
+	s.columns, s.fixed, s.pattern, s.groups = nil, nil, nil, nil
+	switch mode := strings.ToLower(strings.TrimSpace(config["mode"])); mode {
+	case "", modeFixedWidth:
+		if err := s.configureFixed(config); err != nil {
+			return err
+		}
+	case modeRegex:
+		if s.pattern, err = regexp.Compile(config["pattern"]); err != nil {
+			return fmt.Errorf("invalid 'pattern': %w", err)
+		}
+		for i, group := range s.pattern.SubexpNames() {
+			if group != "" {
+				s.columns = append(s.columns, group)
+				s.groups = append(s.groups, i)
+			}
+		}
+		if len(s.columns) == 0 {
+			return fmt.Errorf("'pattern' has no named groups (?P<name>...)")
+		}
+	default:
+		return fmt.Errorf("unsupported 'mode' %q, expected fixed_width or regex", mode)
+	}
+	seen := make(map[string]bool, len(s.columns))
+	for _, column := range s.columns {
+		if seen[column] {
+			return fmt.Errorf("duplicate column '%s'", column)
+		}
+		seen[column] = true
+	}
+	if s.sourceFile && seen[files.SourceFileColumn] {
+		return fmt.Errorf("column '%s' conflicts with the source file column", files.SourceFileColumn)
+	}
+	return nil
+}
+
+func (s *Source) configureFixed(config map[string]string) error {
+	trim := strings.ToLower(strings.TrimSpace(config["trim"]))
+	if trim == "" {
+		trim = trimBoth
+	}
+	var err error
+	if s.fixed, err = parseFixedColumns(config["columns"], trim); err != nil {
+		return err
+	}
+	for _, c := range s.fixed {
+		s.columns = append(s.columns, c.name)
+	}
+	switch unit := strings.ToLower(strings.TrimSpace(config["width_unit"])); unit {
+	case "", "char":
+		s.byteWidth = false
+	case "byte":
+		if s.encoding != nil && strings.HasPrefix(strings.ToLower(strings.TrimSpace(config["encoding"])), "utf-16") {
+			return fmt.Errorf("'width_unit' byte is not supported for utf-16 files")
+		}
+		s.byteWidth = true
+	default:
+		return fmt.Errorf("unsupported 'width_unit' %q, expected char or byte", unit)
+	}
+	return nil
+}
+
+// nextFile 关闭当前文件并打开下一个文件，跳过开头的 skip_lines 行。
+func (s *Source) nextFile() error {
+	if err := s.closeStream(); err != nil {
+		return err
+	}
+	stream, err := s.files.Next()
+	if err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return fmt.Errorf("text source: %w", err)
+	}
+	s.stream = stream
+	if s.byteWidth {
+		// 按字节截取时保留原始编码，字段截取后再逐个解码
+		s.reader = bufio.NewReader(files.Decode(stream, nil, s.stripBOM))
+	} else {
+		s.reader = bufio.NewReader(files.Decode(stream, s.encoding, s.stripBOM))
+	}
+	s.line = 0
+	for s.line < s.skipLines {
+		if _, err := s.readLine(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// readLine 读取一行并去掉行尾的换行符。
+func (s *Source) readLine() ([]byte, error) {
+	line, err := s.reader.ReadBytes('\n')
+	if len(line) == 0 && err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("text source: failed to read %s: %w", s.stream.Name, err)
+	}
+	s.line++
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), nil
+}
+
+// Read 读取并解析下一行，当前文件读完时自动切换到下一个文件。
+func (s *Source) Read() (record.Record, error) {
+	for {
+		if s.reader == nil {
+			return nil, io.EOF
+		}
+		line, err := s.readLine()
+		if err == io.EOF {
+			if err = s.nextFile(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if s.skipBlank && len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if s.skip != nil && s.skip.Match(line) {
+			continue
+		}
+
+		r, err := s.parse(line)
+		if err != nil {
+			if s.unmatched == unmatchedSkip {
+				s.skipped++
+				continue
+			}
+			return nil, fmt.Errorf("text source: %s line %d: %w", s.stream.Name, s.line, err)
+		}
+		s.matched++
+		if s.sourceFile {
+			r[files.SourceFileColumn] = s.stream.Name
+		}
+		return r, nil
+	}
+}
+
+// parse 按模式将一行解析为记录，无法解析时返回错误。
+func (s *Source) parse(line []byte) (record.Record, error) {
+	if s.pattern != nil {
+		match := s.pattern.FindSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line does not match 'pattern': %q", truncate(string(line)))
+		}
+		r := make(record.Record, len(s.columns)+1)
+		for i, column := range s.columns {
+			if group := match[s.groups[i]]; group != nil {
+				r[column] = string(group)
+			} else {
+				// 未参与匹配的可选捕获组输出为 NULL
+				r[column] = nil
+			}
+		}
+		return r, nil
+	}
+	return s.parseFixed(line)
+}
+
+// Progress 返回已解析与因无法解析而跳过的行数。
+func (s *Source) Progress() map[string]int64 {
+	progress := map[string]int64{"text matched lines": s.matched}
+	if s.unmatched == unmatchedSkip {
+		progress["text unmatched lines skipped"] = s.skipped
+	}
+	return progress
+}
+
+// Close 关闭文件句柄，释放资源。
+func (s *Source) Close() error {
+	err := s.closeStream()
+	if s.files != nil {
+		err = errors.Join(err, s.files.Close())
+		s.files = nil
+	}
+	return err
+}
+
+func (s *Source) closeStream() error {
+	s.reader = nil
+	if s.stream == nil {
+		return nil
+	}
+	err := s.stream.Close()
+	s.stream = nil
+	if err != nil {
+		return fmt.Errorf("text source: failed to close file: %w", err)
+	}
+	return nil
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, v := range s.columns {
+		columns[v] = v
+	}
+	if s.sourceFile {
+		columns[files.SourceFileColumn] = files.SourceFileColumn
+	}
+	return columns
+}
+
+// truncate 截取过长的行用于错误提示。
+func truncate(line string) string {
+	const limit = 200
+	if runes := []rune(line); len(runes) > limit {
+		return string(runes[:limit]) + "..."
+	}
+	return line
+}
+
+func boolOption(config map[string]string, key string, defaultValue bool) (bool, error) {
+	v := strings.TrimSpace(config[key])
+	if v == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid '%s' %q", key, v)
+	}
+	return parsed, nil
+}
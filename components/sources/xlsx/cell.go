@@ -0,0 +1,97 @@
+package xlsx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cellValue 按单元格的类型与样式属性将原始值转换为 Go 类型：
+// 布尔值输出 bool，数字按是否为日期格式输出 time.Time、int64 或 float64，其余输出字符串。
+func (s *Source) cellValue(col int, raw string, attr cellAttr) (any, error) {
+	switch attr.typ {
+	case "b":
+		return raw == "1" || strings.EqualFold(raw, "true"), nil
+	case "d":
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		return raw, nil
+	case "", "n":
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return raw, nil
+		}
+		if s.isDateStyle(attr.style) {
+			t, err := excelize.ExcelDateToTime(number, s.date1904)
+			if err != nil {
+				cell, _ := excelize.CoordinatesToCellName(col, s.row)
+				return nil, fmt.Errorf("xlsx source: invalid date in cell %s: %w", cell, err)
+			}
+			return t, nil
+		}
+		if number == math.Trunc(number) && math.Abs(number) < 1<<53 {
+			return int64(number), nil
+		}
+		return number, nil
+	default:
+		return raw, nil
+	}
+}
+
+// isDateStyle 根据样式的数字格式判断单元格是否为日期或时间，样式只从 styles.xml 读取并按编号缓存。
+func (s *Source) isDateStyle(styleID int) bool {
+	if isDate, ok := s.dateStyle[styleID]; ok {
+		return isDate
+	}
+	isDate := false
+	if style, err := s.file.GetStyle(styleID); err == nil && style != nil {
+		if style.CustomNumFmt != nil {
+			isDate = isDateFormat(*style.CustomNumFmt)
+		} else {
+			isDate = isBuiltInDateFormat(style.NumFmt)
+		}
+	}
+	s.dateStyle[styleID] = isDate
+	return isDate
+}
+
+// isBuiltInDateFormat 判断内置数字格式编号是否为日期或时间格式。
+func isBuiltInDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// isDateFormat 判断自定义数字格式是否包含日期或时间占位符，引号中的文本与方括号中的颜色、条件不参与判断。
+func isDateFormat(format string) bool {
+	if strings.EqualFold(format, "general") {
+		return false
+	}
+	var inQuote, inBracket bool
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '\\':
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			inBracket = true
+		case c == ']':
+			inBracket = false
+		case inBracket:
+		default:
+			switch c | 0x20 {
+			case 'y', 'm', 'd', 'h', 's':
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cellAttr 为单元格 XML 元素上的类型与样式属性。
+type cellAttr struct {
+	typ   string // t 属性，为空表示数字
+	style int    // s 属性，即样式编号
+}
+
+// sheetAttrs 与行迭代器并行地流式读取工作表 XML，只解析每个单元格的类型与样式属性。
+// excelize 的 GetCellType 与 GetCellStyle 会将整个工作表加载到内存，逐单元格调用会使流式读取失效。
+type sheetAttrs struct {
+	archive *zip.ReadCloser
+	part    io.ReadCloser
+	decoder *xml.Decoder
+	row     int               // 已解析到的行号
+	next    *xml.StartElement // 读取时越过的下一行
+	cells   map[int]cellAttr  // 当前行各列的属性
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name  string     `xml:"name,attr"`
+		Attrs []xml.Attr `xml:",any,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// openSheetAttrs 按工作簿关系找到工作表对应的 XML 部件并打开。
+func openSheetAttrs(filePath string, sheet string) (*sheetAttrs, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[strings.ToLower(strings.TrimPrefix(f.Name, "/"))] = f
+	}
+	part, err := sheetPart(parts, sheet)
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	reader, err := part.Open()
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return &sheetAttrs{archive: archive, part: reader, decoder: xml.NewDecoder(reader)}, nil
+}
+
+func sheetPart(parts map[string]*zip.File, sheet string) (*zip.File, error) {
+	var rootRels xmlRelationships
+	if err := decodePart(parts, "_rels/.rels", &rootRels); err != nil {
+		return nil, err
+	}
+	workbookPath := "xl/workbook.xml"
+	for _, rel := range rootRels.Relationships {
+		if strings.HasSuffix(rel.Type, "/officeDocument") {
+			workbookPath = resolveTarget("", rel.Target)
+			break
+		}
+	}
+	var workbook xmlWorkbook
+	if err := decodePart(parts, workbookPath, &workbook); err != nil {
+		return nil, err
+	}
+	var rID string
+	for _, v := range workbook.Sheets {
+		if v.Name != sheet {
+			continue
+		}
+		for _, attr := range v.Attrs {
+			if attr.Name.Local == "id" && attr.Name.Space != "" {
+				rID = attr.Value
+			}
+		}
+	}
+	if rID == "" {
+		return nil, fmt.Errorf("sheet '%s' not found in %s", sheet, workbookPath)
+	}
+	var rels xmlRelationships
+	dir, file := path.Split(workbookPath)
+	if err := decodePart(parts, dir+"_rels/"+file+".rels", &rels); err != nil {
+		return nil, err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == rID {
+			target := resolveTarget(dir, rel.Target)
+			if part, ok := parts[strings.ToLower(target)]; ok {
+				return part, nil
+			}
+			return nil, fmt.Errorf("worksheet part %s not found", target)
+		}
+	}
+	return nil, fmt.Errorf("relationship %s of sheet '%s' not found", rID, sheet)
+}
+
+// resolveTarget 将关系中的目标解析为包内路径，以 / 开头的目标相对于包根目录。
+func resolveTarget(dir string, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(dir, target)
+}
+
+func decodePart(parts map[string]*zip.File, name string, v any) error {
+	part, ok := parts[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("part %s not found", name)
+	}
+	reader, err := part.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// cellsOf 返回第 row 行各列（从 1 开始）的单元格属性，行号需递增调用；工作表中没有该行时返回空。
+func (a *sheetAttrs) cellsOf(row int) (map[int]cellAttr, error) {
+	for a.row < row {
+		start, err := a.nextRow()
+		if err != nil {
+			return nil, err
+		}
+		if start == nil {
+			a.row, a.cells = row, nil
+			break
+		}
+		rowNum := a.row + 1
+		if v := attrValue(start, "r"); v != "" {
+			if rowNum, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid row number %q", v)
+			}
+		}
+		if rowNum > row {
+			// 越过了请求的行，说明该行为空，留待下次读取
+			a.next = start
+			a.row, a.cells = row, nil
+			break
+		}
+		a.row = rowNum
+		if a.cells, err = a.readCells(); err != nil {
+			return nil, err
+		}
+	}
+	if a.row != row {
+		return nil, nil
+	}
+	return a.cells, nil
+}
+
+// nextRow 读取到下一个 row 元素的开始，工作表结束时返回 nil。
+func (a *sheetAttrs) nextRow() (*xml.StartElement, error) {
+	if a.next != nil {
+		start := a.next
+		a.next = nil
+		return start, nil
+	}
+	for {
+		token, err := a.decoder.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "row" {
+			start = start.Copy()
+			return &start, nil
+		}
+	}
+}
+
+// readCells 读取当前行内所有单元格的属性，直到行结束。
+func (a *sheetAttrs) readCells() (map[int]cellAttr, error) {
+	cells := make(map[int]cellAttr)
+	col := 0
+	for {
+		token, err := a.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				if err := a.decoder.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			col++
+			if ref := attrValue(&t, "r"); ref != "" {
+				if col, _, err = excelize.CellNameToCoordinates(ref); err != nil {
+					return nil, err
+				}
+			}
+			attr := cellAttr{typ: attrValue(&t, "t")}
+			if v := attrValue(&t, "s"); v != "" {
+				attr.style, _ = strconv.Atoi(v)
+			}
+			cells[col] = attr
+			if err := a.decoder.Skip(); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				return cells, nil
+			}
+		}
+	}
+}
+
+func attrValue(start *xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Close 关闭工作表部件与文件。
+func (a *sheetAttrs) Close() error {
+	a.part.Close()
+	return a.archive.Close()
+}
@@ -0,0 +1,385 @@
+package xlsx
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+	"github.com/xuri/excelize/v2"
+)
+
+var name = "xlsx"
+
+const (
+	valueModeNative = "native"
+	valueModeString = "string"
+)
+
+// Source 实现了 core.Source 接口，用于从 Excel(.xlsx) 文件的一个工作表读取数据。
+//
+// 行数据通过 excelize 的行迭代器顺序读取；native 模式下会按单元格类型与数字格式输出
+// int64、float64、bool、time.Time 或 string，string 模式输出与 Excel 中显示一致的文本。
+type Source struct {
+	filePath  string
+	file      *excelize.File
+	rows      *excelize.Rows
+	attrs     *sheetAttrs // native 模式下与 rows 并行读取单元格的类型与样式
+	sheet     string      // 工作表名称
+	header    []string    // 表头列名
+	firstCol  int         // 读取范围的首列，从 1 开始
+	lastCol   int         // 读取范围的末列，0 表示不限
+	lastRow   int         // 读取范围的末行，0 表示不限
+	row       int         // 当前已读取到的行号，用于精确的错误报告
+	native    bool
+	date1904  bool
+	dateStyle map[int]bool // 单元格样式是否为日期格式的缓存
+}
+
+// SourceCreator 实现了源组件的创建接口，返回组件名称、实例和参数定义
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "file_id",
+			DefaultValue: "",
+			Required:     true,
+			Description:  "The file_id to the XLSX file",
+		},
+		{
+			Key:          "sheet",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "sheet name or 1-based sheet index, empty means the first sheet",
+		},
+		{
+			Key:          "range",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "cell range to read, e.g. B2:F100 or B:F, empty means the whole sheet",
+		},
+		{
+			Key:          "header_offset",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "number of rows in the range skipped before the header row",
+		},
+		{
+			Key:          "has_header",
+			DefaultValue: "true",
+			Required:     false,
+			Description:  "whether the first row is a header, column letters (A, B, ...) are used as names otherwise",
+		},
+		{
+			Key:          "value_mode",
+			DefaultValue: valueModeNative,
+			Required:     false,
+			Description:  "native emits typed cells (numbers, booleans, dates), string emits the displayed text",
+		},
+	}
+	return name, &Source{}, nil, paramList
+}
+
+// Open 负责解析配置、打开文件并读取表头。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	filePath, ok := config["file_path"]
+	if !ok {
+		return fmt.Errorf("xlsx source: config is missing required key 'file_path'")
+	}
+	s.filePath = filePath
+	s.native = config["value_mode"] != valueModeString
+	s.dateStyle = make(map[int]bool)
+	s.header = nil
+
+	firstRow := 1
+	s.firstCol, s.lastCol, s.lastRow = 1, 0, 0
+	if v := strings.TrimSpace(config["range"]); v != "" {
+		var err error
+		s.firstCol, firstRow, s.lastCol, s.lastRow, err = parseRange(v)
+		if err != nil {
+			return fmt.Errorf("xlsx source: invalid 'range' %q: %w", v, err)
+		}
+	}
+	headerOffset := 0
+	if v := strings.TrimSpace(config["header_offset"]); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("xlsx source: invalid 'header_offset' %q", v)
+		}
+		headerOffset = parsed
+	}
+	hasHeader := true
+	if v := strings.TrimSpace(config["has_header"]); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("xlsx source: invalid 'has_header' %q", v)
+		}
+		hasHeader = parsed
+	}
+
+	var err error
+	s.file, err = excelize.OpenFile(s.filePath)
+	if err != nil {
+		return fmt.Errorf("xlsx source: failed to open file %s: %w", s.filePath, err)
+	}
+	s.sheet, err = resolveSheet(s.file, config["sheet"])
+	if err != nil {
+		return err
+	}
+	if props, err := s.file.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		s.date1904 = *props.Date1904
+	}
+	s.rows, err = s.file.Rows(s.sheet)
+	if err != nil {
+		return fmt.Errorf("xlsx source: failed to read sheet '%s': %w", s.sheet, err)
+	}
+	s.row = 0
+	if s.native {
+		if s.attrs, err = openSheetAttrs(s.filePath, s.sheet); err != nil {
+			return fmt.Errorf("xlsx source: failed to read sheet '%s': %w", s.sheet, err)
+		}
+	}
+
+	// 跳过范围之前的行与表头之前的偏移行
+	for s.row < firstRow-1+headerOffset {
+		if !s.rows.Next() {
+			return s.exhausted("file is empty or contains only a header")
+		}
+		s.row++
+	}
+	if !hasHeader {
+		return s.columnLetters()
+	}
+	if !s.rows.Next() {
+		return s.exhausted("file is empty or contains only a header")
+	}
+	s.row++
+	cells, err := s.rows.Columns()
+	if err != nil {
+		return fmt.Errorf("xlsx source: failed to read header at row %d: %w", s.row, err)
+	}
+	cells = s.clip(cells)
+	s.header = make([]string, len(cells))
+	seen := make(map[string]int)
+	for i, cell := range cells {
+		columnName := strings.TrimSpace(cell)
+		if columnName == "" {
+			columnName, _ = excelize.ColumnNumberToName(s.firstCol + i)
+		}
+		// 重复的列名追加序号，避免同名列互相覆盖
+		if n := seen[columnName]; n > 0 {
+			seen[columnName] = n + 1
+			columnName = fmt.Sprintf("%s_%d", columnName, n+1)
+		} else {
+			seen[columnName] = 1
+		}
+		s.header[i] = columnName
+	}
+	// 指定了列范围时，范围内末尾没有表头的列同样以列字母命名
+	s.padHeader(s.lastCol)
+	return nil
+}
+
+func (s *Source) exhausted(message string) error {
+	if err := s.rows.Error(); err != nil {
+		return fmt.Errorf("xlsx source: failed to read sheet '%s': %w", s.sheet, err)
+	}
+	return fmt.Errorf("xlsx source: %s", message)
+}
+
+// Read 读取下一行非空数据并将其转换为一个 core.Record，到达工作表或范围末尾时返回 io.EOF。
+func (s *Source) Read() (record.Record, error) {
+	for {
+		if s.lastRow > 0 && s.row >= s.lastRow {
+			return nil, io.EOF
+		}
+		if !s.rows.Next() {
+			if err := s.rows.Error(); err != nil {
+				return nil, fmt.Errorf("xlsx source: error reading data at row %d: %w", s.row+1, err)
+			}
+			return nil, io.EOF
+		}
+		s.row++
+		var cells []string
+		var err error
+		if s.native {
+			cells, err = s.rows.Columns(excelize.Options{RawCellValue: true})
+		} else {
+			cells, err = s.rows.Columns()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xlsx source: error reading data at row %d: %w", s.row, err)
+		}
+		cells = s.clip(cells)
+		if isEmptyRow(cells) {
+			continue
+		}
+		if len(cells) > len(s.header) {
+			return nil, fmt.Errorf("xlsx source: column count mismatch at row %d. Expected %d, got %d", s.row, len(s.header), len(cells))
+		}
+		var attrs map[int]cellAttr
+		if s.native {
+			if attrs, err = s.attrs.cellsOf(s.row); err != nil {
+				return nil, fmt.Errorf("xlsx source: error reading data at row %d: %w", s.row, err)
+			}
+		}
+		r := make(record.Record, len(s.header))
+		for i, columnName := range s.header {
+			if i >= len(cells) || cells[i] == "" {
+				r[columnName] = nil
+				continue
+			}
+			if !s.native {
+				r[columnName] = cells[i]
+				continue
+			}
+			value, err := s.cellValue(s.firstCol+i, cells[i], attrs[s.firstCol+i])
+			if err != nil {
+				return nil, err
+			}
+			r[columnName] = value
+		}
+		return r, nil
+	}
+}
+
+// columnLetters 在没有表头时以列字母作为列名，列数取读取范围的宽度，
+// 未指定范围时流式扫描一遍工作表取最宽的一行。
+func (s *Source) columnLetters() error {
+	lastCol := s.lastCol
+	if lastCol == 0 {
+		rows, err := s.file.Rows(s.sheet)
+		if err != nil {
+			return fmt.Errorf("xlsx source: failed to read sheet '%s': %w", s.sheet, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			cells, err := rows.Columns(excelize.Options{RawCellValue: true})
+			if err != nil {
+				return fmt.Errorf("xlsx source: failed to read sheet '%s': %w", s.sheet, err)
+			}
+			lastCol = max(lastCol, len(cells))
+		}
+	}
+	s.padHeader(lastCol)
+	return nil
+}
+
+// padHeader 用列字母补齐表头，直到 lastCol 列。
+func (s *Source) padHeader(lastCol int) {
+	for col := s.firstCol + len(s.header); col <= lastCol; col++ {
+		columnName, _ := excelize.ColumnNumberToName(col)
+		s.header = append(s.header, columnName)
+	}
+}
+
+// clip 截取读取范围内的列。
+func (s *Source) clip(cells []string) []string {
+	if s.firstCol > 1 {
+		if len(cells) < s.firstCol {
+			return nil
+		}
+		cells = cells[s.firstCol-1:]
+	}
+	if s.lastCol > 0 && len(cells) > s.lastCol-s.firstCol+1 {
+		cells = cells[:s.lastCol-s.firstCol+1]
+	}
+	return cells
+}
+
+// Close 关闭行迭代器与文件，释放资源。
+func (s *Source) Close() error {
+	var err error
+	if s.rows != nil {
+		err = s.rows.Close()
+		s.rows = nil
+	}
+	if s.attrs != nil {
+		if closeErr := s.attrs.Close(); err == nil {
+			err = closeErr
+		}
+		s.attrs = nil
+	}
+	if s.file != nil {
+		if closeErr := s.file.Close(); err == nil {
+			err = closeErr
+		}
+		s.file = nil
+	}
+	return err
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, v := range s.header {
+		columns[v] = v
+	}
+	return columns
+}
+
+// resolveSheet 按名称或从 1 开始的序号查找工作表，名称优先。
+func resolveSheet(file *excelize.File, sheet string) (string, error) {
+	list := file.GetSheetList()
+	if len(list) == 0 {
+		return "", fmt.Errorf("xlsx source: workbook has no sheets")
+	}
+	sheet = strings.TrimSpace(sheet)
+	if sheet == "" {
+		return list[0], nil
+	}
+	for _, v := range list {
+		if v == sheet {
+			return v, nil
+		}
+	}
+	if index, err := strconv.Atoi(sheet); err == nil {
+		if index < 1 || index > len(list) {
+			return "", fmt.Errorf("xlsx source: sheet index %d out of range, workbook has %d sheets", index, len(list))
+		}
+		return list[index-1], nil
+	}
+	return "", fmt.Errorf("xlsx source: sheet '%s' not found, available sheets: %s", sheet, strings.Join(list, ", "))
+}
+
+// parseRange 解析 B2:F100 形式的单元格范围或 B:F 形式的列范围。
+func parseRange(value string) (firstCol, firstRow, lastCol, lastRow int, err error) {
+	from, to, ok := strings.Cut(strings.ToUpper(value), ":")
+	if !ok {
+		return 0, 0, 0, 0, fmt.Errorf("expected <from>:<to>")
+	}
+	if firstCol, firstRow, err = parseRangeEdge(from); err != nil {
+		return
+	}
+	if lastCol, lastRow, err = parseRangeEdge(to); err != nil {
+		return
+	}
+	if firstRow == 0 {
+		firstRow = 1
+	}
+	if lastCol < firstCol || (lastRow > 0 && lastRow < firstRow) {
+		err = fmt.Errorf("range end is before range start")
+	}
+	return
+}
+
+func parseRangeEdge(edge string) (col, row int, err error) {
+	edge = strings.TrimSpace(edge)
+	if strings.IndexFunc(edge, func(r rune) bool { return r >= '0' && r <= '9' }) < 0 {
+		col, err = excelize.ColumnNameToNumber(edge)
+		return col, 0, err
+	}
+	return excelize.CellNameToCoordinates(edge)
+}
+
+func isEmptyRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,12 @@
+module github.com/BernardSimon/etl-go/components/sources/xml
+
+replace github.com/BernardSimon/etl-go/etl/core => ../../../etl/core
+
+go 1.24.4
+
+require (
+	github.com/BernardSimon/etl-go/etl/core v0.0.0-00010101000000-000000000000
+	golang.org/x/text v0.32.0
+)
+
+require github.com/klauspost/compress v1.17.9 // indirect
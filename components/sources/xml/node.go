@@ -0,0 +1,175 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// node 是记录元素的子树，只在解析单条记录时保存在内存中。
+type node struct {
+	name     string
+	attrs    []xml.Attr
+	children []*node
+	text     strings.Builder
+}
+
+// readNode 从 start 开始读取一个完整的元素，直到与之对应的结束标签。
+func readNode(decoder *xml.Decoder, start xml.StartElement) (*node, error) {
+	n := &node{name: start.Name.Local, attrs: start.Attr}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := readNode(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		case xml.CharData:
+			n.text.Write(t)
+		case xml.EndElement:
+			return n, nil
+		}
+	}
+}
+
+func (n *node) value() string {
+	return strings.TrimSpace(n.text.String())
+}
+
+func (n *node) attr(name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// find 按相对路径查找第一个匹配的值：child/grandchild 取元素文本，@name 取属性，. 取元素自身的文本。
+func (n *node) find(path []string) (any, bool) {
+	current := n
+	for i, segment := range path {
+		switch {
+		case segment == ".":
+			continue
+		case strings.HasPrefix(segment, "@"):
+			if i != len(path)-1 {
+				return nil, false
+			}
+			return current.attr(segment[1:])
+		default:
+			var next *node
+			for _, child := range current.children {
+				if segment == "*" || child.name == segment {
+					next = child
+					break
+				}
+			}
+			if next == nil {
+				return nil, false
+			}
+			current = next
+		}
+	}
+	return current.value(), true
+}
+
+// flatten 将记录元素的属性与子元素展开为列：属性与只含文本的子元素以名称为列名，
+// 嵌套元素的列名以 separator 连接父元素名，重复出现的同名元素合并为数组。
+func (n *node) flatten(separator string) map[string]any {
+	out := make(map[string]any)
+	n.flattenInto(out, "", separator)
+	return out
+}
+
+func (n *node) flattenInto(out map[string]any, prefix string, separator string) {
+	for _, a := range n.attrs {
+		if !isNamespace(a) {
+			out[prefix+a.Name.Local] = a.Value
+		}
+	}
+	for _, child := range n.children {
+		if len(child.children) == 0 && len(child.attrs) == 0 {
+			add(out, prefix+child.name, child.value())
+			continue
+		}
+		if len(child.children) == 0 {
+			// 带属性的叶子元素：属性展开为 child.attr，文本作为 child 列
+			add(out, prefix+child.name, child.value())
+			for _, a := range child.attrs {
+				if !isNamespace(a) {
+					out[prefix+child.name+separator+a.Name.Local] = a.Value
+				}
+			}
+			continue
+		}
+		child.flattenInto(out, prefix+child.name+separator, separator)
+	}
+}
+
+// isNamespace 判断属性是否为命名空间声明。
+func isNamespace(a xml.Attr) bool {
+	return a.Name.Space == "xmlns" || a.Name.Local == "xmlns"
+}
+
+// add 写入一列，同名的列再次出现时合并为数组。
+func add(out map[string]any, key string, value string) {
+	existing, ok := out[key]
+	if !ok {
+		out[key] = value
+		return
+	}
+	if list, ok := existing.([]any); ok {
+		out[key] = append(list, value)
+		return
+	}
+	out[key] = []any{existing, value}
+}
+
+// recordPath 是记录元素的位置，descendant 为 true 时只要求元素栈的末尾与 segments 匹配。
+type recordPath struct {
+	segments   []string
+	descendant bool
+}
+
+// parseRecordPath 解析 /root/items/item 或 //item 形式的路径，* 匹配任意元素名。
+func parseRecordPath(path string) (recordPath, error) {
+	path = strings.TrimSpace(path)
+	var p recordPath
+	switch {
+	case strings.HasPrefix(path, "//"):
+		p.descendant = true
+		path = path[2:]
+	case strings.HasPrefix(path, "/"):
+		path = path[1:]
+	default:
+		p.descendant = true
+	}
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || strings.ContainsAny(segment, "[]@()") {
+			return p, fmt.Errorf("invalid 'record_path' %q, expected /root/items/item or //item", path)
+		}
+		p.segments = append(p.segments, segment)
+	}
+	return p, nil
+}
+
+// match 判断元素栈是否指向记录元素。
+func (p recordPath) match(stack []string) bool {
+	if len(stack) < len(p.segments) || (!p.descendant && len(stack) != len(p.segments)) {
+		return false
+	}
+	offset := len(stack) - len(p.segments)
+	for i, segment := range p.segments {
+		if segment != "*" && segment != stack[offset+i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/BernardSimon/etl-go/etl/core/files"
+	"golang.org/x/text/transform"
+)
+
+// reader 在一个文件中按元素栈定位记录元素，每次只把一条记录的子树读入内存。
+type reader struct {
+	stream  *files.Stream
+	decoder *xml.Decoder
+	path    recordPath
+	stack   []string
+}
+
+func openReader(stream *files.Stream, path recordPath) *reader {
+	decoder := xml.NewDecoder(files.Decode(stream, nil, true))
+	// 按文档声明的编码（如 GBK）转换为 UTF-8
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		enc, err := files.LookupEncoding(label, true)
+		if err != nil {
+			return nil, err
+		}
+		if enc == nil {
+			return input, nil
+		}
+		return transform.NewReader(input, enc.NewDecoder()), nil
+	}
+	return &reader{stream: stream, decoder: decoder, path: path}
+}
+
+// next 返回下一条记录元素，文件读完时返回 io.EOF。
+func (r *reader) next() (*node, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			if len(r.stack) > 0 {
+				return nil, r.errorf("unexpected end of file, element <%s> is not closed", r.stack[len(r.stack)-1])
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, r.errorf("%w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			r.stack = append(r.stack, t.Name.Local)
+			if !r.path.match(r.stack) {
+				continue
+			}
+			r.stack = r.stack[:len(r.stack)-1]
+			n, err := readNode(r.decoder, t)
+			if err != nil {
+				return nil, r.errorf("%w", err)
+			}
+			return n, nil
+		case xml.EndElement:
+			r.stack = r.stack[:len(r.stack)-1]
+		}
+	}
+}
+
+// errorf 生成带文件名与行号的错误。
+func (r *reader) errorf(format string, args ...any) error {
+	line, _ := r.decoder.InputPos()
+	return fmt.Errorf("xml source: %s line %d: %w", r.stream.Name, line, fmt.Errorf(format, args...))
+}
+
+func (r *reader) close() error {
+	return r.stream.Close()
+}
@@ -0,0 +1,268 @@
+package xml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/files"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/source"
+)
+
+var name = "xml"
+
+func SetCustomName(customName string) {
+	name = customName
+}
+
+// Source 实现了 core.Source 接口，用于从 XML 文件读取数据。
+//
+// 文件通过 encoding/xml 的 token 流式解码，record_path 选中的每个重复元素是一条记录，
+// 同一时刻只有一条记录的子树保存在内存中，因此可以处理 G 字节级别的文件。
+// 列可以由 columns 按路径指定，也可以由记录元素的属性与子元素自动展开得到。
+// 元素按本地名称匹配，命名空间前缀被忽略。
+type Source struct {
+	inputs     []files.Input
+	files      *files.Reader
+	reader     *reader // 当前文件的 reader
+	path       recordPath
+	mapping    []column // 指定的列与路径，为空时自动展开
+	separator  string
+	keys       []string
+	sourceFile bool
+}
+
+// column 是一个输出列及其相对记录元素的路径。
+type column struct {
+	name string
+	path []string
+}
+
+func SourceCreator() (string, source.Source, *string, []params.Params) {
+	paramList := []params.Params{
+		{
+			Key:          "file_id",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "The file_id to the XML file",
+		},
+		{
+			Key:          "file_ids",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "Comma separated file_ids read in order as one stream, used instead of file_id",
+		},
+		{
+			Key:          "record_path",
+			DefaultValue: "",
+			Required:     true,
+			Description:  "path of the repeating record element: /root/items/item from the document root, //item at any depth, * matches any name",
+		},
+		{
+			Key:          "columns",
+			DefaultValue: "",
+			Required:     false,
+			Description:  "comma separated column=path relative to the record element: child/grandchild for text, @attr and child/@attr for attributes, . for the record text; empty flattens all attributes and child elements",
+		},
+		{
+			Key:          "flatten_separator",
+			DefaultValue: ".",
+			Required:     false,
+			Description:  "separator between parent and child names of flattened columns",
+		},
+		{
+			Key:          "keys_sample_rows",
+			DefaultValue: "0",
+			Required:     false,
+			Description:  "Number of records scanned for determining flattened columns, 0 scans all records",
+		},
+		{
+			Key:          "include_source_file",
+			DefaultValue: "false",
+			Required:     false,
+			Description:  "Add a _source_file column with the name of the file each record was read from",
+		},
+	}
+	return name, &Source{}, nil, paramList
+}
+
+// Open 解析配置并准备读取。没有指定 columns 时先流式扫描记录以得到展开后所有列的并集。
+func (s *Source) Open(config map[string]string, dataSource *datasource.Datasource) error {
+	var err error
+	s.inputs, err = files.Inputs(config)
+	if err != nil {
+		return fmt.Errorf("xml source: %w", err)
+	}
+	if s.path, err = parseRecordPath(config["record_path"]); err != nil {
+		return fmt.Errorf("xml source: %w", err)
+	}
+	s.separator = config["flatten_separator"]
+	if s.separator == "" {
+		s.separator = "."
+	}
+	s.sourceFile = strings.EqualFold(strings.TrimSpace(config["include_source_file"]), "true")
+	if s.mapping, err = parseColumns(config["columns"]); err != nil {
+		return fmt.Errorf("xml source: %w", err)
+	}
+
+	s.keys = nil
+	if s.mapping != nil {
+		for _, c := range s.mapping {
+			s.keys = append(s.keys, c.name)
+		}
+	} else {
+		keysSampleRows := 0
+		if parsed, err := strconv.Atoi(strings.TrimSpace(config["keys_sample_rows"])); err == nil && parsed >= 0 {
+			keysSampleRows = parsed
+		}
+		if err := s.scanKeys(keysSampleRows); err != nil {
+			return err
+		}
+	}
+	if s.sourceFile {
+		for _, key := range s.keys {
+			if key == files.SourceFileColumn {
+				return fmt.Errorf("xml source: column '%s' conflicts with the source file column", files.SourceFileColumn)
+			}
+		}
+		s.keys = append(s.keys, files.SourceFileColumn)
+	}
+	s.files = files.NewReader(s.inputs)
+	s.reader = nil
+	return nil
+}
+
+// parseColumns 解析 column=path 形式的列定义。
+func parseColumns(spec string) ([]column, error) {
+	var columns []column
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		columnName, path, ok := strings.Cut(item, "=")
+		columnName, path = strings.TrimSpace(columnName), strings.TrimSpace(path)
+		if !ok || columnName == "" || path == "" {
+			return nil, fmt.Errorf("invalid column %q, expected column=path", item)
+		}
+		if seen[columnName] {
+			return nil, fmt.Errorf("duplicate column '%s'", columnName)
+		}
+		seen[columnName] = true
+		columns = append(columns, column{name: columnName, path: strings.Split(strings.Trim(path, "/"), "/")})
+	}
+	return columns, nil
+}
+
+// scanKeys 流式读取记录并按首次出现的顺序收集展开后的列，limit 大于 0 时只扫描前 limit 条记录。
+func (s *Source) scanKeys(limit int) error {
+	inputs := files.NewReader(s.inputs)
+	defer inputs.Close()
+	seen := make(map[string]bool)
+	rows := 0
+	for limit <= 0 || rows < limit {
+		stream, err := inputs.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("xml source: %w", err)
+		}
+		r := openReader(stream, s.path)
+		for ; limit <= 0 || rows < limit; rows++ {
+			n, err := r.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				_ = r.close()
+				return err
+			}
+			for key := range n.flatten(s.separator) {
+				if !seen[key] {
+					seen[key] = true
+					s.keys = append(s.keys, key)
+				}
+			}
+		}
+		if err := r.close(); err != nil {
+			return fmt.Errorf("xml source: failed to close %s: %w", stream.Name, err)
+		}
+	}
+	return nil
+}
+
+// Read 读取下一条记录，当前文件读完时自动切换到下一个文件，所有文件读完时返回 io.EOF。
+func (s *Source) Read() (record.Record, error) {
+	for {
+		if s.reader == nil {
+			stream, err := s.files.Next()
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			if err != nil {
+				return nil, fmt.Errorf("xml source: %w", err)
+			}
+			s.reader = openReader(stream, s.path)
+		}
+		n, err := s.reader.next()
+		if err == io.EOF {
+			err = s.reader.close()
+			s.reader = nil
+			if err != nil {
+				return nil, fmt.Errorf("xml source: failed to close file: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var r record.Record
+		if s.mapping != nil {
+			r = make(record.Record, len(s.mapping)+1)
+			for _, c := range s.mapping {
+				if value, ok := n.find(c.path); ok {
+					r[c.name] = value
+				} else {
+					r[c.name] = nil
+				}
+			}
+		} else {
+			r = n.flatten(s.separator)
+		}
+		if s.sourceFile {
+			r[files.SourceFileColumn] = s.reader.stream.Name
+		}
+		return r, nil
+	}
+}
+
+// Close 关闭文件句柄，释放资源。
+func (s *Source) Close() error {
+	var err error
+	if s.reader != nil {
+		err = s.reader.close()
+		s.reader = nil
+	}
+	if s.files != nil {
+		err = errors.Join(err, s.files.Close())
+		s.files = nil
+	}
+	return err
+}
+
+// Column 返回源数据的列映射关系
+func (s *Source) Column() map[string]string {
+	columns := make(map[string]string)
+	for _, v := range s.keys {
+		columns[v] = v
+	}
+	return columns
+}
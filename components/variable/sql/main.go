@@ -19,10 +19,12 @@ func SetCustomNameMysql(customName string, datasourceName string) {
 }
 
 type Variable struct {
+	placeholder func(n int) string // 绑定其他变量时使用的位置占位符
+	backslash   bool               // 字符串字面量中的反斜杠是否为转义符，只有 MySQL 如此
 }
 
 func VariableCreatorMysql() (string, variable.Variable, *string, []params.Params) {
-	return mysqlName, &Variable{}, &mysqlDatasourceName, []params.Params{
+	return mysqlName, &Variable{placeholder: params.QuestionPlaceholder, backslash: true}, &mysqlDatasourceName, []params.Params{
 		{
 			Key:          "query",
 			Required:     true,
@@ -40,7 +42,7 @@ func SetCustomNamePostgre(customName string, datasourceName string) {
 }
 
 func VariableCreatorPostgre() (string, variable.Variable, *string, []params.Params) {
-	return postgreName, &Variable{}, &postgreDatasourceName, []params.Params{
+	return postgreName, &Variable{placeholder: params.DollarPlaceholder}, &postgreDatasourceName, []params.Params{
 		{
 			Key:          "query",
 			Required:     true,
@@ -57,7 +59,7 @@ func SetCustomNameSqlite(customName string, datasourceName string) {
 	sqliteDatasourceName = datasourceName
 }
 func VariableCreatorSqlite() (string, variable.Variable, *string, []params.Params) {
-	return sqliteName, &Variable{}, &sqliteDatasourceName, []params.Params{
+	return sqliteName, &Variable{placeholder: params.QuestionPlaceholder}, &sqliteDatasourceName, []params.Params{
 		{
 			Key:          "query",
 			Required:     true,
@@ -75,10 +77,14 @@ func (s *Variable) Get(config map[string]string, datasource *datasource.Datasour
 	if err != nil {
 		return "", err
 	}
+	query, args, err := params.BindNamed(query, config, 0, s.placeholder, s.backslash)
+	if err != nil {
+		return "", err
+	}
 	db := (*datasource).Open().(*sql.DB)
 	defer (*datasource).Close()
 	var result string
-	err = db.QueryRow(query).Scan(&result)
+	err = db.QueryRow(query, args...).Scan(&result)
 	if err != nil {
 		err := (*datasource).Close()
 		return "", err
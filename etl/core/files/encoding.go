@@ -0,0 +1,63 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// LookupEncoding 返回文件编码对应的解码器，utf-8 返回 nil。
+// stripBOM 时 utf-16 按 BOM 判断字节序，没有 BOM 时按指定的字节序（默认小端）处理；其余名称按 WHATWG 编码标签查找。
+func LookupEncoding(name string, stripBOM bool) (encoding.Encoding, error) {
+	bom := unicode.IgnoreBOM
+	if stripBOM {
+		bom = unicode.UseBOM
+	}
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-")) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	case "gbk", "cp936":
+		return simplifiedchinese.GBK, nil
+	case "gb18030":
+		return simplifiedchinese.GB18030, nil
+	case "utf-16", "utf16", "utf-16le", "utf16le":
+		return unicode.UTF16(unicode.LittleEndian, bom), nil
+	case "utf-16be", "utf16be":
+		return unicode.UTF16(unicode.BigEndian, bom), nil
+	case "latin-1", "latin1", "iso-8859-1":
+		return charmap.ISO8859_1, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported 'encoding' %q", name)
+	}
+	return enc, nil
+}
+
+// Decode 将文件内容转换为 UTF-8。
+// stripBOM 时去掉文件开头的 UTF-8 BOM，声明为 GBK 等编码的文件也是如此，因为部分工具会在非 UTF-8 文件前写入它；
+// UTF-16 的 BOM 由解码器处理。
+func Decode(r io.Reader, enc encoding.Encoding, stripBOM bool) io.Reader {
+	if stripBOM {
+		buffered := bufio.NewReader(r)
+		if head, _ := buffered.Peek(len(utf8BOM)); bytes.Equal(head, utf8BOM) {
+			_, _ = buffered.Discard(len(utf8BOM))
+		}
+		r = buffered
+	}
+	if enc == nil {
+		return r
+	}
+	return transform.NewReader(r, enc.NewDecoder())
+}
@@ -0,0 +1,202 @@
+// Package files 为基于上传文件的数据源提供统一的输入：
+// 按顺序读取多个文件，并透明地解压 gzip、zstd 压缩文件与 zip 压缩包中的文件。
+package files
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// SourceFileColumn 是记录来源文件名的列名。
+const SourceFileColumn = "_source_file"
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zstdMagic     = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+)
+
+// Input 是一个待读取的文件。
+type Input struct {
+	Path string // 文件在磁盘上的路径
+	Name string // 上传时的文件名，用于来源列与错误提示
+}
+
+// Inputs 从配置中取出输入文件：file_paths（逗号分隔）优先，其次 file_path。
+// file_names 是引擎写入的 JSON 数组，按顺序给出每个文件上传时的名称，缺失时使用路径中的文件名。
+func Inputs(config map[string]string) ([]Input, error) {
+	var paths []string
+	if v := strings.TrimSpace(config["file_paths"]); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	} else if v := strings.TrimSpace(config["file_path"]); v != "" {
+		paths = []string{v}
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("config is missing required key 'file_path' or 'file_paths'")
+	}
+	var names []string
+	if v := config["file_names"]; v != "" {
+		_ = json.Unmarshal([]byte(v), &names)
+	}
+	inputs := make([]Input, len(paths))
+	for i, p := range paths {
+		inputs[i] = Input{Path: p, Name: filepath.Base(p)}
+		if i < len(names) && names[i] != "" {
+			inputs[i].Name = names[i]
+		}
+	}
+	return inputs, nil
+}
+
+// Stream 是一个已打开的输入流：一个普通文件、一个解压后的文件或 zip 压缩包中的一个文件。
+type Stream struct {
+	io.Reader
+	Name    string // 来源文件名，zip 中的文件为 压缩包名/文件路径
+	closers []io.Closer
+}
+
+// Close 关闭解压器与文件句柄。
+func (s *Stream) Close() error {
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.closers = nil
+	return errors.Join(errs...)
+}
+
+// Reader 按顺序打开输入文件中的每个流，zip 压缩包中的每个文件依次作为一个流。
+type Reader struct {
+	inputs  []Input
+	index   int // 下一个待打开的输入
+	archive *zip.ReadCloser
+	name    string // 当前压缩包的名称
+	entries []*zip.File
+	entry   int // 压缩包中下一个待打开的文件
+}
+
+// NewReader 创建按顺序读取 inputs 的 Reader。
+func NewReader(inputs []Input) *Reader {
+	return &Reader{inputs: inputs}
+}
+
+// Next 打开下一个流，所有输入读完时返回 io.EOF。返回的流由调用方关闭。
+func (r *Reader) Next() (*Stream, error) {
+	for {
+		if r.archive != nil {
+			if r.entry < len(r.entries) {
+				entry := r.entries[r.entry]
+				r.entry++
+				return r.openEntry(entry)
+			}
+			err := r.archive.Close()
+			r.archive, r.entries = nil, nil
+			if err != nil {
+				return nil, fmt.Errorf("failed to close %s: %w", r.name, err)
+			}
+		}
+		if r.index >= len(r.inputs) {
+			return nil, io.EOF
+		}
+		input := r.inputs[r.index]
+		r.index++
+
+		file, err := os.Open(input.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file %s: %w", input.Name, err)
+		}
+		buffered := bufio.NewReader(file)
+		magic, _ := buffered.Peek(4)
+		if bytes.HasPrefix(magic, zipMagic) || bytes.HasPrefix(magic, emptyZipMagic) {
+			// zip 需要随机读取中央目录，改为按路径打开
+			_ = file.Close()
+			if err := r.openArchive(input); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return decompress(&Stream{Reader: buffered, Name: input.Name, closers: []io.Closer{file}})
+	}
+}
+
+// openArchive 打开 zip 压缩包并列出其中的文件，目录与 macOS 生成的元数据文件被忽略。
+func (r *Reader) openArchive(input Input) error {
+	archive, err := zip.OpenReader(input.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive %s: %w", input.Name, err)
+	}
+	r.archive, r.name, r.entries, r.entry = archive, input.Name, nil, 0
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(filepath.Base(entry.Name), "._") {
+			continue
+		}
+		r.entries = append(r.entries, entry)
+	}
+	return nil
+}
+
+func (r *Reader) openEntry(entry *zip.File) (*Stream, error) {
+	name := r.name + "/" + entry.Name
+	content, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	return decompress(&Stream{Reader: bufio.NewReader(content), Name: name, closers: []io.Closer{content}})
+}
+
+// Close 关闭尚未读完的压缩包。
+func (r *Reader) Close() error {
+	r.index = len(r.inputs)
+	if r.archive != nil {
+		err := r.archive.Close()
+		r.archive, r.entries = nil, nil
+		return err
+	}
+	return nil
+}
+
+// decompress 根据文件头识别 gzip 与 zstd 压缩，并将流替换为解压后的内容。
+func decompress(s *Stream) (*Stream, error) {
+	buffered := s.Reader.(*bufio.Reader)
+	magic, _ := buffered.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("failed to read gzip file %s: %w", s.Name, err)
+		}
+		s.Reader = gz
+		s.closers = append(s.closers, gz)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(buffered)
+		if err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("failed to read zstd file %s: %w", s.Name, err)
+		}
+		s.Reader = zr
+		s.closers = append(s.closers, zr.IOReadCloser())
+	case bytes.HasPrefix(magic, zipMagic):
+		_ = s.Close()
+		return nil, fmt.Errorf("nested zip archive %s is not supported", s.Name)
+	}
+	return s, nil
+}
@@ -0,0 +1,453 @@
+package files
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+)
+
+// OutputParams 是文件输出类 Sink 共用的滚动、分区与打包参数。
+var OutputParams = []params.Params{
+	{
+		Key:          "max_rows",
+		Description:  "start a new file after this many rows, 0 disables row based rollover",
+		DefaultValue: "0",
+		Required:     false,
+	},
+	{
+		Key:          "max_bytes",
+		Description:  "start a new file once a file reaches this uncompressed size, such as 512MB, 0 disables size based rollover",
+		DefaultValue: "0",
+		Required:     false,
+	},
+	{
+		Key:          "partition_columns",
+		Description:  "comma separated pipeline columns, rows are written to one file per combination of their values, named like name_region=east",
+		DefaultValue: "",
+		Required:     false,
+	},
+	{
+		Key:          "max_open_files",
+		Description:  "maximum number of partition files kept open at once, the least recently written one is closed and later rows of its partition go to a new _partNNNN file, 0 means no limit",
+		DefaultValue: "64",
+		Required:     false,
+	},
+	{
+		Key:          "compression",
+		Description:  "none or gzip, gzip compresses every file and appends .gz to its extension",
+		DefaultValue: "none",
+		Required:     false,
+	},
+	{
+		Key:          "package",
+		Description:  "none or zip, zip packs all files of the run into one zip archive",
+		DefaultValue: "none",
+		Required:     false,
+	},
+}
+
+// Encoder 将记录按文件格式写入一个输出文件。
+type Encoder interface {
+	Encode(r record.Record) error
+	// Close 写入文件结尾（如 JSON 数组的结束符号），不关闭底层文件
+	Close() error
+}
+
+// Output 管理文件输出类 Sink 的输出文件：按行数或大小滚动，按列值分区，可压缩并打包为 zip。
+// 第一个文件使用 file_name 对应的 file_path，其余文件通过 create 创建；
+// 同一分区的第 n 个文件（n > 1）的名称带有 _partNNNN 后缀。
+type Output struct {
+	name       string                                        // 不含扩展名的文件名
+	ext        string                                        // 扩展名，不含开头的点
+	basePath   string                                        // file_name 对应的路径
+	create     func(name string, ext string) (string, error) // 创建并登记新的输出文件
+	newEncoder func(w io.Writer) (Encoder, error)
+	maxRows    int64
+	maxBytes   int64
+	maxOpen    int               // 同时打开的文件数上限，0 表示不限
+	partition  []string          // 分区列的记录键
+	columns    map[string]string // 记录键 -> 输出列名，用于分区文件名
+	gzip       bool
+	zip        bool
+	open       map[string]*outputFile // 分区 -> 正在写入的文件
+	order      []string               // 分区出现的顺序
+	parts      map[string]int         // 分区 -> 已创建的文件数
+	files      []outputPath           // 已创建的全部文件
+	baseUsed   bool
+	writes     int64 // 已写入的记录数，用于找出最久未写入的文件
+}
+
+type outputFile struct {
+	file    *os.File
+	buf     *bufio.Writer
+	gz      *gzip.Writer
+	counter *countingWriter
+	enc     Encoder
+	rows    int64
+	used    int64 // 最近一次写入时 Output.writes 的值
+}
+
+type outputPath struct {
+	path string
+	name string // 文件名与扩展名，用于 zip 中的条目名
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewOutput 按配置创建输出。columns 为管道的列映射，create 为 nil 时新文件与 file_path 放在同一目录。
+func NewOutput(config map[string]string, columns map[string]string, create func(name string, ext string) (string, error), newEncoder func(w io.Writer) (Encoder, error)) (*Output, error) {
+	o := &Output{
+		name:       strings.TrimSpace(config["file_name"]),
+		ext:        strings.TrimPrefix(strings.TrimSpace(config["file_ext"]), "."),
+		basePath:   config["file_path"],
+		create:     create,
+		newEncoder: newEncoder,
+		columns:    columns,
+		open:       make(map[string]*outputFile),
+		parts:      make(map[string]int),
+	}
+	if o.basePath == "" {
+		return nil, fmt.Errorf("config is missing or has invalid 'file_name'")
+	}
+	if o.name == "" {
+		o.name = strings.TrimSuffix(filepath.Base(o.basePath), filepath.Ext(o.basePath))
+	}
+	o.name = strings.TrimSuffix(o.name, "."+o.ext)
+
+	var err error
+	if o.maxRows, err = parseCount(config["max_rows"]); err != nil {
+		return nil, fmt.Errorf("invalid 'max_rows': %w", err)
+	}
+	if o.maxBytes, err = parseSize(config["max_bytes"]); err != nil {
+		return nil, fmt.Errorf("invalid 'max_bytes': %w", err)
+	}
+	maxOpen := int64(64)
+	if v := strings.TrimSpace(config["max_open_files"]); v != "" {
+		if maxOpen, err = parseCount(v); err != nil {
+			return nil, fmt.Errorf("invalid 'max_open_files': %w", err)
+		}
+	}
+	o.maxOpen = int(maxOpen)
+	for _, key := range strings.Split(config["partition_columns"], ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if _, ok := columns[key]; !ok {
+			return nil, fmt.Errorf("column '%s' in 'partition_columns' is not a pipeline column", key)
+		}
+		o.partition = append(o.partition, key)
+	}
+	switch strings.ToLower(strings.TrimSpace(config["compression"])) {
+	case "", "none":
+	case "gzip", "gz":
+		o.gzip = true
+	default:
+		return nil, fmt.Errorf("unsupported 'compression' %q, expected none or gzip", config["compression"])
+	}
+	switch strings.ToLower(strings.TrimSpace(config["package"])) {
+	case "", "none":
+	case "zip":
+		o.zip = true
+	default:
+		return nil, fmt.Errorf("unsupported 'package' %q, expected none or zip", config["package"])
+	}
+	return o, nil
+}
+
+// Write 将一条记录写入所属分区的文件，文件达到行数或大小上限后先切换到新文件。
+// 打开的文件数达到 max_open_files 时关闭最久未写入的文件，该分区之后的记录写入新的 _partNNNN 文件。
+func (o *Output) Write(r record.Record) error {
+	key, suffix := o.partitionOf(r)
+	f := o.open[key]
+	if f != nil && ((o.maxRows > 0 && f.rows >= o.maxRows) || (o.maxBytes > 0 && f.counter.n >= o.maxBytes)) {
+		delete(o.open, key)
+		if err := f.close(); err != nil {
+			return err
+		}
+		f = nil
+	}
+	if f == nil {
+		if o.maxOpen > 0 && len(o.open) >= o.maxOpen {
+			if err := o.closeLeastRecent(); err != nil {
+				return err
+			}
+		}
+		var err error
+		if f, err = o.next(key, suffix); err != nil {
+			return err
+		}
+	}
+	if err := f.enc.Encode(r); err != nil {
+		return err
+	}
+	f.rows++
+	o.writes++
+	f.used = o.writes
+	return nil
+}
+
+// closeLeastRecent 关闭最久未写入的文件。
+func (o *Output) closeLeastRecent() error {
+	var oldest string
+	var f *outputFile
+	for key, v := range o.open {
+		if f == nil || v.used < f.used {
+			oldest, f = key, v
+		}
+	}
+	if f == nil {
+		return nil
+	}
+	delete(o.open, oldest)
+	return f.close()
+}
+
+// Flush 将缓冲的内容写入磁盘，每个批次之后调用。
+func (o *Output) Flush() error {
+	for _, key := range o.order {
+		if f := o.open[key]; f != nil {
+			if err := f.buf.Flush(); err != nil {
+				return fmt.Errorf("failed to flush file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close 关闭全部文件。没有分区且没有写入任何记录时仍生成一个只有文件头的文件；开启 zip 时将全部文件打包并删除原文件。
+func (o *Output) Close() error {
+	var errs []error
+	for _, key := range o.order {
+		if f := o.open[key]; f != nil {
+			delete(o.open, key)
+			if err := f.close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(o.files) == 0 && len(o.partition) == 0 && len(errs) == 0 {
+		f, err := o.next("", "")
+		if err == nil {
+			err = f.close()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if o.zip && len(errs) == 0 {
+		if err := o.pack(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// partitionOf 返回记录所属的分区与分区在文件名中的后缀。
+func (o *Output) partitionOf(r record.Record) (string, string) {
+	if len(o.partition) == 0 {
+		return "", ""
+	}
+	var key, suffix strings.Builder
+	for _, column := range o.partition {
+		value := "null"
+		if v := r[column]; v != nil {
+			// 日期按 2006-01-02 输出，而不是 time.Time 默认的带时区格式
+			value = record.Format(v)
+		}
+		key.WriteString(value)
+		key.WriteByte(0)
+		suffix.WriteString("_" + fileNamePart(o.columns[column]) + "=" + fileNamePart(value))
+	}
+	return key.String(), suffix.String()
+}
+
+// next 为分区创建下一个文件。
+func (o *Output) next(key string, suffix string) (*outputFile, error) {
+	o.parts[key]++
+	name := o.name + suffix
+	if n := o.parts[key]; n > 1 {
+		name += fmt.Sprintf("_part%04d", n)
+	}
+	ext := o.ext
+	if o.gzip {
+		ext += ".gz"
+	}
+
+	var path string
+	var err error
+	switch {
+	case key == "" && !o.baseUsed && !o.gzip:
+		// file_name 对应的文件由引擎登记，扩展名与配置一致时直接使用
+		path = o.basePath
+		o.baseUsed = true
+	case o.create != nil:
+		if path, err = o.create(name, ext); err != nil {
+			return nil, fmt.Errorf("failed to create output file %s.%s: %w", name, ext, err)
+		}
+	default:
+		path = filepath.Join(filepath.Dir(o.basePath), name+"."+ext)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create/open file: %w", err)
+	}
+	f := &outputFile{file: file}
+	var w io.Writer = file
+	if o.gzip {
+		f.gz = gzip.NewWriter(file)
+		w = f.gz
+	}
+	f.buf = bufio.NewWriter(w)
+	f.counter = &countingWriter{w: f.buf}
+	if f.enc, err = o.newEncoder(f.counter); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if o.parts[key] == 1 {
+		o.order = append(o.order, key)
+	}
+	o.open[key] = f
+	o.files = append(o.files, outputPath{path: path, name: name + "." + ext})
+	return f, nil
+}
+
+func (f *outputFile) close() error {
+	err := f.enc.Close()
+	if flushErr := f.buf.Flush(); flushErr != nil && err == nil {
+		err = flushErr
+	}
+	if f.gz != nil {
+		if gzErr := f.gz.Close(); gzErr != nil && err == nil {
+			err = gzErr
+		}
+	}
+	if closeErr := f.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to finish file %s: %w", f.file.Name(), err)
+	}
+	return nil
+}
+
+// pack 将全部文件写入一个 zip 压缩包，之后删除原文件；引擎在运行结束时删除已不存在的文件的记录。
+func (o *Output) pack() error {
+	var path string
+	var err error
+	if o.create != nil {
+		if path, err = o.create(o.name, "zip"); err != nil {
+			return fmt.Errorf("failed to create zip file: %w", err)
+		}
+	} else {
+		path = filepath.Join(filepath.Dir(o.basePath), o.name+".zip")
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+	archive := zip.NewWriter(file)
+	for _, f := range o.files {
+		if err = addToZip(archive, f); err != nil {
+			break
+		}
+	}
+	if closeErr := archive.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write zip file: %w", err)
+	}
+	for _, f := range o.files {
+		if err := os.Remove(f.path); err != nil {
+			return fmt.Errorf("failed to remove packed file: %w", err)
+		}
+	}
+	o.files = []outputPath{{path: path, name: o.name + ".zip"}}
+	return nil
+}
+
+func addToZip(archive *zip.Writer, f outputPath) error {
+	src, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	method := zip.Deflate
+	if strings.HasSuffix(f.name, ".gz") {
+		method = zip.Store
+	}
+	dst, err := archive.CreateHeader(&zip.FileHeader{Name: f.name, Method: method, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// fileNamePart 替换文件名中不允许出现的字符。
+func fileNamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func parseCount(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a non-negative integer, got %q", s)
+	}
+	return n, nil
+}
+
+// parseSize 解析 1048576、512KB、64MB、1GB 形式的大小，单位按 1024 进位，空字符串为 0。
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := parseCount(s)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
@@ -1,3 +1,7 @@
 module github.com/BernardSimon/etl-go/etl/core
 
 go 1.24.4
+
+require github.com/klauspost/compress v1.17.9
+
+require golang.org/x/text v0.32.0
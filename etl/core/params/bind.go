@@ -0,0 +1,178 @@
+package params
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindPrefix 是任务变量以驱动参数方式传入组件时使用的配置键前缀。
+// SQL 中的 :start_date 占位符会从配置项 bind.start_date 中取值。
+const BindPrefix = "bind."
+
+// NamedParameter 是 SQL 中的一个命名占位符，形式为 :name 或带类型提示的 :name:type。
+type NamedParameter struct {
+	Name  string
+	Type  string // int、float、bool、date、datetime、string，为空时按字符串绑定
+	start int
+	end   int
+}
+
+// ParseNamedParameters 找出 SQL 中的命名占位符。
+// 字符串字面量、带引号的标识符、注释以及 PostgreSQL 的 :: 类型转换中的内容不会被识别为占位符。
+// backslashEscapes 表示字符串字面量中的反斜杠是否转义下一个字符：MySQL 默认如此，
+// PostgreSQL 与 SQLite 的标准字符串中反斜杠是普通字符，只有 PostgreSQL 的 E'...' 字符串例外。
+func ParseNamedParameters(query string, backslashEscapes bool) []NamedParameter {
+	var result []NamedParameter
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(query, i, c, c == '\'' && (backslashEscapes || isEscapeString(query, i)))
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return result
+			}
+			i += end + 3
+		case c == ':':
+			if i+1 < len(query) && query[i+1] == ':' {
+				// PostgreSQL 类型转换 ::type
+				i++
+				continue
+			}
+			if i > 0 && isNameChar(query[i-1]) {
+				continue
+			}
+			name := readName(query, i+1)
+			if name == "" {
+				continue
+			}
+			p := NamedParameter{Name: name, start: i, end: i + 1 + len(name)}
+			// 单个冒号后紧跟的名称是类型提示，双冒号则保留给数据库做类型转换
+			if p.end+1 < len(query) && query[p.end] == ':' && query[p.end+1] != ':' {
+				if hint := readName(query, p.end+1); hint != "" {
+					p.Type = strings.ToLower(hint)
+					p.end += 1 + len(hint)
+				}
+			}
+			result = append(result, p)
+			i = p.end - 1
+		}
+	}
+	return result
+}
+
+// BindNamed 将 SQL 中的命名占位符替换为驱动的位置占位符，并按类型提示从 config 中取出绑定值。
+// 只有 config 中存在 bind.name 的占位符才会被替换，其余形如 :name 的文本(例如 PostgreSQL 的数组切片 arr[1:n])原样保留。
+// placeholder 根据参数序号(从 offset+1 开始)生成占位符，例如 MySQL 的 ? 或 PostgreSQL 的 $1，backslashEscapes 见 ParseNamedParameters。
+func BindNamed(query string, config map[string]string, offset int, placeholder func(n int) string, backslashEscapes bool) (string, []any, error) {
+	parameters := ParseNamedParameters(query, backslashEscapes)
+	if len(parameters) == 0 {
+		return query, nil, nil
+	}
+	var builder strings.Builder
+	args := make([]any, 0, len(parameters))
+	last := 0
+	for _, p := range parameters {
+		raw, ok := config[BindPrefix+p.Name]
+		if !ok {
+			continue
+		}
+		value, err := ConvertBindValue(raw, p.Type)
+		if err != nil {
+			return "", nil, fmt.Errorf("parameter :%s: %w", p.Name, err)
+		}
+		args = append(args, value)
+		builder.WriteString(query[last:p.start])
+		builder.WriteString(placeholder(offset + len(args)))
+		last = p.end
+	}
+	if len(args) == 0 {
+		return query, nil, nil
+	}
+	builder.WriteString(query[last:])
+	return builder.String(), args, nil
+}
+
+// QuestionPlaceholder 生成 MySQL、SQLite 使用的 ? 占位符。
+func QuestionPlaceholder(int) string {
+	return "?"
+}
+
+// DollarPlaceholder 生成 PostgreSQL 使用的 $n 占位符。
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// ConvertBindValue 按类型提示将变量值转换为驱动参数。
+func ConvertBindValue(value string, hint string) (any, error) {
+	switch hint {
+	case "", "string", "text":
+		return value, nil
+	case "int", "integer":
+		return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case "float", "double", "number":
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case "date":
+		return time.ParseInLocation("2006-01-02", strings.TrimSpace(value), time.Local)
+	case "datetime", "timestamp":
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999", time.RFC3339Nano, "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid datetime value %q", value)
+	default:
+		return nil, fmt.Errorf("unsupported type hint %q", hint)
+	}
+}
+
+func skipQuoted(query string, i int, quote byte, backslashEscapes bool) int {
+	for j := i + 1; j < len(query); j++ {
+		if query[j] == '\\' && backslashEscapes {
+			j++
+			continue
+		}
+		if query[j] == quote {
+			// 连续两个引号是转义
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(query)
+}
+
+// isEscapeString 判断 i 处的引号是否开始一个 PostgreSQL 的 E'...' 字符串。
+func isEscapeString(query string, i int) bool {
+	return i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i == 1 || !isNameChar(query[i-2]))
+}
+
+func readName(query string, from int) string {
+	if from >= len(query) || !(isLetter(query[from]) || query[from] == '_') {
+		return ""
+	}
+	end := from
+	for end < len(query) && isNameChar(query[end]) {
+		end++
+	}
+	return query[from:end]
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isLetter(c) || c == '_' || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,111 @@
+package params
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNamedParameters(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		backslash bool
+		want      []NamedParameter
+	}{
+		{"plain", "SELECT * FROM t WHERE a = :a AND b = :b", false, []NamedParameter{{Name: "a"}, {Name: "b"}}},
+		{"type hint", "WHERE d >= :start:date AND n = :n:INT", false, []NamedParameter{{Name: "start", Type: "date"}, {Name: "n", Type: "int"}}},
+		{"postgres cast", "WHERE a = :a::int AND b::text = 'x'", false, []NamedParameter{{Name: "a"}}},
+		{"mysql string literal", "WHERE a = ':x' AND b = 'it''s :y' AND c = 'a\\':z' AND d = :d", true, []NamedParameter{{Name: "d"}}},
+		{"quoted identifier", "SELECT \":x\", `:y` FROM t WHERE a = :a", false, []NamedParameter{{Name: "a"}}},
+		{"line comment", "SELECT 1 -- :x\nWHERE a = :a", false, []NamedParameter{{Name: "a"}}},
+		{"block comment", "SELECT /* :x */ 1 WHERE a = :a", false, []NamedParameter{{Name: "a"}}},
+		{"unterminated block comment", "SELECT :a /* :x", false, []NamedParameter{{Name: "a"}}},
+		{"array slice", "SELECT arr[1:n], arr[lo:hi] FROM t", false, nil},
+		{"time literal", "WHERE t = '10:30:00'", false, nil},
+		{"not a name", "SELECT :1, : a, :", false, nil},
+		{"mysql assignment", "SELECT @a:=1", false, nil},
+		{"standard string ends at quote after backslash", `WHERE path = 'C:\' AND a = :a`, false, []NamedParameter{{Name: "a"}}},
+		{"backslash escapes quote", `WHERE path = 'C:\' AND a = :a`, true, nil},
+		{"postgres escape string", `WHERE a = E'it\'s :x' AND b = :b`, false, []NamedParameter{{Name: "b"}}},
+		{"standard string with doubled quote", `WHERE a = 'it''s :x\' AND b = :b`, false, []NamedParameter{{Name: "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []NamedParameter
+			for _, p := range ParseNamedParameters(tt.query, tt.backslash) {
+				got = append(got, NamedParameter{Name: p.Name, Type: p.Type})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNamedParameters(%q, %v) = %v, want %v", tt.query, tt.backslash, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindNamed(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		config    map[string]string
+		offset    int
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			name:      "bound",
+			query:     "WHERE a = :a AND n > :n:int",
+			config:    map[string]string{"bind.a": "x", "bind.n": "5"},
+			wantQuery: "WHERE a = $1 AND n > $2",
+			wantArgs:  []any{"x", int64(5)},
+		},
+		{
+			name:      "offset",
+			query:     "WHERE a = :a",
+			config:    map[string]string{"bind.a": "x"},
+			offset:    2,
+			wantQuery: "WHERE a = $3",
+			wantArgs:  []any{"x"},
+		},
+		{
+			name:      "unbound names are kept",
+			query:     "SELECT arr[i:n], :other:int FROM t WHERE a = :a",
+			config:    map[string]string{"bind.a": "x"},
+			wantQuery: "SELECT arr[i:n], :other:int FROM t WHERE a = $1",
+			wantArgs:  []any{"x"},
+		},
+		{
+			name:      "nothing bound",
+			query:     "SELECT arr[:n] FROM t",
+			config:    map[string]string{},
+			wantQuery: "SELECT arr[:n] FROM t",
+		},
+		{
+			name:      "repeated",
+			query:     "WHERE a = :a OR b = :a",
+			config:    map[string]string{"bind.a": "x"},
+			wantQuery: "WHERE a = $1 OR b = $2",
+			wantArgs:  []any{"x", "x"},
+		},
+		{
+			name:      "postgres backslash in string",
+			query:     `WHERE path = 'C:\' AND a = :a`,
+			config:    map[string]string{"bind.a": "x"},
+			wantQuery: `WHERE path = 'C:\' AND a = $1`,
+			wantArgs:  []any{"x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := BindNamed(tt.query, tt.config, tt.offset, DollarPlaceholder, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("BindNamed(%q) = %q, %v, want %q, %v", tt.query, query, args, tt.wantQuery, tt.wantArgs)
+			}
+		})
+	}
+	if _, _, err := BindNamed("WHERE n = :n:int", map[string]string{"bind.n": "x"}, 0, QuestionPlaceholder, true); err == nil {
+		t.Error("BindNamed accepted an invalid int value")
+	}
+}
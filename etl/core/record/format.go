@@ -0,0 +1,24 @@
+package record
+
+import (
+	"fmt"
+	"time"
+)
+
+// Format 将记录中的值格式化为文本，供按字符串比较、转换或哈希值的组件使用。
+// time.Time 在零点时只输出日期，否则输出日期与时间（有小数秒时保留），[]byte 按字符串输出，其余值与 %v 一致。
+func Format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+		return v.Format("2006-01-02 15:04:05.999999999")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,15 @@
+package record
+
+// 以下是组件之间传递列类型时使用的通用类型名，与具体数据库无关。
+// Source 通过 source.Schema 报告列类型，Sink 通过 sink.SchemaAware 接收，并按各自的方言映射为具体类型。
+// 定点数使用 "decimal(精度,小数位)" 的形式，例如 "decimal(10,2)"。
+const (
+	TypeInteger  = "integer"  // int64
+	TypeFloat    = "float"    // float64
+	TypeDecimal  = "decimal"  // 保留精度的字符串
+	TypeBoolean  = "boolean"  // bool
+	TypeDate     = "date"     // time.Time
+	TypeDatetime = "datetime" // time.Time
+	TypeBytes    = "bytes"    // []byte
+	TypeString   = "string"   // string
+)
@@ -13,3 +13,24 @@ type Sink interface {
 	Write(id string, records []record.Record) error
 	Close() error
 }
+
+// SchemaAware 由需要列类型的 Sink 实现。引擎在 Open 之前传入经过处理器后仍保留的列的类型，
+// 类型名见 record 包中的通用类型名，无法确定类型的列不会出现在映射中。
+type SchemaAware interface {
+	SetColumnTypes(types map[string]string)
+}
+
+// Transactional 由需要感知运行结果的 Sink 实现。引擎在 Source 完整读取、所有记录写入且运行没有错误也没有被取消后调用 Commit，
+// 运行失败、被取消或 Commit 失败时调用 Rollback；两者都在 Close 之前调用，每次运行至多提交一次。
+type Transactional interface {
+	Commit() error
+	Rollback() error
+}
+
+// FileOutput 由会在运行中创建多个输出文件的 Sink 实现。引擎在 Open 之前传入 create，
+// 它创建一个名为 name、扩展名为 ext 的输出文件记录并返回文件在磁盘上的路径。
+// 创建的文件与 file_name 生成的文件一样在运行结束后记录大小并关联到任务记录，运行失败时被删除；
+// 运行结束时已不存在的文件只删除记录。
+type FileOutput interface {
+	SetFileCreator(create func(name string, ext string) (path string, err error))
+}
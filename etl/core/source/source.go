@@ -13,3 +13,20 @@ type Source interface {
 	Read() (record.Record, error)
 	Close() error
 }
+
+// Incremental 由支持增量抽取的 Source 实现。
+// 引擎在 Open 之前注入上一次成功运行提交的水位线，并在运行成功后取回本次读取到的最大水位线交由调用方持久化。
+type Incremental interface {
+	SetWatermark(watermark string)
+	Watermark() string
+}
+
+// Progress 由能够报告读取进度的 Source 实现，例如分段并行读取时每个分段已读取的行数，键为计数的描述。
+type Progress interface {
+	Progress() map[string]int64
+}
+
+// Schema 由能够报告列类型的 Source 实现，返回列名到 record 包中通用类型名的映射。
+type Schema interface {
+	ColumnTypes() map[string]string
+}
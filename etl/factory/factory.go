@@ -16,6 +16,7 @@ var (
 	sourceRegistry     = make(map[string]*SourceStore)
 	processorRegistry  = make(map[string]*ProcessorStore)
 	sinkRegistry       = make(map[string]*SinkStore)
+	sinkCreators       = make(map[string]sink.SinkCreator)
 	executorRegistry   = make(map[string]*ExecutorStore)
 	variableRegistry   = make(map[string]*VariableStore)
 	datasourceRegistry = make(map[string]*DatasourceStore)
@@ -69,6 +70,7 @@ func RegisterSink(creator sink.SinkCreator) {
 		ss.Datasource = d
 	}
 	sinkRegistry[n] = &ss
+	sinkCreators[n] = creator
 }
 func RegisterExecutor(creator executor.ExecutorCreator) {
 	n, e, d, p := creator()
@@ -136,12 +138,16 @@ func CreateProcessor(name string) (ProcessorStore, error) {
 	return *store, nil
 }
 
+// CreateSink 每次调用都通过注册的 creator 创建新的 Sink 实例。Sink 在 Open 与 Commit 之间保存事务、暂存表、
+// 输出文件等运行状态，同一类型的 Sink 可能被多个任务同时运行，不能共用一个实例。
 func CreateSink(name string) (SinkStore, error) {
 	store, ok := sinkRegistry[name]
 	if !ok {
 		return SinkStore{}, fmt.Errorf("factory error: no sink registered with name: %s", name)
 	}
-	return *store, nil
+	created := *store
+	_, created.Handle, _, _ = sinkCreators[name]()
+	return created, nil
 }
 
 func CreateExecutor(name string) (ExecutorStore, error) {
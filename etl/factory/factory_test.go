@@ -0,0 +1,45 @@
+package factory
+
+import (
+	"testing"
+
+	"github.com/BernardSimon/etl-go/etl/core/datasource"
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/etl/core/record"
+	"github.com/BernardSimon/etl-go/etl/core/sink"
+)
+
+// stateSink 在 Open 中保存运行状态，用于检查每次创建的实例互不共用。
+type stateSink struct{ table string }
+
+func (s *stateSink) Open(config map[string]string, _ map[string]string, _ *datasource.Datasource) error {
+	s.table = config["table"]
+	return nil
+}
+func (s *stateSink) Write(string, []record.Record) error { return nil }
+func (s *stateSink) Close() error                        { return nil }
+
+func TestCreateSinkReturnsNewInstance(t *testing.T) {
+	RegisterSink(func() (string, sink.Sink, *string, []params.Params) {
+		return "factory_test_state", &stateSink{}, nil, []params.Params{{Key: "table"}}
+	})
+	first, err := CreateSink("factory_test_state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := CreateSink("factory_test_state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Handle == second.Handle {
+		t.Fatal("CreateSink returned the same instance twice")
+	}
+	_ = first.Handle.Open(map[string]string{"table": "a"}, nil, nil)
+	_ = second.Handle.Open(map[string]string{"table": "b"}, nil, nil)
+	if got := first.Handle.(*stateSink).table; got != "a" {
+		t.Errorf("first sink table = %q after opening the second sink, want %q", got, "a")
+	}
+	if first.Name != "factory_test_state" || len(first.Params) != 1 {
+		t.Errorf("CreateSink returned %+v, want the registered name and params", first)
+	}
+}
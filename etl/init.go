@@ -2,6 +2,7 @@ package etl
 
 import (
 	dorisDatasource "github.com/BernardSimon/etl-go/components/datasource/doris"
+	httpDatasource "github.com/BernardSimon/etl-go/components/datasource/http"
 	mysqlDatasource "github.com/BernardSimon/etl-go/components/datasource/mysql"
 	postgreDatasource "github.com/BernardSimon/etl-go/components/datasource/postgre"
 	sqliteDatasource "github.com/BernardSimon/etl-go/components/datasource/sqlite"
@@ -14,10 +15,19 @@ import (
 	csvSink "github.com/BernardSimon/etl-go/components/sinks/csv"
 	dorisSink "github.com/BernardSimon/etl-go/components/sinks/doris"
 	jsonSink "github.com/BernardSimon/etl-go/components/sinks/json"
+	parquetSink "github.com/BernardSimon/etl-go/components/sinks/parquet"
 	sqlSink "github.com/BernardSimon/etl-go/components/sinks/sql"
+	xlsxSink "github.com/BernardSimon/etl-go/components/sinks/xlsx"
 	csvSource "github.com/BernardSimon/etl-go/components/sources/csv"
+	generatorSource "github.com/BernardSimon/etl-go/components/sources/generator"
+	httpSource "github.com/BernardSimon/etl-go/components/sources/http"
 	jsonSource "github.com/BernardSimon/etl-go/components/sources/json"
+	mysqlCdcSource "github.com/BernardSimon/etl-go/components/sources/mysqlCdc"
+	parquetSource "github.com/BernardSimon/etl-go/components/sources/parquet"
 	sqlSource "github.com/BernardSimon/etl-go/components/sources/sql"
+	textSource "github.com/BernardSimon/etl-go/components/sources/text"
+	xlsxSource "github.com/BernardSimon/etl-go/components/sources/xlsx"
+	xmlSource "github.com/BernardSimon/etl-go/components/sources/xml"
 	sqlVariable "github.com/BernardSimon/etl-go/components/variable/sql"
 	"github.com/BernardSimon/etl-go/etl/factory"
 )
@@ -28,6 +38,7 @@ func init() {
 	factory.RegisterDataSource(mysqlDatasource.DatasourceCreator)
 	factory.RegisterDataSource(postgreDatasource.DatasourceCreator)
 	factory.RegisterDataSource(sqliteDatasource.DatasourceCreator)
+	factory.RegisterDataSource(httpDatasource.DatasourceCreator)
 
 	//注册变量执行器
 	factory.RegisterVariable(sqlVariable.VariableCreatorMysql)
@@ -45,6 +56,13 @@ func init() {
 	factory.RegisterSource(csvSource.SourceCreator)
 	factory.RegisterSource(jsonSource.SourceCreator)
 	factory.RegisterSource(sqlSource.SourceCreatorSqlite)
+	factory.RegisterSource(xlsxSource.SourceCreator)
+	factory.RegisterSource(parquetSource.SourceCreator)
+	factory.RegisterSource(httpSource.SourceCreator)
+	factory.RegisterSource(textSource.SourceCreator)
+	factory.RegisterSource(xmlSource.SourceCreator)
+	factory.RegisterSource(generatorSource.SourceCreator)
+	factory.RegisterSource(mysqlCdcSource.SourceCreator)
 
 	//注册数据输出
 	factory.RegisterSink(sqlSink.SinkCreatorMysql)
@@ -53,6 +71,8 @@ func init() {
 	factory.RegisterSink(jsonSink.SinkCreator)
 	factory.RegisterSink(dorisSink.SinkCreator)
 	factory.RegisterSink(sqlSink.SinkCreatorSqlite)
+	factory.RegisterSink(xlsxSink.SinkCreator)
+	factory.RegisterSink(parquetSink.SinkCreator)
 
 	//注册处理器
 	factory.RegisterProcessor(convertTypeProcessor.ProcessorCreator)
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -56,6 +57,8 @@ type Engine struct {
 	afterExecutorDatasource  *datasource.Datasource
 	batchSize                int
 	channelSize              int
+	watermark                string // 增量抽取水位线：运行前为上次提交值，运行成功后为本次读取到的最大值
+	drained                  bool   // Source 是否已读到末尾，只有完整读取的运行才能提交
 	cancel                   context.CancelFunc
 	wg                       sync.WaitGroup
 }
@@ -94,6 +97,16 @@ func NewEngine(id string, beforeExecutor *executor.Executor, beforeExecutorDatas
 	return &engine
 }
 
+// SetWatermark 设置上一次成功运行提交的水位线，仅对实现了 source.Incremental 的 Source 生效。
+func (e *Engine) SetWatermark(watermark string) {
+	e.watermark = watermark
+}
+
+// Watermark 返回增量水位线。Run 成功返回后为本次运行读取到的最大值，可由调用方持久化后在下次运行时传入。
+func (e *Engine) Watermark() string {
+	return e.watermark
+}
+
 // Run 动态构建并启动整个并发 ETL 流水线。
 func (e *Engine) Run(id string, ctx context.Context, beforeExecuteConfig *map[string]string, sourceConfig map[string]string, processorConfigs []ProcessorConfig, sinkConfig map[string]string, afterExecuteConfig *map[string]string) (err error) {
 	// 1. 创建一个可取消的上下文，用于实现“一处失败，全体取消”的快速失败机制。
@@ -148,7 +161,17 @@ func (e *Engine) Run(id string, ctx context.Context, beforeExecuteConfig *map[st
 		}
 	}
 
+	// settled 为 false 表示 Sink 已打开但尚未提交，退出时需要回滚
+	transactional, isTransactional := e.sink.(sink.Transactional)
+	settled := true
 	defer func() {
+		if isTransactional && !settled {
+			zap.L().Info("Rolling back (Sink)...", zap.String("service", "etl"), zap.String("name", id))
+			if rollbackErr := transactional.Rollback(); rollbackErr != nil {
+				zap.L().Error("Failed to roll back Sink", zap.Error(rollbackErr), zap.String("service", "etl"), zap.String("name", id))
+				err = errors.Join(err, fmt.Errorf("pipeline: failed to roll back sink: %w", rollbackErr))
+			}
+		}
 		zap.L().Info("Closing (Sink)...", zap.String("service", "etl"), zap.String("name", id))
 		if closeErr := e.sink.Close(); closeErr != nil && err == nil {
 			zap.L().Error("Failed to close Sink", zap.Error(closeErr), zap.String("service", "etl"), zap.String("name", id))
@@ -197,6 +220,11 @@ func (e *Engine) Run(id string, ctx context.Context, beforeExecuteConfig *map[st
 		}
 	}
 	zap.L().Info("正在打开数据源 (Source)...", zap.String("service", "etl"), zap.String("name", id))
+	incremental, isIncremental := e.source.(source.Incremental)
+	if isIncremental {
+		// Source 实例在任务间共享，每次运行都需要重新注入水位线
+		incremental.SetWatermark(e.watermark)
+	}
 	if err := e.source.Open(sourceConfig, e.sourceDatasource); err != nil {
 		zap.L().Error("数据源打开失败", zap.Error(err), zap.String("service", "etl"), zap.String("name", id))
 		return fmt.Errorf("pipeline: failed to open source: %w", err)
@@ -211,12 +239,30 @@ func (e *Engine) Run(id string, ctx context.Context, beforeExecuteConfig *map[st
 			return fmt.Errorf("pipeline: failed to open processor #%d (%s): %w", i+1, processorConfigs[i].Type, err)
 		}
 	}
+	if aware, ok := e.sink.(sink.SchemaAware); ok {
+		aware.SetColumnTypes(e.columnTypes(column))
+	}
+	if output, ok := e.sink.(sink.FileOutput); ok {
+		// Sink 在自己的协程中创建文件，此时主协程只在等待，fileIds 不会被并发访问
+		output.SetFileCreator(func(name string, ext string) (string, error) {
+			fileId, filePath, err := file.CreateOutputFile(name, ext)
+			if err != nil {
+				return "", err
+			}
+			fileIds = append(fileIds, fileId)
+			return filePath, nil
+		})
+	}
 	zap.L().Info("正在打开数据汇 (Sink)...", zap.String("service", "etl"), zap.String("name", id))
+	// Open 失败时 Sink 可能已创建了临时表等资源，同样需要回滚
+	settled = false
 	if err := e.sink.Open(sinkConfig, column, e.sinkDatasource); err != nil {
 		zap.L().Error("数据汇打开失败", zap.Error(err), zap.String("service", "etl"), zap.String("name", id))
 		return fmt.Errorf("pipeline: failed to open sink: %w", err)
 	}
 
+	e.drained = false
+
 	// 3. 动态创建一系列 Channel，作为连接各个并发阶段的“传送带”。
 	numWorkers := len(e.processors) + 2 // Source + Processors + Sink
 	errChan := make(chan error, numWorkers)
@@ -247,10 +293,30 @@ func (e *Engine) Run(id string, ctx context.Context, beforeExecuteConfig *map[st
 			finalErr = fmt.Errorf("%v; %w", finalErr, runErr)
 		}
 	}
+	// 被取消时各协程直接退出而不发送错误，数据可能只处理了一部分，必须按失败处理，不能提交
+	// runCtx 派生自 ctx，手动中止、心跳中止与调用方取消都会反映在 runCtx 上
+	if finalErr == nil {
+		if ctxErr := runCtx.Err(); ctxErr != nil {
+			finalErr = fmt.Errorf("pipeline: run cancelled: %w", ctxErr)
+		}
+	}
 	if finalErr != nil {
 		zap.L().Error("数据处理失败", zap.Error(finalErr), zap.String("service", "etl"), zap.String("name", id))
 		return finalErr
 	}
+	if isTransactional {
+		// 提交前再次确认 Source 已完整读取，例如 sync 模式会删除没有读到的主键
+		if !e.drained {
+			zap.L().Error("数据源未完整读取，拒绝提交", zap.String("service", "etl"), zap.String("name", id))
+			return fmt.Errorf("pipeline: source was not fully read, refusing to commit sink")
+		}
+		zap.L().Info("正在提交数据汇 (Sink)...", zap.String("service", "etl"), zap.String("name", id))
+		if err := transactional.Commit(); err != nil {
+			zap.L().Error("数据汇提交失败", zap.Error(err), zap.String("service", "etl"), zap.String("name", id))
+			return fmt.Errorf("pipeline: failed to commit sink: %w", err)
+		}
+		settled = true
+	}
 	if afterExecuteConfig != nil {
 		zap.L().Info("正在打开后处理器 (Executor)...", zap.String("service", "etl"), zap.String("name", id))
 		if err = e.afterExecutor.Open(*afterExecuteConfig, e.afterExecutorDatasource); err != nil {
@@ -265,6 +331,13 @@ func (e *Engine) Run(id string, ctx context.Context, beforeExecuteConfig *map[st
 			return err
 		}
 	}
+	// 增量查询不按水位线列排序，只有完整读取且未被取消的运行读到的最大值才能作为下一次的起点
+	if isIncremental && e.drained && runCtx.Err() == nil {
+		if watermark := incremental.Watermark(); watermark != "" {
+			e.watermark = watermark
+			zap.L().Info("增量水位线已更新: "+watermark, zap.String("service", "etl"), zap.String("name", id))
+		}
+	}
 	return nil
 }
 
@@ -288,10 +361,14 @@ func (e *Engine) runSource(id string, ctx context.Context, outChan chan<- record
 		if err != nil {
 			if err == io.EOF {
 				zap.L().Info("Source 已成功读取所有数据", zap.String("service", "etl"), zap.String("name", id))
+				e.logSourceProgress(id)
+				// 主协程在 wg.Wait 之后读取
+				e.drained = true
 				return // 数据流正常结束
 			}
 			// 发生不可恢复的读取错误
 			zap.L().Error("Source 读取数据时发生错误", zap.Error(err), zap.String("service", "etl"), zap.String("name", id))
+			e.logSourceProgress(id)
 			errChan <- fmt.Errorf("source error: %w", err)
 			e.cancel() // 发生错误，立即取消所有其他 goroutine
 			return
@@ -307,6 +384,34 @@ func (e *Engine) runSource(id string, ctx context.Context, outChan chan<- record
 	}
 }
 
+// columnTypes 返回经过处理器后仍保留的列在 Source 中报告的类型，
+// 被处理器重命名或新增的列无法确定类型，不会出现在结果中。
+func (e *Engine) columnTypes(columns map[string]string) map[string]string {
+	types := make(map[string]string)
+	schema, ok := e.source.(source.Schema)
+	if !ok {
+		return types
+	}
+	sourceTypes := schema.ColumnTypes()
+	for name := range columns {
+		if t, ok := sourceTypes[name]; ok {
+			types[name] = t
+		}
+	}
+	return types
+}
+
+// logSourceProgress 输出实现了 source.Progress 的 Source 的读取进度。
+func (e *Engine) logSourceProgress(id string) {
+	p, ok := e.source.(source.Progress)
+	if !ok {
+		return
+	}
+	for name, rows := range p.Progress() {
+		zap.L().Info(fmt.Sprintf("Source 读取进度 %s: %d", name, rows), zap.String("service", "etl"), zap.String("name", id))
+	}
+}
+
 // runProcessor 是流水线上的一个工作站，负责执行单个处理逻辑。
 func (e *Engine) runProcessor(id string, ctx context.Context, p procrssor.Processor, inChan <-chan record.Record, outChan chan<- record.Record, errChan chan<- error, num int, pType string) {
 	defer e.wg.Done()
@@ -406,10 +511,20 @@ func HandleInternalConfig(config *map[string]string) (string, error) {
 				return "", fmt.Errorf("file_id config is invalid: %w", k)
 			}
 			(*config)["file_path"] = filePath
+			if _, ok := (*config)["file_ids"]; !ok {
+				if err := setFileNames(config, []string{v}); err != nil {
+					return "", fmt.Errorf("file_id config is invalid: %w", err)
+				}
+			}
 			continue
 
 		case "file_ids":
-			fileIds := strings.Split(v, ",")
+			var fileIds []string
+			for _, fileId := range strings.Split(v, ",") {
+				if fileId = strings.TrimSpace(fileId); fileId != "" {
+					fileIds = append(fileIds, fileId)
+				}
+			}
 			if len(fileIds) == 0 {
 				return "", fmt.Errorf("file_ids config is invalid: %w", k)
 			}
@@ -422,6 +537,9 @@ func HandleInternalConfig(config *map[string]string) (string, error) {
 				filePaths[i] = filePath
 			}
 			(*config)["file_paths"] = strings.Join(filePaths, ",")
+			if err := setFileNames(config, fileIds); err != nil {
+				return "", fmt.Errorf("file_ids config is invalid: %w", err)
+			}
 			continue
 		case "file_name":
 			fileExt, ok := (*config)["file_ext"]
@@ -441,3 +559,21 @@ func HandleInternalConfig(config *map[string]string) (string, error) {
 	}
 	return fileId, nil
 }
+
+// setFileNames 以 JSON 数组写入输入文件上传时的名称，供文件类数据源填充来源列与错误提示。
+func setFileNames(config *map[string]string, fileIds []string) error {
+	names := make([]string, len(fileIds))
+	for i, fileId := range fileIds {
+		name, err := file.GetFileName(fileId)
+		if err != nil {
+			return err
+		}
+		names[i] = name
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	(*config)["file_names"] = string(data)
+	return nil
+}
@@ -4,6 +4,7 @@ go 1.24.4
 
 require (
 	github.com/BernardSimon/etl-go/components/datasource/doris v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/datasource/http v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/datasource/mysql v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/datasource/postgre v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/datasource/sqlite v0.0.0-00010101000000-000000000000
@@ -16,10 +17,19 @@ require (
 	github.com/BernardSimon/etl-go/components/sinks/csv v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/sinks/doris v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/sinks/json v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sinks/parquet v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/sinks/sql v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sinks/xlsx v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/sources/csv v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/generator v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/http v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/sources/json v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/mysqlCdc v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/parquet v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/sources/sql v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/text v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/xlsx v0.0.0-00010101000000-000000000000
+	github.com/BernardSimon/etl-go/components/sources/xml v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/components/variable/sql v0.0.0-00010101000000-000000000000
 	github.com/BernardSimon/etl-go/etl/core v0.0.0-00010101000000-000000000000
 	github.com/gin-contrib/cors v1.7.6
@@ -34,11 +44,15 @@ require (
 	golang.org/x/text v0.32.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gopkg.in/yaml.v3 v3.0.1
+	gorm.io/driver/mysql v1.6.0
+	gorm.io/driver/postgres v1.6.0
 	gorm.io/gorm v1.31.1
 )
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/Masterminds/semver v1.5.0 // indirect
+	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/bytedance/sonic v1.14.0 // indirect
 	github.com/bytedance/sonic/loader v0.3.0 // indirect
 	github.com/cloudwego/base64x v0.1.6 // indirect
@@ -46,15 +60,21 @@ require (
 	github.com/gabriel-vasile/mimetype v1.4.9 // indirect
 	github.com/gin-contrib/sse v1.1.0 // indirect
 	github.com/glebarez/go-sqlite v1.21.2 // indirect
+	github.com/go-mysql-org/go-mysql v1.12.0 // indirect
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/go-playground/validator/v10 v10.27.0 // indirect
 	github.com/go-sql-driver/mysql v1.9.3 // indirect
 	github.com/goccy/go-json v0.10.5 // indirect
 	github.com/goccy/go-yaml v1.18.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/pgx/v5 v5.7.6 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
 	github.com/leodido/go-urn v1.4.0 // indirect
 	github.com/lib/pq v1.10.9 // indirect
@@ -62,14 +82,27 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/parquet-go/parquet-go v0.25.1 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	github.com/pingcap/errors v0.11.5-0.20240311024730-e056997136bb // indirect
+	github.com/pingcap/log v1.1.1-0.20230317032135-a0d097d16e22 // indirect
+	github.com/pingcap/tidb/pkg/parser v0.0.0-20241118164214-4f047be191be // indirect
 	github.com/quic-go/qpack v0.5.1 // indirect
 	github.com/quic-go/quic-go v0.54.0 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
+	github.com/shopspring/decimal v1.2.0 // indirect
+	github.com/tiendc/go-deepcopy v1.7.1 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.3.0 // indirect
+	github.com/xuri/efp v0.0.1 // indirect
+	github.com/xuri/excelize/v2 v2.10.0 // indirect
+	github.com/xuri/nfp v0.0.2-0.20250530014748-2ddeb826f9a9 // indirect
+	go.uber.org/atomic v1.11.0 // indirect
 	go.uber.org/mock v0.5.0 // indirect
-	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/arch v0.20.0 // indirect
 	golang.org/x/crypto v0.44.0 // indirect
 	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
@@ -87,6 +120,7 @@ require (
 
 replace (
 	github.com/BernardSimon/etl-go/components/datasource/doris => ./components/datasource/doris
+	github.com/BernardSimon/etl-go/components/datasource/http => ./components/datasource/http
 	github.com/BernardSimon/etl-go/components/datasource/mysql => ./components/datasource/mysql
 	github.com/BernardSimon/etl-go/components/datasource/postgre => ./components/datasource/postgre
 	github.com/BernardSimon/etl-go/components/datasource/sqlite => ./components/datasource/sqlite
@@ -99,10 +133,19 @@ replace (
 	github.com/BernardSimon/etl-go/components/sinks/csv => ./components/sinks/csv
 	github.com/BernardSimon/etl-go/components/sinks/doris => ./components/sinks/doris
 	github.com/BernardSimon/etl-go/components/sinks/json => ./components/sinks/json
+	github.com/BernardSimon/etl-go/components/sinks/parquet => ./components/sinks/parquet
 	github.com/BernardSimon/etl-go/components/sinks/sql => ./components/sinks/sql
+	github.com/BernardSimon/etl-go/components/sinks/xlsx => ./components/sinks/xlsx
 	github.com/BernardSimon/etl-go/components/sources/csv => ./components/sources/csv
+	github.com/BernardSimon/etl-go/components/sources/generator => ./components/sources/generator
+	github.com/BernardSimon/etl-go/components/sources/http => ./components/sources/http
 	github.com/BernardSimon/etl-go/components/sources/json => ./components/sources/json
+	github.com/BernardSimon/etl-go/components/sources/mysqlCdc => ./components/sources/mysqlCdc
+	github.com/BernardSimon/etl-go/components/sources/parquet => ./components/sources/parquet
 	github.com/BernardSimon/etl-go/components/sources/sql => ./components/sources/sql
+	github.com/BernardSimon/etl-go/components/sources/text => ./components/sources/text
+	github.com/BernardSimon/etl-go/components/sources/xlsx => ./components/sources/xlsx
+	github.com/BernardSimon/etl-go/components/sources/xml => ./components/sources/xml
 	github.com/BernardSimon/etl-go/components/variable/sql => ./components/variable/sql
 	github.com/BernardSimon/etl-go/etl/core => ./etl/core
 )
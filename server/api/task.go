@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 
 	params2 "github.com/BernardSimon/etl-go/etl/core/params"
@@ -44,6 +46,7 @@ func DeleteTask(req *_type.DeleteTaskRequest, lang string) (interface{}, error)
 	if err != nil {
 		return false, errors.New("failed to delete task")
 	}
+	model.DB.Where("task_id = ?", m.ID).Delete(&model.TaskWatermark{})
 	return i18n.Translate(lang, "success"), nil
 }
 
@@ -72,11 +75,22 @@ func UpdateTask(req *_type.UpdateTaskRequest, lang string) (interface{}, error)
 	if m.Status == 1 {
 		return nil, errors.New("cannot edit in task scheduling")
 	}
-	m.Name = req.Name
-	m.Cron = req.Cron
-	m.Data = &req.ParStr
-	m.Status = 0
-	if err := model.DB.Save(&m).Error; err != nil {
+	// 数据输入配置发生变化时，已提交的增量水位线不再有效
+	if m.Data != nil {
+		oldSource, _ := json.Marshal(m.Data.Source)
+		newSource, _ := json.Marshal(req.ParStr.Source)
+		if !bytes.Equal(oldSource, newSource) {
+			model.DB.Where("task_id = ?", m.ID).Delete(&model.TaskWatermark{})
+		}
+	}
+	// 只更新编辑的列，整行保存会用读取时的租约列覆盖运行中任务的租约
+	err := model.DB.Model(&model.Task{}).Where("id = ?", m.ID).UpdateColumns(map[string]interface{}{
+		"name":   req.Name,
+		"cron":   req.Cron,
+		"data":   &req.ParStr,
+		"status": 0,
+	}).Error
+	if err != nil {
 		return nil, errors.New("failed to edit task")
 	}
 	return i18n.Translate(lang, "success"), nil
@@ -111,7 +125,7 @@ func RunTask(req *_type.RunTaskRequest, lang string) (interface{}, error) {
 
 	// 更新任务状态
 	m.Status = 1
-	if err := tx.Save(&m).Error; err != nil {
+	if err := tx.Model(&model.Task{}).Where("id = ?", m.ID).UpdateColumn("status", 1).Error; err != nil {
 		tx.Rollback()
 		return nil, errors.New("failed to update task status")
 	}
@@ -133,11 +147,17 @@ func RunTask(req *_type.RunTaskRequest, lang string) (interface{}, error) {
 func StopTask(req *_type.StopTaskRequest, lang string) (interface{}, error) {
 	var m model.Task
 	model.DB.Where("id = ?", req.Id).Find(&m)
-	defer model.DB.Save(&m)
 	if m.Status != 1 {
 		return nil, errors.New("unable to stop scheduling task has not started yet")
 	}
 	task.CancelMission(&m)
+	err := model.DB.Model(&model.Task{}).Where("id = ?", m.ID).UpdateColumns(map[string]interface{}{
+		"status":   m.Status,
+		"entry_id": nil,
+	}).Error
+	if err != nil {
+		return nil, errors.New("failed to update task status")
+	}
 	return i18n.Translate(lang, "success"), nil
 }
 func RunTaskOnce(req *_type.RunTaskOnceRequest, _ string) (interface{}, error) {
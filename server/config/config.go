@@ -11,6 +11,9 @@ import (
 var Config configModel
 var Ip string
 
+// NodeId 当前实例在集群中的唯一标识，用于任务租约与运行记录的归属判断
+var NodeId string
+
 type configModel struct {
 	Username  string `yaml:"username"`
 	Password  string `yaml:"password"`
@@ -21,6 +24,11 @@ type configModel struct {
 	ServerUrl string `yaml:"serverUrl"`
 	RunWeb    bool   `yaml:"runWeb"`
 	WebUrl    string `yaml:"webUrl"`
+	DbDriver  string `yaml:"dbDriver,omitempty"`  // 元数据库类型：sqlite(默认)、mysql 或 postgres，多实例部署需使用 mysql 或 postgres
+	DbDsn     string `yaml:"dbDsn,omitempty"`     // 元数据库连接串，sqlite 默认为 ./data.db
+	NodeId    string `yaml:"nodeId,omitempty"`    // 集群节点标识，为空时使用 主机名-serverUrl
+	LeaseTtl  int    `yaml:"leaseTtl,omitempty"`  // 任务租约有效期(秒)，超时未续约视为节点失联
+	Heartbeat int    `yaml:"heartbeat,omitempty"` // 运行中任务心跳间隔(秒)
 }
 
 func init() {
@@ -35,6 +43,17 @@ func init() {
 		os.Exit(1)
 	}
 	Ip = GetLocalIP()
+	NodeId = Config.NodeId
+	if NodeId == "" {
+		hostname, _ := os.Hostname()
+		NodeId = hostname + "-" + Config.ServerUrl
+	}
+	if Config.Heartbeat <= 0 {
+		Config.Heartbeat = 10
+	}
+	if Config.LeaseTtl <= Config.Heartbeat {
+		Config.LeaseTtl = Config.Heartbeat * 3
+	}
 }
 
 func SaveConfig() error {
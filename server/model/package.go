@@ -17,7 +17,10 @@ import (
 	"time"
 
 	"github.com/BernardSimon/etl-go/server/config"
+	_type "github.com/BernardSimon/etl-go/server/type"
 	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm/schema"
 
 	"go.uber.org/zap"
@@ -30,16 +33,76 @@ import (
 var DB *gorm.DB
 
 func MigrateDb() error {
-	err := DB.AutoMigrate(&DataSource{}, &Variable{}, &Task{}, &TaskRecord{}, &File{}, &TaskRecordFile{})
+	err := DB.AutoMigrate(&DataSource{}, &Variable{}, &Task{}, &TaskRecord{}, &File{}, &TaskRecordFile{}, &TaskWatermark{})
 	if err != nil {
 		return err
 	}
+	// 升级前运行中的任务没有租约到期时间，补上一个租约周期，到期后才能被其他节点抢占
+	err = DB.Model(&Task{}).Where("is_running = ? AND lock_expire_at IS NULL", true).
+		UpdateColumn("lock_expire_at", time.Now().Add(time.Duration(config.Config.LeaseTtl)*time.Second)).Error
+	if err != nil {
+		return err
+	}
+	return enableTemplateVariables()
+}
+
+// enableTemplateVariables 为升级前创建、配置中含有 ${ 的任务开启变量模板替换：升级前的 ${name} 总会被替换，
+// 升级后未开启替换的任务会拒绝运行。只处理配置中还没有 template_variables 的任务，用户之后关闭替换不会被改回。
+func enableTemplateVariables() error {
+	var tasks []struct {
+		ID   string
+		Data *string
+	}
+	if err := DB.Model(&Task{}).Select("id", "data").Find(&tasks).Error; err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if task.Data == nil || !strings.Contains(*task.Data, "${") {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(*task.Data), &fields); err != nil {
+			return fmt.Errorf("任务 %s 的配置解析错误: %w", task.ID, err)
+		}
+		if _, ok := fields["template_variables"]; ok {
+			continue
+		}
+		data := &_type.TaskData{}
+		if err := data.Scan(*task.Data); err != nil {
+			return fmt.Errorf("任务 %s 的配置解析错误: %w", task.ID, err)
+		}
+		data.TemplateVariables = true
+		if err := DB.Model(&Task{}).Where("id = ?", task.ID).UpdateColumn("data", data).Error; err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// dialector 按配置返回元数据库的连接方式，多个实例共享元数据库时需使用 mysql 或 postgres。
+func dialector() (gorm.Dialector, error) {
+	dsn := config.Config.DbDsn
+	switch strings.ToLower(strings.TrimSpace(config.Config.DbDriver)) {
+	case "", "sqlite":
+		if dsn == "" {
+			dsn = "./data.db"
+		}
+		return sqlite.Open(dsn), nil
+	case "mysql":
+		return mysql.Open(dsn), nil
+	case "postgres", "postgre", "postgresql":
+		return postgres.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported dbDriver %q, expected sqlite, mysql or postgres", config.Config.DbDriver)
+	}
+}
+
 func InitDb() error {
-	// 初始化 SQLite 数据库连接
-	dB, err := gorm.Open(sqlite.Open("./data.db"), &gorm.Config{
+	d, err := dialector()
+	if err != nil {
+		return err
+	}
+	dB, err := gorm.Open(d, &gorm.Config{
 		Logger:      &sqlLogger{},
 		PrepareStmt: true, // 开启预编译语句缓存
 	})
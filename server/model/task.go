@@ -18,18 +18,32 @@ type Task struct {
 	LastEndTime     *CustomTime     `json:"last_end_time"`
 	ErrMsg          string          `json:"err_msg"`
 	IsRunning       bool
-	EntryID         *int // cron.EntryID
+	EntryID         *int        // cron.EntryID
+	LockedBy        string      `json:"locked_by" gorm:"size:255"` // 持有运行租约的节点
+	LockExpireAt    *CustomTime `json:"lock_expire_at"`            // 租约到期时间，运行期间由心跳续约
+	LastFireAt      *CustomTime `json:"last_fire_at"`              // 最近一次被抢占执行的调度计划时间
 }
 
 type TaskRecord struct {
 	Model
-	CreatedAt *CustomTime
-	RunBy     string          `json:"run_by"`
-	TaskID    string          `json:"task_id"`
-	Task      Task            `json:"task"`
-	Status    int             `json:"status"` //0运行中；1运行成功；2运行失败
-	StartTime *CustomTime     `json:"start_time"`
-	EndTime   *CustomTime     `json:"end_time"`
-	Message   string          `json:"message"`
-	Data      *_type.TaskData `json:"data" gorm:"type:json"`
+	CreatedAt       *CustomTime
+	RunBy           string          `json:"run_by"`
+	TaskID          string          `json:"task_id"`
+	Task            Task            `json:"task"`
+	Status          int             `json:"status"` //0运行中；1运行成功；2运行失败
+	StartTime       *CustomTime     `json:"start_time"`
+	EndTime         *CustomTime     `json:"end_time"`
+	Message         string          `json:"message"`
+	Data            *_type.TaskData `json:"data" gorm:"type:json"`
+	Node            string          `json:"node" gorm:"size:255"` // 执行节点
+	HeartbeatAt     *CustomTime     `json:"heartbeat_at"`         // 最近一次心跳时间
+	CancelRequested bool            `json:"cancel_requested"`     // 跨节点中止请求，由执行节点的心跳协程处理
+}
+
+// TaskWatermark 保存任务增量抽取已提交的水位线，只在运行成功后更新。
+// 与 Task 分表存放，避免调度流程整体保存 Task 时覆盖水位线。
+type TaskWatermark struct {
+	Model
+	TaskID string `json:"task_id" gorm:"size:36;uniqueIndex"`
+	Value  string `json:"value"`
 }
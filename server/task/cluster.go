@@ -0,0 +1,304 @@
+package task
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BernardSimon/etl-go/server/config"
+	"github.com/BernardSimon/etl-go/server/model"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// 多个 etl-go 实例共享同一元数据库时：
+//   - 每个节点都按数据库中 status = 1 的任务维护本地 cron，调度到点时先抢占任务租约，抢到的节点才真正执行；
+//   - 执行节点定期为运行记录写入心跳并续约，其他节点发现心跳超时后将该运行标记为中断并接管。
+//
+// 租约到期时间与心跳由各节点按本机时间写入并比较，节点之间需要通过 NTP 等方式保持时钟同步，
+// 时钟偏差会相应地缩短或延长租约，偏差应远小于 leaseTtl 与 heartbeat 的差值。
+
+type scheduledEntry struct {
+	id   cron.EntryID
+	cron string
+}
+
+var (
+	entryMu  sync.Mutex
+	entryMap = make(map[string]scheduledEntry) // 任务ID -> 本节点 cron 条目
+)
+
+func leaseTtl() time.Duration {
+	return time.Duration(config.Config.LeaseTtl) * time.Second
+}
+
+func heartbeatInterval() time.Duration {
+	return time.Duration(config.Config.Heartbeat) * time.Second
+}
+
+// acquireLease 以条件更新的方式抢占任务的运行租约，只有一个节点能更新成功。
+// fireAt 为调度的计划时间，各节点上同一次调度的计划时间相同：已被抢占过的计划时间不会再次执行，
+// 即使抢到的节点已经运行结束。手动运行与接管重跑传入零值，不检查计划时间。
+func acquireLease(taskID string, fireAt time.Time) (bool, error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"is_running":     true,
+		"locked_by":      config.NodeId,
+		"lock_expire_at": now.Add(leaseTtl()),
+	}
+	query := model.DB.Model(&model.Task{}).
+		Where("id = ? AND (is_running = ? OR lock_expire_at < ?)", taskID, false, now)
+	if !fireAt.IsZero() {
+		query = query.Where("(last_fire_at IS NULL OR last_fire_at < ?)", fireAt)
+		updates["last_fire_at"] = fireAt
+	}
+	tx := query.UpdateColumns(updates)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected == 1, nil
+}
+
+// releaseLease 释放本节点持有的租约；租约已被其他节点接管时不做任何修改。
+func releaseLease(taskID string) error {
+	return model.DB.Model(&model.Task{}).
+		Where("id = ? AND locked_by = ?", taskID, config.NodeId).
+		UpdateColumns(map[string]interface{}{
+			"is_running":     false,
+			"locked_by":      "",
+			"lock_expire_at": nil,
+		}).Error
+}
+
+// renewLease 为本节点持有的租约续期，返回本节点是否仍持有租约。
+func renewLease(taskID string) (bool, error) {
+	tx := model.DB.Model(&model.Task{}).
+		Where("id = ? AND locked_by = ?", taskID, config.NodeId).
+		UpdateColumn("lock_expire_at", time.Now().Add(leaseTtl()))
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected == 1, nil
+}
+
+// fireTime 返回任务在本节点 cron 中这一次触发的计划时间。
+// 任务开始时条目的 Prev 已更新为本次的计划时间，条目被移除时退回到当前时间。
+func fireTime(taskID string) time.Time {
+	entryMu.Lock()
+	e, ok := entryMap[taskID]
+	entryMu.Unlock()
+	if ok {
+		if prev := cr.Entry(e.id).Prev; !prev.IsZero() {
+			return prev
+		}
+	}
+	return time.Now().Truncate(time.Second)
+}
+
+// addEntry 在本节点的 cron 中登记任务。
+func addEntry(taskID string, spec string) (cron.EntryID, error) {
+	entryMu.Lock()
+	defer entryMu.Unlock()
+	if e, ok := entryMap[taskID]; ok {
+		cr.Remove(e.id)
+	}
+	entryID, err := cr.AddFunc(spec, func() {
+		middleware(taskID, "system", fireTime(taskID))
+	})
+	if err != nil {
+		delete(entryMap, taskID)
+		return 0, err
+	}
+	entryMap[taskID] = scheduledEntry{id: entryID, cron: spec}
+	return entryID, nil
+}
+
+// removeEntry 将任务从本节点的 cron 中移除。
+func removeEntry(taskID string) {
+	entryMu.Lock()
+	defer entryMu.Unlock()
+	if e, ok := entryMap[taskID]; ok {
+		cr.Remove(e.id)
+		delete(entryMap, taskID)
+	}
+}
+
+// syncMissions 使本节点的 cron 与数据库中的调度状态保持一致，
+// 这样在任意节点上启动或停止调度，其余节点都会在下一个心跳周期内跟进。
+func syncMissions() error {
+	var missions []model.Task
+	if err := model.DB.Where("status = ? AND cron <> ?", 1, "manual").Find(&missions).Error; err != nil {
+		return err
+	}
+	active := make(map[string]string, len(missions))
+	for _, mission := range missions {
+		active[mission.ID] = mission.Cron
+	}
+	entryMu.Lock()
+	var stale []string
+	for id, e := range entryMap {
+		if spec, ok := active[id]; !ok || spec != e.cron {
+			stale = append(stale, id)
+		}
+	}
+	entryMu.Unlock()
+	for _, id := range stale {
+		removeEntry(id)
+	}
+	for id, spec := range active {
+		entryMu.Lock()
+		_, scheduled := entryMap[id]
+		entryMu.Unlock()
+		if scheduled {
+			continue
+		}
+		if _, err := addEntry(id, spec); err != nil {
+			zap.L().Error("任务调度失败", zap.String("service", "task"), zap.String("name", id), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// heartbeat 从抢到租约起定期续约，运行记录创建后同时为其写入心跳并处理其他节点发来的中止请求。
+// 变量解析与组件初始化期间租约同样需要续约，否则耗时超过 leaseTtl 时会被其他节点接管。
+type heartbeat struct {
+	taskID   string
+	cancel   func()
+	done     chan struct{}
+	mu       sync.Mutex
+	recordID string
+	lost     bool
+}
+
+// startHeartbeat 开始为任务续约，租约被其他节点接管时调用 cancel。
+func startHeartbeat(taskID string, cancel func()) *heartbeat {
+	h := &heartbeat{taskID: taskID, cancel: cancel, done: make(chan struct{})}
+	go h.run()
+	return h
+}
+
+// attach 登记本次运行的运行记录。
+func (h *heartbeat) attach(recordID string) {
+	h.mu.Lock()
+	h.recordID = recordID
+	h.mu.Unlock()
+}
+
+// leaseLost 返回租约是否已被其他节点接管。
+func (h *heartbeat) leaseLost() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lost
+}
+
+func (h *heartbeat) stop() {
+	close(h.done)
+}
+
+func (h *heartbeat) run() {
+	ticker := time.NewTicker(heartbeatInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+		}
+		h.mu.Lock()
+		recordID := h.recordID
+		h.mu.Unlock()
+		if recordID != "" {
+			err := model.DB.Model(&model.TaskRecord{}).Where("id = ?", recordID).UpdateColumn("heartbeat_at", time.Now()).Error
+			if err != nil {
+				zap.L().Error("任务心跳写入失败", zap.String("service", "task"), zap.String("name", h.taskID), zap.Error(err))
+			}
+		}
+		owned, err := renewLease(h.taskID)
+		if err != nil {
+			zap.L().Error("任务租约续期失败", zap.String("service", "task"), zap.String("name", h.taskID), zap.Error(err))
+		} else if !owned {
+			// 租约已过期并被其他节点接管，继续运行会与接管节点重复执行
+			zap.L().Warn("任务租约已被其他节点接管，正在停止任务", zap.String("service", "task"), zap.String("name", h.taskID))
+			h.mu.Lock()
+			h.lost = true
+			h.mu.Unlock()
+			if recordID != "" {
+				runMu.Lock()
+				ManualCancelMap[recordID] = cancelLeaseLost
+				runMu.Unlock()
+			}
+			h.cancel()
+			return
+		}
+		if recordID == "" {
+			continue
+		}
+		var cancelRequested bool
+		err = model.DB.Model(&model.TaskRecord{}).Where("id = ?", recordID).Select("cancel_requested").Find(&cancelRequested).Error
+		if err != nil {
+			zap.L().Error("任务中止请求查询失败", zap.String("service", "task"), zap.String("name", h.taskID), zap.Error(err))
+		} else if cancelRequested {
+			zap.L().Info("收到中止请求，正在停止任务", zap.String("service", "task"), zap.String("name", h.taskID))
+			runMu.Lock()
+			ManualCancelMap[recordID] = cancelManual
+			runMu.Unlock()
+			h.cancel()
+		}
+	}
+}
+
+// takeoverOrphans 接管心跳超时的运行：将运行记录标记为中断并释放失联节点的租约，
+// 由调度触发的运行会在本节点立即重新执行。
+func takeoverOrphans() {
+	now := time.Now()
+	var orphans []model.TaskRecord
+	err := model.DB.Where("status = ? AND node <> ? AND heartbeat_at < ?", 0, config.NodeId, now.Add(-leaseTtl())).Find(&orphans).Error
+	if err != nil {
+		zap.L().Error("查询失联任务失败", zap.String("service", "system"), zap.String("name", config.NodeId), zap.Error(err))
+		return
+	}
+	for _, orphan := range orphans {
+		tx := model.DB.Model(&model.TaskRecord{}).
+			Where("id = ? AND status = ?", orphan.ID, 0).
+			UpdateColumns(map[string]interface{}{
+				"status":   2,
+				"message":  fmt.Sprintf("执行节点 %s 失联，任务已由节点 %s 接管", orphan.Node, config.NodeId),
+				"end_time": now,
+			})
+		// 其他节点可能已经抢先接管
+		if tx.Error != nil || tx.RowsAffected != 1 {
+			continue
+		}
+		model.DB.Model(&model.Task{}).
+			Where("id = ? AND locked_by = ? AND lock_expire_at < ?", orphan.TaskID, orphan.Node, now).
+			UpdateColumns(map[string]interface{}{
+				"is_running":     false,
+				"locked_by":      "",
+				"lock_expire_at": nil,
+			})
+		zap.L().Warn(fmt.Sprintf("执行节点 %s 失联，已接管任务运行 %s", orphan.Node, orphan.ID), zap.String("service", "task"), zap.String("name", orphan.TaskID))
+		if orphan.RunBy == "system" {
+			go middleware(orphan.TaskID, "system", time.Time{})
+		}
+	}
+	// 释放没有运行记录但租约已过期的任务，例如节点在变量解析阶段失联
+	model.DB.Model(&model.Task{}).
+		Where("is_running = ? AND lock_expire_at < ?", true, now).
+		UpdateColumns(map[string]interface{}{
+			"is_running":     false,
+			"locked_by":      "",
+			"lock_expire_at": nil,
+		})
+}
+
+// watchCluster 周期性同步调度状态并接管失联节点的运行。
+func watchCluster() {
+	ticker := time.NewTicker(heartbeatInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := syncMissions(); err != nil {
+			zap.L().Error("任务调度同步失败", zap.String("service", "system"), zap.String("name", config.NodeId), zap.Error(err))
+		}
+		takeoverOrphans()
+	}
+}
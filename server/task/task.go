@@ -2,16 +2,15 @@ package task
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/BernardSimon/etl-go/etl/core/datasource"
 	"github.com/BernardSimon/etl-go/etl/core/executor"
+	"github.com/BernardSimon/etl-go/etl/core/params"
 	"github.com/BernardSimon/etl-go/etl/core/procrssor"
 	"github.com/BernardSimon/etl-go/etl/core/sink"
 	"github.com/BernardSimon/etl-go/etl/core/source"
@@ -19,7 +18,6 @@ import (
 	"github.com/BernardSimon/etl-go/etl/pipeline"
 	"github.com/BernardSimon/etl-go/server/config"
 	"github.com/BernardSimon/etl-go/server/model"
-	_type "github.com/BernardSimon/etl-go/server/type"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -28,19 +26,20 @@ var cr *cron.Cron
 
 func SetMissions() {
 	cr = cron.New()
-	var missions []model.Task
-	err := model.DB.Model(&model.Task{}).Where("is_running != 0").UpdateColumn("is_running", 0).Error
-	if err != nil {
-		zap.L().Error("任务启动失败-数据库查询失败", zap.String("service", "system"), zap.String("name", config.Ip), zap.Error(err))
-		os.Exit(1)
-	}
-	err = model.DB.Where("status = ?", 1).Find(&missions).Error
+	// 只重置本节点遗留的运行状态，其他节点的运行由心跳超时接管
+	err := model.DB.Model(&model.Task{}).
+		Where("is_running != 0 AND (locked_by = ? OR locked_by = ? OR locked_by IS NULL)", config.NodeId, "").
+		UpdateColumns(map[string]interface{}{
+			"is_running":     0,
+			"locked_by":      "",
+			"lock_expire_at": nil,
+		}).Error
 	if err != nil {
 		zap.L().Error("任务启动失败-数据库查询失败", zap.String("service", "system"), zap.String("name", config.Ip), zap.Error(err))
 		os.Exit(1)
 	}
 	tx := model.DB.Model(&model.TaskRecord{}).
-		Where("status = ?", 0).
+		Where("status = ? AND (node = ? OR node = ? OR node IS NULL)", 0, config.NodeId, "").
 		UpdateColumns(map[string]interface{}{
 			"status":  2,
 			"message": "任务执行被中断，请重新执行",
@@ -49,27 +48,20 @@ func SetMissions() {
 		zap.L().Error("发现被中断任务，请查看任务运行记录", zap.String("service", "system"), zap.String("name", config.Ip))
 	}
 	if tx.Error != nil {
-		zap.L().Error("任务启动失败-数据库查询失败", zap.String("service", "system"), zap.String("name", config.Ip), zap.Error(err))
+		zap.L().Error("任务启动失败-数据库查询失败", zap.String("service", "system"), zap.String("name", config.Ip), zap.Error(tx.Error))
 		os.Exit(1)
 	}
-	if len(missions) == 0 {
-		zap.L().Info("系统任务已启动", zap.String("service", "system"), zap.String("name", config.Ip))
-		return
-	}
-	for _, mission := range missions {
-		if mission.Cron == "manual" {
-			continue
-		}
-		err := ScheduleMission(&mission)
-		if err != nil {
-			panic(err)
-		}
+	if err = syncMissions(); err != nil {
+		zap.L().Error("任务启动失败-数据库查询失败", zap.String("service", "system"), zap.String("name", config.Ip), zap.Error(err))
+		os.Exit(1)
 	}
 	cr.Start()
-	zap.L().Info("系统任务已启动", zap.String("service", "system"), zap.String("name", config.Ip))
+	go watchCluster()
+	zap.L().Info("系统任务已启动", zap.String("service", "system"), zap.String("name", config.Ip), zap.String("content", config.NodeId))
 }
 
-func middleware(missionID string, runBy string) {
+// middleware 执行一次任务。fireAt 为调度的计划时间，手动运行与接管重跑为零值。
+func middleware(missionID string, runBy string, fireAt time.Time) {
 	var mission model.Task
 	runtime := model.CustomTime{Time: time.Now()}
 	model.DB.Where("id = ?", missionID).First(&mission)
@@ -78,73 +70,79 @@ func middleware(missionID string, runBy string) {
 		zap.L().Error("系统错误，执行未调度任务", zap.String("service", "task"), zap.String("name", mission.ID))
 		return
 	}
-	defer model.DB.Save(&mission)
-	if !mission.IsRunning {
-
-		//记录开始状态时间
-		mission.IsRunning = true
-		mission.LastRunTime = &runtime
-		model.DB.Save(&mission)
-		// 初始化map
-		variableList := make(map[string]string)
-		// 或者更好的方式是直接处理变量替换
-		rawData, _ := json.Marshal(mission.Data)
-		stringData := string(rawData)
-
-		re := regexp.MustCompile(`\$\{[^}]*}`)
-		matches := re.FindAllString(stringData, -1)
-		missionRun := mission
-		if len(matches) != 0 {
-			// 使用map去重并获取变量值
-			for _, match := range matches {
-				if _, exists := variableList[match]; !exists {
-					vName := strings.TrimPrefix(match, "${")
-					vName = strings.TrimSuffix(vName, "}")
-					value, err := GetValueByName(vName)
-					if err != nil {
-						zap.L().Error("变量解析错误:"+match, zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
-						return
-						// 可以考虑是否继续执行或者返回错误
-					}
-					variableList[match] = value
-				}
-			}
-			// 变量替换
-			for placeholder, value := range variableList {
-				stringData = strings.ReplaceAll(stringData, placeholder, value)
-			}
+	// 抢占运行租约，多节点部署时同一任务只会在一个节点上执行
+	acquired, err := acquireLease(mission.ID, fireAt)
+	if err != nil {
+		zap.L().Error("任务租约获取失败", zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
+		return
+	}
+	if !acquired {
+		zap.L().Info("任务正在运行中,下个周期将再次尝试", zap.String("service", "task"), zap.String("name", mission.ID))
+		return
+	}
+	// 抢到租约后立即开始续约，变量解析与组件初始化期间租约同样不会过期
+	runCtx, cancel := context.WithCancel(context.Background())
+	hb := startHeartbeat(mission.ID, cancel)
+	model.DB.Where("id = ?", missionID).First(&mission)
+	// 运行期间任务可能被编辑，租约也可能被其他节点接管，结束时只更新本次运行写入的列
+	paused := false
+	defer func() {
+		hb.stop()
+		cancel()
+		updates := map[string]interface{}{
+			"last_end_time":     mission.LastEndTime,
+			"last_success_time": mission.LastSuccessTime,
+			"err_msg":           mission.ErrMsg,
+		}
+		if paused {
+			updates["status"] = mission.Status
+			updates["entry_id"] = nil
+		}
+		if err := model.DB.Model(&model.Task{}).Where("id = ?", mission.ID).UpdateColumns(updates).Error; err != nil {
+			zap.L().Error("任务运行结果保存失败", zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
+		}
+		if err := releaseLease(mission.ID); err != nil {
+			zap.L().Error("任务租约释放失败", zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
+		}
+	}()
 
-			// 更新任务配置
-			var replacedData _type.TaskData
-			err := json.Unmarshal([]byte(stringData), &replacedData)
-			if err != nil {
-				zap.L().Error("任务变量配置解析错误", zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
-				return
-			}
-			zap.L().Info(fmt.Sprintf("任务 %s 变量替换成功", mission.Name), zap.String("service", "task"), zap.String("name", mission.ID), zap.Any("content", variableList))
-			missionRun.Data = &replacedData
-		}
-		//执行任务业务函数
-		err := RunTask(missionRun, runBy)
-		//记录结束时间
-		endTime := model.CustomTime{Time: time.Now()}
-		mission.LastEndTime = &endTime
-		if err != nil {
-			//记录错误
-			mission.ErrMsg = err.Error()
-			if runBy == "system" {
-				cancelMission(&mission, 2)
-				zap.L().Error(fmt.Sprintf("任务 %s 执行失败,已自动暂停", mission.Name), zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
-			}
-		} else {
-			mission.LastSuccessTime = &runtime
-			mission.ErrMsg = "Success"
-			zap.L().Info(fmt.Sprintf("任务 %s 执行成功", mission.Name), zap.String("service", "task"), zap.String("name", mission.ID))
+	//记录开始状态时间
+	mission.LastRunTime = &runtime
+	model.DB.Model(&model.Task{}).Where("id = ?", mission.ID).UpdateColumn("last_run_time", &runtime)
+	// 解析变量：默认以驱动参数绑定 :name 占位符，开启模板替换时才替换 ${name}
+	missionRun := mission
+	data, variableList, err := prepareTaskData(mission.Data)
+	if err != nil {
+		zap.L().Error("任务变量解析错误", zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
+		mission.ErrMsg = err.Error()
+		return
+	}
+	missionRun.Data = data
+	if len(variableList) != 0 {
+		zap.L().Info(fmt.Sprintf("任务 %s 变量解析成功", mission.Name), zap.String("service", "task"), zap.String("name", mission.ID), zap.Any("content", variableList))
+	}
+	if hb.leaseLost() {
+		mission.ErrMsg = "任务租约已被其他节点接管，本节点的运行已中止"
+		return
+	}
+	//执行任务业务函数
+	err = RunTask(runCtx, missionRun, runBy, hb)
+	//记录结束时间
+	endTime := model.CustomTime{Time: time.Now()}
+	mission.LastEndTime = &endTime
+	if err != nil {
+		//记录错误
+		mission.ErrMsg = err.Error()
+		// 被中止的运行不是任务本身的失败，不暂停调度
+		if runBy == "system" && !errors.Is(err, context.Canceled) {
+			cancelMission(&mission, 2)
+			paused = true
+			zap.L().Error(fmt.Sprintf("任务 %s 执行失败,已自动暂停", mission.Name), zap.String("service", "task"), zap.String("name", mission.ID), zap.Error(err))
 		}
-		mission.IsRunning = false
 	} else {
-		zap.L().Info("任务正在运行中,下个周期将再次尝试", zap.String("service", "task"), zap.String("name", mission.ID))
-		return
+		mission.LastSuccessTime = &runtime
+		mission.ErrMsg = "Success"
+		zap.L().Info(fmt.Sprintf("任务 %s 执行成功", mission.Name), zap.String("service", "task"), zap.String("name", mission.ID))
 	}
 }
 
@@ -153,10 +151,8 @@ func CancelMission(mission *model.Task) {
 }
 func cancelMission(mission *model.Task, status int) {
 	mission.Status = status
-	if mission.EntryID != nil {
-		cr.Remove(cron.EntryID(*mission.EntryID))
-		mission.EntryID = nil
-	}
+	removeEntry(mission.ID)
+	mission.EntryID = nil
 }
 
 func ScheduleMission(mission *model.Task) error {
@@ -166,17 +162,22 @@ func ScheduleMission(mission *model.Task) error {
 	if _, err := cron.ParseStandard(mission.Cron); err != nil {
 		return errors.New("任务的表达式无效")
 	}
-	EntryID, err := cr.AddFunc(mission.Cron, func() {
-		middleware(mission.ID, "system")
-	})
+	EntryID, err := addEntry(mission.ID, mission.Cron)
 	if err != nil {
 		return err
 	}
 	mission.Status = 1
-	mission.IsRunning = false
 	eId := int(EntryID)
 	mission.EntryID = &eId
-	model.DB.Save(mission)
+	// 只更新调度相关的列，不覆盖运行中任务的租约
+	err = model.DB.Model(&model.Task{}).Where("id = ?", mission.ID).UpdateColumns(map[string]interface{}{
+		"status":   mission.Status,
+		"entry_id": eId,
+	}).Error
+	if err != nil {
+		removeEntry(mission.ID)
+		return err
+	}
 	return nil
 }
 func RunMissionManual(missionID string) error {
@@ -185,13 +186,17 @@ func RunMissionManual(missionID string) error {
 	if isRunning {
 		return errors.New("任务正在运行中")
 	}
-	go middleware(missionID, "manual")
+	go middleware(missionID, "manual", time.Time{})
 	return nil
 }
 
-var runCtxMap = make(map[string]context.CancelFunc)
+var (
+	runMu     sync.Mutex
+	runCtxMap = make(map[string]context.CancelFunc)
+)
 
-func RunTask(mission model.Task, runBy string) (err error) {
+// RunTask 执行一次任务并写入运行记录，ctx 在租约被其他节点接管时取消，hb 为本次运行的心跳。
+func RunTask(ctx context.Context, mission model.Task, runBy string, hb *heartbeat) (err error) {
 	var missionRecord = model.TaskRecord{
 		RunBy:  runBy,
 		TaskID: mission.ID,
@@ -201,21 +206,33 @@ func RunTask(mission model.Task, runBy string) (err error) {
 		},
 		Message: "",
 		Data:    mission.Data,
+		Node:    config.NodeId,
+		HeartbeatAt: &model.CustomTime{
+			Time: time.Now(),
+		},
 	}
 	err = model.DB.Model(&model.TaskRecord{}).Create(&missionRecord).Error
 	if err != nil {
 		return
 	}
+	hb.attach(missionRecord.ID)
 	defer func() {
-		if err == nil {
-			if _, exist := ManualCancelMap[missionRecord.ID]; exist {
-				missionRecord.Status = 2
-				missionRecord.Message = "任务被手动中止"
-				delete(ManualCancelMap, mission.ID)
-			} else {
-				missionRecord.Status = 1
-				missionRecord.Message = "ok"
+		runMu.Lock()
+		reason, canceled := ManualCancelMap[missionRecord.ID]
+		delete(ManualCancelMap, missionRecord.ID)
+		runMu.Unlock()
+		if hb.leaseLost() {
+			reason, canceled = cancelLeaseLost, true
+		}
+		if canceled && (err == nil || errors.Is(err, context.Canceled)) {
+			missionRecord.Status = 2
+			missionRecord.Message = "任务被手动中止"
+			if reason == cancelLeaseLost {
+				missionRecord.Message = "任务租约已被其他节点接管，本节点的运行已中止"
 			}
+		} else if err == nil {
+			missionRecord.Status = 1
+			missionRecord.Message = "ok"
 		} else {
 			missionRecord.Status = 2
 			missionRecord.Message = err.Error()
@@ -251,7 +268,7 @@ func RunTask(mission model.Task, runBy string) (err error) {
 				return errors.New("数据源未指定")
 			}
 			var dataSourceData model.DataSource
-			err = model.DB.Where("`id` = ?", mission.Data.BeforeExecute.DataSource).First(&dataSourceData).Error
+			err = model.DB.Where("id = ?", mission.Data.BeforeExecute.DataSource).First(&dataSourceData).Error
 			if err != nil {
 				return errors.New("数据源不存在")
 			}
@@ -296,7 +313,7 @@ func RunTask(mission model.Task, runBy string) (err error) {
 			return errors.New("数据源未指定")
 		}
 		var dataSourceData model.DataSource
-		err = model.DB.Where("`id` = ?", mission.Data.Source.DataSource).First(&dataSourceData).Error
+		err = model.DB.Where("id = ?", mission.Data.Source.DataSource).First(&dataSourceData).Error
 		if err != nil {
 			return errors.New("数据源不存在")
 		}
@@ -339,7 +356,7 @@ func RunTask(mission model.Task, runBy string) (err error) {
 			return errors.New("数据源未指定")
 		}
 		var dataSourceData model.DataSource
-		err = model.DB.Where("`id` = ?", mission.Data.Sinks.DataSource).First(&dataSourceData).Error
+		err = model.DB.Where("id = ?", mission.Data.Sinks.DataSource).First(&dataSourceData).Error
 		if err != nil {
 			return errors.New("数据源不存在")
 		}
@@ -404,7 +421,7 @@ func RunTask(mission model.Task, runBy string) (err error) {
 				return errors.New("数据源未指定")
 			}
 			var dataSourceData model.DataSource
-			err = model.DB.Where("`id` = ?", mission.Data.AfterExecute.DataSource).First(&dataSourceData).Error
+			err = model.DB.Where("id = ?", mission.Data.AfterExecute.DataSource).First(&dataSourceData).Error
 			if err != nil {
 				return errors.New("数据源不存在")
 			}
@@ -433,32 +450,73 @@ func RunTask(mission model.Task, runBy string) (err error) {
 		}
 	}
 	engine := pipeline.NewEngine(missionRecord.ID, BeforeExecutor, BeforeExecutorDatasource, Source, SourceDatasource, processors, Sink, SinkDatasource, cfg, AfterExecutor, AfterExecutorDatasource)
-	ctx := context.Background()
+	var watermark model.TaskWatermark
+	model.DB.Where("task_id = ?", mission.ID).Limit(1).Find(&watermark)
+	engine.SetWatermark(watermark.Value)
 	runCtx, cancel := context.WithCancel(ctx)
-	defer delete(runCtxMap, missionRecord.ID)
 	defer cancel()
+	runMu.Lock()
 	runCtxMap[missionRecord.ID] = cancel
+	runMu.Unlock()
+	defer func() {
+		runMu.Lock()
+		delete(runCtxMap, missionRecord.ID)
+		runMu.Unlock()
+	}()
 	if err := engine.Run(missionRecord.ID, runCtx, BeforeExecutorConfig, SourceConfig, processorsConfigs, SinkConfig, AfterExecutorConfig); err != nil {
 		return err
 	}
+	// 运行成功后才提交水位线，失败或被中止的运行下次会从上一次成功的位置重新抽取
+	if runCtx.Err() == nil && engine.Watermark() != watermark.Value {
+		watermark.TaskID = mission.ID
+		watermark.Value = engine.Watermark()
+		if err := model.DB.Save(&watermark).Error; err != nil {
+			return fmt.Errorf("增量水位线保存失败: %w", err)
+		}
+	}
 	return nil
 }
 
+// ManualCancelMap 记录被中止的运行及中止原因。
 var ManualCancelMap = make(map[string]string)
 
+const (
+	cancelManual    = "cancel" // 手动中止
+	cancelLeaseLost = "lease"  // 租约被其他节点接管
+)
+
 func CancelMissionRecord(ID string) error {
-	if cancel, ok := runCtxMap[ID]; ok {
+	runMu.Lock()
+	cancel, ok := runCtxMap[ID]
+	if ok {
+		ManualCancelMap[ID] = cancelManual
+	}
+	runMu.Unlock()
+	if ok {
 		cancel()
-		ManualCancelMap[ID] = "cancel"
 		return nil
-	} else {
+	}
+	// 任务运行在其他节点上，写入中止请求，由执行节点的心跳协程处理
+	tx := model.DB.Model(&model.TaskRecord{}).Where("id = ? AND status = ?", ID, 0).UpdateColumn("cancel_requested", true)
+	if tx.Error != nil || tx.RowsAffected != 1 {
 		return errors.New("任务不存在或状态不可停止")
 	}
+	return nil
 }
 
 func GetValueByName(name string) (string, error) {
+	return getValueByName(name, make(map[string]bool))
+}
+
+// getValueByName 获取变量值，变量自身的 :other 占位符会先解析同名变量再绑定，resolving 用于发现循环引用。
+func getValueByName(name string, resolving map[string]bool) (string, error) {
+	if resolving[name] {
+		return "", fmt.Errorf("variable %s is referenced recursively", name)
+	}
+	resolving[name] = true
+	defer delete(resolving, name)
 	var variable model.Variable
-	err := model.DB.Where("`name` = ?", name).Preload("DataSource").First(&variable).Error
+	err := model.DB.Where("name = ?", name).Preload("DataSource").First(&variable).Error
 	if err != nil {
 		return "", errors.New("variable does not exist")
 	}
@@ -470,13 +528,31 @@ func GetValueByName(name string) (string, error) {
 	for _, param := range *variable.Value {
 		variableConfig[param.Key] = param.Value
 	}
+	for _, param := range *variable.Value {
+		for _, name := range namedParameters(param.Value) {
+			key := params.BindPrefix + name
+			if _, ok := variableConfig[key]; ok {
+				continue
+			}
+			var count int64
+			model.DB.Model(&model.Variable{}).Where("name = ?", name).Count(&count)
+			if count == 0 {
+				continue
+			}
+			value, err := getValueByName(name, resolving)
+			if err != nil {
+				return "", err
+			}
+			variableConfig[key] = value
+		}
+	}
 	var vDatasource *datasource.Datasource
 	if v.Datasource != nil {
 		if variable.DataSource.ID == "" {
 			return "", errors.New("variable data source does not exist")
 		}
 		var dataSourceData model.DataSource
-		err := model.DB.Where("`name` = ?", variable.DataSource.Name).Find(&dataSourceData).Error
+		err := model.DB.Where("name = ?", variable.DataSource.Name).Find(&dataSourceData).Error
 		if err != nil {
 			return "", errors.New("variable data source does not exist")
 		}
@@ -0,0 +1,127 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BernardSimon/etl-go/etl/core/params"
+	"github.com/BernardSimon/etl-go/server/model"
+	_type "github.com/BernardSimon/etl-go/server/type"
+)
+
+// 任务配置使用变量的两种方式：
+//   - 绑定(默认)：SQL 中的 :name 或 :name:type 占位符引用同名变量，变量值以 bind.name 参数传给组件，由组件作为驱动参数绑定；
+//   - 模板(需开启 template_variables)：配置中的 ${name} 被替换为变量值的文本。
+
+var templatePattern = regexp.MustCompile(`\$\{[^}]*}`)
+
+// prepareTaskData 解析任务配置中引用的变量，返回可直接执行的任务配置与解析到的变量值。
+func prepareTaskData(data *_type.TaskData) (*_type.TaskData, map[string]string, error) {
+	if data == nil {
+		return nil, nil, nil
+	}
+	values := make(map[string]string)
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	stringData := string(rawData)
+	matches := templatePattern.FindAllString(stringData, -1)
+	if len(matches) != 0 {
+		if !data.TemplateVariables {
+			return nil, nil, fmt.Errorf("任务配置中使用了变量模板 %s，请开启变量模板替换或改用 :name 占位符绑定变量", matches[0])
+		}
+		for _, match := range matches {
+			name := strings.TrimSuffix(strings.TrimPrefix(match, "${"), "}")
+			if _, ok := values[name]; ok {
+				continue
+			}
+			value, err := GetValueByName(name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("变量 %s 解析错误: %w", name, err)
+			}
+			values[name] = value
+			// 按 JSON 字符串转义后再替换，变量值中的引号不会破坏任务配置
+			escaped, _ := json.Marshal(value)
+			stringData = strings.ReplaceAll(stringData, match, string(escaped[1:len(escaped)-1]))
+		}
+	}
+	var prepared _type.TaskData
+	if err := json.Unmarshal([]byte(stringData), &prepared); err != nil {
+		return nil, nil, fmt.Errorf("任务变量配置解析错误: %w", err)
+	}
+	if err := bindVariables(&prepared, values); err != nil {
+		return nil, nil, err
+	}
+	return &prepared, values, nil
+}
+
+// bindVariables 为每个组件追加其参数中引用到的变量，变量值以 bind.name 参数传入。
+// 与变量同名的占位符才会被绑定，其余形如 :name 的文本由组件原样保留在 SQL 中。
+func bindVariables(data *_type.TaskData, values map[string]string) error {
+	components := data.Components()
+	referenced := make([][]string, len(components))
+	var names []string
+	for i, component := range components {
+		seen := make(map[string]bool)
+		for _, param := range *component {
+			if strings.HasPrefix(param.Key, params.BindPrefix) {
+				continue
+			}
+			for _, name := range namedParameters(param.Value) {
+				if !seen[name] {
+					seen[name] = true
+					referenced[i] = append(referenced[i], name)
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	var existing []string
+	if err := model.DB.Model(&model.Variable{}).Where("name IN ?", names).Pluck("name", &existing).Error; err != nil {
+		return err
+	}
+	isVariable := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		isVariable[name] = true
+	}
+	for i, component := range components {
+		for _, name := range referenced[i] {
+			if !isVariable[name] {
+				continue
+			}
+			value, ok := values[name]
+			if !ok {
+				var err error
+				value, err = GetValueByName(name)
+				if err != nil {
+					return fmt.Errorf("变量 %s 解析错误: %w", name, err)
+				}
+				values[name] = value
+			}
+			*component = append(*component, _type.TaskParam{Key: params.BindPrefix + name, Value: value})
+		}
+	}
+	return nil
+}
+
+// namedParameters 返回配置值中命名占位符的名称。服务端不知道组件的数据库方言，
+// 按反斜杠转义与不转义两种规则解析后合并，多出的名称只会多传一个组件不会使用的 bind 参数。
+func namedParameters(value string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, backslashEscapes := range []bool{false, true} {
+		for _, p := range params.ParseNamedParameters(value, backslashEscapes) {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				names = append(names, p.Name)
+			}
+		}
+	}
+	return names
+}
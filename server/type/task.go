@@ -10,44 +10,53 @@ import (
 
 type TaskData struct {
 	BeforeExecute *struct {
-		Type       string  `json:"type"`
-		DataSource *string `json:"data_source"`
-		Params     []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"params"`
+		Type       string      `json:"type"`
+		DataSource *string     `json:"data_source"`
+		Params     []TaskParam `json:"params"`
 	} `json:"before_execute"`
 	Source struct {
-		Type       string  `json:"type"`
-		DataSource *string `json:"data_source"`
-		Params     []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"params"`
+		Type       string      `json:"type"`
+		DataSource *string     `json:"data_source"`
+		Params     []TaskParam `json:"params"`
 	} `json:"source"`
 	Processors []struct {
-		Type   string `json:"type"`
-		Params []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"params"`
+		Type   string      `json:"type"`
+		Params []TaskParam `json:"params"`
 	} `json:"processors"`
 	Sinks struct {
-		Type       string  `json:"type"`
-		DataSource *string `json:"data_source"`
-		Params     []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"params"`
+		Type       string      `json:"type"`
+		DataSource *string     `json:"data_source"`
+		Params     []TaskParam `json:"params"`
 	} `json:"sink"`
 	AfterExecute *struct {
-		Type       string  `json:"type"`
-		DataSource *string `json:"data_source"`
-		Params     []struct {
-			Key   string `json:"key"`
-			Value string `json:"value"`
-		} `json:"params"`
+		Type       string      `json:"type"`
+		DataSource *string     `json:"data_source"`
+		Params     []TaskParam `json:"params"`
 	} `json:"after_execute"`
+	// TemplateVariables 开启后，配置中的 ${name} 会被替换为变量值的文本；
+	// 默认关闭，变量应通过 SQL 中的 :name 占位符以驱动参数的方式绑定。
+	TemplateVariables bool `json:"template_variables"`
+}
+
+// TaskParam 是任务中单个组件的参数。
+type TaskParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Components 返回任务中配置的所有组件的参数列表，用于统一处理变量。
+func (ct *TaskData) Components() []*[]TaskParam {
+	components := []*[]TaskParam{&ct.Source.Params, &ct.Sinks.Params}
+	if ct.BeforeExecute != nil {
+		components = append(components, &ct.BeforeExecute.Params)
+	}
+	if ct.AfterExecute != nil {
+		components = append(components, &ct.AfterExecute.Params)
+	}
+	for i := range ct.Processors {
+		components = append(components, &ct.Processors[i].Params)
+	}
+	return components
 }
 
 func (ct *TaskData) Value() (driver.Value, error) {
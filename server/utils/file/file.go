@@ -116,6 +116,18 @@ func GetFilePath(id string) (string, error) {
 	return absPath, nil
 }
 
+// GetFileName 返回文件上传或生成时的名称，名称不含扩展名时补上扩展名。
+func GetFileName(id string) (string, error) {
+	var files model.File
+	if err := model.DB.Where("id = ?", id).First(&files).Error; err != nil {
+		return "", errors.New("file record does not exist")
+	}
+	if strings.HasSuffix(files.Name, files.ExName) {
+		return files.Name, nil
+	}
+	return files.Name + files.ExName, nil
+}
+
 func SetOutputFile(fileName string, exName string) model.File {
 	var file = model.File{
 		Model: model.Model{
@@ -48,6 +48,7 @@ export interface MissionData {
   processors: ProcessorConfig[];
   sink: ConfigItem;
   after_execute: ConfigItem | null;
+  template_variables?: boolean;
 }
 
 /**
@@ -104,6 +105,7 @@ export interface MissionFormData {
   processors: ProcessorConfig[];
   sink: ConfigItem;
   after_execute: ConfigItem;
+  template_variables: boolean;
   task_type?: TaskType;
 }
 